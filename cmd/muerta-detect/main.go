@@ -0,0 +1,131 @@
+// Command muerta-detect evaluates the shelf life detector on a dataset
+// directory: the images with the dates printed on them labelled in the
+// labels.json file, like the one exported from the corrected detections.
+//
+//	muerta-detect [flags] <dataset directory>
+//
+// It prints the dates detected on each image and the precision, recall and
+// exact-date accuracy of the manufacturing and the expiry dates, as JSON if
+// -json is set. With -text the images are not recognized and the dates are
+// extracted from the text labelled with them, which only tests the
+// extraction.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+)
+
+func main() {
+	var (
+		engine  = flag.String("engine", sldetector.EngineTesseract, "OCR engine: tesseract or http")
+		url     = flag.String("url", "", "URL of the OCR service of the http engine")
+		token   = flag.String("token", "", "bearer token of the OCR service")
+		timeout = flag.Duration("timeout", time.Minute, "time limit of the detection of an image")
+		text    = flag.Bool("text", false, "extract the dates from the labelled text instead of the images")
+		asJSON  = flag.Bool("json", false, "print the report as JSON")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] <dataset directory>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	samples, err := sldetector.LoadDataset(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("dataset load: %v", err)
+	}
+
+	detect := extract
+	if !*text {
+		var ocr sldetector.OCREngine
+		switch *engine {
+		case sldetector.EngineTesseract:
+			ocr, err = sldetector.NewTesseractEngine(1, *timeout)
+			if err != nil {
+				log.Fatalf("engine create: %v", err)
+			}
+		case sldetector.EngineHTTP:
+			ocr = sldetector.NewHTTPEngine(*url, *token)
+		default:
+			log.Fatalf("unknown engine %q", *engine)
+		}
+		detector := sldetector.New(ocr, *timeout)
+		defer detector.Close()
+		detect = func(sample sldetector.Sample) (sldetector.Detection, error) {
+			return detector.DetectAt(context.Background(), sample.Image, sample.Label.DetectedAt)
+		}
+	}
+
+	report := sldetector.Evaluate(samples, detect)
+	if *asJSON {
+		err = printJSON(os.Stdout, report)
+	} else {
+		err = printTable(os.Stdout, report)
+	}
+	if err != nil {
+		log.Fatalf("report print: %v", err)
+	}
+}
+
+// extract extracts the dates from the labelled text of the sample.
+func extract(sample sldetector.Sample) (sldetector.Detection, error) {
+	return sldetector.Detection{
+		Text:  sample.Label.Text,
+		Dates: sldetector.ExtractDates(sample.Label.Text, sample.Label.DetectedAt),
+	}, nil
+}
+
+func printJSON(w io.Writer, report sldetector.Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func printTable(w io.Writer, report sldetector.Report) error {
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "IMAGE\tMANUFACTURED\tEXPECTED\tEXPIRY\tEXPECTED\tLATENCY\tERROR")
+	for _, result := range report.Results {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%dms\t%s\n",
+			result.Name,
+			formatDate(result.Manufactured),
+			formatDate(result.ExpectedManufactured),
+			formatDate(result.Expiry),
+			formatDate(result.ExpectedExpiry),
+			result.LatencyMs,
+			result.Error,
+		)
+	}
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, "DATE\tPRECISION\tRECALL\tACCURACY")
+	for _, metrics := range []struct {
+		name string
+		sldetector.Metrics
+	}{
+		{sldetector.DateManufactured, report.Manufactured},
+		{sldetector.DateExpiry, report.Expiry},
+	} {
+		fmt.Fprintf(table, "%s\t%.3f\t%.3f\t%.3f\n",
+			metrics.name, metrics.Precision, metrics.Recall, metrics.Accuracy)
+	}
+	return table.Flush()
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return "-"
+	}
+	return date.Format(time.DateOnly)
+}
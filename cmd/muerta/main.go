@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -13,7 +14,13 @@ import (
 	"github.com/romankravchuk/muerta/internal/api"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/services/notification"
+	shelflife "github.com/romankravchuk/muerta/internal/services/shelf-life"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	notificationrepo "github.com/romankravchuk/muerta/internal/storage/postgres/notification"
+	slrepo "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+	statusrepo "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life-status"
+	userrepo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
 	"github.com/romankravchuk/muerta/internal/storage/redis"
 )
 
@@ -58,13 +65,56 @@ func init() {
 func main() {
 	logger := logger.New()
 	api := api.New(cfg, client, cache, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	evaluator := shelflife.NewStatusEvaluator(
+		slrepo.New(client),
+		statusrepo.New(client),
+		cfg.ShelfLife.ExpiringThreshold,
+		logger.GetLogger(),
+	)
+	notifier := newNotifier(logger)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		evaluator.Run(ctx, cfg.ShelfLife.EvaluateInterval)
+	}()
+	go func() {
+		defer wg.Done()
+		notifier.Run(ctx, cfg.Notifications.Interval)
+	}()
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
 		log.Println("Gracefully shutting down...")
+		cancel()
+		wg.Wait()
 		cfg.ShutdownShelfDetectorChan <- struct{}{}
 		_ = api.Shutdown()
 	}()
 	log.Fatalf("api run: %v", api.Run())
 }
+
+// newNotifier creates the notifier with the inbox and webhook channels, and
+// the SMTP channel when the mail server is configured.
+func newNotifier(logger logger.Logger) notification.Notifier {
+	repo := notificationrepo.New(client)
+	channels := []notification.Channel{
+		notification.NewInboxChannel(repo),
+		notification.NewWebhookChannel(cfg.Notifications.WebhookTimeout),
+	}
+	if smtp := cfg.Notifications.SMTP; smtp.Host != "" {
+		channels = append(
+			channels,
+			notification.NewSMTPChannel(smtp.Host, smtp.Port, smtp.User, smtp.Password, smtp.From, smtp.Timeout),
+		)
+	}
+	return notification.NewNotifier(
+		userrepo.New(client),
+		repo,
+		channels,
+		cfg.Notifications.Thresholds,
+		logger.GetLogger(),
+	)
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	errs "errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/calendar"
+)
+
+type CalendarController struct {
+	svc service.CalendarServicer
+	log logger.Logger
+}
+
+func New(svc service.CalendarServicer, log logger.Logger) *CalendarController {
+	return &CalendarController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// CreateToken godoc
+//
+//	@Summary		Create calendar token
+//	@Description	Create a token for the calendar feed of the user. The previous token is revoked
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int	true	"User ID"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/calendar/token [post]
+//	@Security		Bearer
+func (h *CalendarController) CreateToken(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	token, err := h.svc.CreateToken(ctx.Context(), id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data: controllers.Data{
+			"token": token,
+			"url":   ctx.BaseURL() + "/api/v1/calendar/" + token + ".ics",
+		},
+	})
+}
+
+// RevokeToken godoc
+//
+//	@Summary		Revoke calendar token
+//	@Description	Revoke the token for the calendar feed of the user
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int	true	"User ID"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/calendar/token [delete]
+//	@Security		Bearer
+func (h *CalendarController) RevokeToken(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	if err := h.svc.RevokeToken(ctx.Context(), id); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// Feed godoc
+//
+//	@Summary		Calendar feed
+//	@Description	Find expiry dates of the user as an iCalendar feed
+//	@Tags			Calendar
+//	@Produce		text/calendar
+//	@Param			token	path		string	true	"Calendar token"
+//	@Success		200		{string}	string
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/calendar/{token}.ics [get]
+func (h *CalendarController) Feed(ctx *fiber.Ctx) error {
+	feed, err := h.svc.Feed(ctx.Context(), ctx.Params("token"))
+	if err != nil {
+		if errs.Is(err, errors.ErrInvalidCalendarToken) {
+			h.log.Error(ctx, logger.Client, err)
+			return ctx.Status(http.StatusNotFound).
+				JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+		}
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	ctx.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	return ctx.Send(feed)
+}
@@ -0,0 +1,18 @@
+package calendar
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	svc "github.com/romankravchuk/muerta/internal/services/calendar"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+// NewRouter serves the calendar feeds. The feeds are authorized by the token
+// in the path because calendar clients can't send the Authorization header.
+func NewRouter(client postgres.Client, log logger.Logger) *fiber.App {
+	r := fiber.New()
+	h := New(svc.New(repo.New(client)), log)
+	r.Get("/:token.ics", h.Feed)
+	return r
+}
@@ -0,0 +1,418 @@
+package household
+
+import (
+	errs "errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/household"
+)
+
+type HouseholdController struct {
+	svc service.HouseholdServicer
+	log logger.Logger
+}
+
+func New(svc service.HouseholdServicer, log logger.Logger) *HouseholdController {
+	return &HouseholdController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// FindMany godoc
+//
+//	@Summary		Find households
+//	@Description	Find households the user is a member of with the role of the user
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handlers.HTTPSuccess
+//	@Failure		502	{object}	handlers.HTTPError
+//	@Router			/households [get]
+//	@Security		Bearer
+func (h *HouseholdController) FindMany(ctx *fiber.Ctx) error {
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindHouseholds(ctx.Context(), user)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"households": result},
+	})
+}
+
+// FindOne godoc
+//
+//	@Summary		Find household
+//	@Description	Find household with the role of the user
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household} [get]
+//	@Security		Bearer
+func (h *HouseholdController) FindOne(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindHousehold(ctx.Context(), user, id)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"household": result},
+	})
+}
+
+// Create godoc
+//
+//	@Summary		Create household
+//	@Description	Create household owned by the user
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.CreateHousehold	true	"Household"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/households [post]
+//	@Security		Bearer
+func (h *HouseholdController) Create(ctx *fiber.Ctx) error {
+	payload := new(params.CreateHousehold)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.CreateHousehold(ctx.Context(), user, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"household": result},
+	})
+}
+
+// Update godoc
+//
+//	@Summary		Update household
+//	@Description	Rename household, owners only
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int					true	"Household ID"
+//	@Param			payload			body		dto.UpdateHousehold	true	"Household"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household} [put]
+//	@Security		Bearer
+func (h *HouseholdController) Update(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	payload := new(params.UpdateHousehold)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.UpdateHousehold(ctx.Context(), user, id, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"household": result},
+	})
+}
+
+// Delete godoc
+//
+//	@Summary		Delete household
+//	@Description	Delete household, owners only. The storages stay shared with the users they were shared with directly
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household} [delete]
+//	@Security		Bearer
+func (h *HouseholdController) Delete(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	user, _ := access.Payload(ctx)
+	if err := h.svc.DeleteHousehold(ctx.Context(), user, id); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// FindMembers godoc
+//
+//	@Summary		Find household members
+//	@Description	Find members of the household with their roles
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/members [get]
+//	@Security		Bearer
+func (h *HouseholdController) FindMembers(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindMembers(ctx.Context(), user, id)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"members": result},
+	})
+}
+
+// UpdateMember godoc
+//
+//	@Summary		Update household member
+//	@Description	Change the role of the member, owners only. The last owner can't be demoted
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int							true	"Household ID"
+//	@Param			id_member		path		int							true	"User ID"
+//	@Param			payload			body		dto.UpdateHouseholdMember	true	"Member"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		409				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/members/{id_member} [put]
+//	@Security		Bearer
+func (h *HouseholdController) UpdateMember(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	memberID := ctx.Locals(context.MemberID).(int)
+	payload := new(params.UpdateHouseholdMember)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	user, _ := access.Payload(ctx)
+	if err := h.svc.UpdateMember(ctx.Context(), user, id, memberID, payload); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// RemoveMember godoc
+//
+//	@Summary		Remove household member
+//	@Description	Remove the member, owners only. Members can leave the household on their own
+//	@Description	unless they are the last owner
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Param			id_member		path		int	true	"User ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		409				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/members/{id_member} [delete]
+//	@Security		Bearer
+func (h *HouseholdController) RemoveMember(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	memberID := ctx.Locals(context.MemberID).(int)
+	user, _ := access.Payload(ctx)
+	if err := h.svc.RemoveMember(ctx.Context(), user, id, memberID); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// FindStorages godoc
+//
+//	@Summary		Find household storages
+//	@Description	Find storages owned by the household
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/storages [get]
+//	@Security		Bearer
+func (h *HouseholdController) FindStorages(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindStorages(ctx.Context(), user, id)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"storages": result},
+	})
+}
+
+// AddStorage godoc
+//
+//	@Summary		Add household storage
+//	@Description	Give the storage to the household, owners only. The owner must be able to change the storage
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Param			id_storage		path		int	true	"Storage ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		409				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/storages/{id_storage} [post]
+//	@Security		Bearer
+func (h *HouseholdController) AddStorage(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	storageID := ctx.Locals(context.StorageID).(int)
+	user, _ := access.Payload(ctx)
+	if err := h.svc.AddStorage(ctx.Context(), user, id, storageID); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// RemoveStorage godoc
+//
+//	@Summary		Remove household storage
+//	@Description	Take the storage from the household, owners only
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int	true	"Household ID"
+//	@Param			id_storage		path		int	true	"Storage ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/storages/{id_storage} [delete]
+//	@Security		Bearer
+func (h *HouseholdController) RemoveStorage(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	storageID := ctx.Locals(context.StorageID).(int)
+	user, _ := access.Payload(ctx)
+	if err := h.svc.RemoveStorage(ctx.Context(), user, id, storageID); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// CreateInvitation godoc
+//
+//	@Summary		Create household invitation
+//	@Description	Create a single use code to join the household, owners only. The code expires in 7 days
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_household	path		int								true	"Household ID"
+//	@Param			payload			body		dto.CreateHouseholdInvitation	true	"Invitation"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/households/{id_household}/invitations [post]
+//	@Security		Bearer
+func (h *HouseholdController) CreateInvitation(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.HouseholdID).(int)
+	payload := new(params.CreateHouseholdInvitation)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.CreateInvitation(ctx.Context(), user, id, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"invitation": result},
+	})
+}
+
+// Join godoc
+//
+//	@Summary		Join household
+//	@Description	Join the household with the invitation code
+//	@Tags			Households
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		dto.JoinHousehold	true	"Invitation code"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/households/join [post]
+//	@Security		Bearer
+func (h *HouseholdController) Join(ctx *fiber.Ctx) error {
+	payload := new(params.JoinHousehold)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.Join(ctx.Context(), user, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"household": result},
+	})
+}
+
+func (h *HouseholdController) badRequest(ctx *fiber.Ctx, err error) error {
+	if err, ok := err.(validator.ValidationErrors); ok {
+		h.log.Error(ctx, logger.Validation, err)
+	} else {
+		h.log.Error(ctx, logger.Client, err)
+	}
+	return ctx.Status(http.StatusBadRequest).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+}
+
+func (h *HouseholdController) fail(ctx *fiber.Ctx, err error) error {
+	h.log.Error(ctx, logger.Server, err)
+	switch {
+	case errs.Is(err, errors.ErrHouseholdNotFound),
+		errs.Is(err, errors.ErrMemberNotFound),
+		errs.Is(err, errors.ErrInvalidInvitation):
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	case errs.Is(err, errors.ErrNotOwner):
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	case errs.Is(err, errors.ErrLastOwner),
+		errs.Is(err, errors.ErrStorageInHousehold):
+		return ctx.Status(http.StatusConflict).
+			JSON(controllers.HTTPError{Error: fiber.ErrConflict.Error()})
+	}
+	return ctx.Status(http.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
@@ -0,0 +1,50 @@
+package household
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	svc "github.com/romankravchuk/muerta/internal/services/household"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/household"
+	storagerepo "github.com/romankravchuk/muerta/internal/storage/postgres/storage"
+)
+
+func NewRouter(
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
+	router := fiber.New()
+	svc := svc.New(repo.New(client), storagerepo.New(client))
+	handler := New(svc, log)
+	router.Use(jware.DeserializeUser)
+	router.Get("/", handler.FindMany)
+	router.Post("/", handler.Create)
+	router.Post("/join", handler.Join)
+	router.Route(context.HouseholdID.Path(), func(router fiber.Router) {
+		router.Use(context.New(log, context.HouseholdID))
+		router.Get("/", handler.FindOne)
+		router.Put("/", handler.Update)
+		router.Delete("/", handler.Delete)
+		router.Route("/members", func(router fiber.Router) {
+			router.Get("/", handler.FindMembers)
+			router.Route(context.MemberID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.MemberID))
+				router.Put("/", handler.UpdateMember)
+				router.Delete("/", handler.RemoveMember)
+			})
+		})
+		router.Route("/storages", func(router fiber.Router) {
+			router.Get("/", handler.FindStorages)
+			router.Route(context.StorageID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.StorageID))
+				router.Post("/", handler.AddStorage)
+				router.Delete("/", handler.RemoveStorage)
+			})
+		})
+		router.Post("/invitations", handler.CreateInvitation)
+	})
+	return router
+}
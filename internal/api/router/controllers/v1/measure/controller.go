@@ -1,6 +1,7 @@
 package measure
 
 import (
+	errs "errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -9,19 +10,27 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/api/router/utils"
 	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
 	service "github.com/romankravchuk/muerta/internal/services/measure"
 )
 
 type MeasureController struct {
-	svc service.MeasureServicer
-	log logger.Logger
+	svc  service.MeasureServicer
+	conv conversion.ConversionServicer
+	log  logger.Logger
 }
 
-func New(svc service.MeasureServicer, log logger.Logger) MeasureController {
+func New(
+	svc service.MeasureServicer,
+	conv conversion.ConversionServicer,
+	log logger.Logger,
+) MeasureController {
 	return MeasureController{
-		svc: svc,
-		log: log,
+		svc:  svc,
+		conv: conv,
+		log:  log,
 	}
 }
 
@@ -186,3 +195,50 @@ func (h *MeasureController) Delete(ctx *fiber.Ctx) error {
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// Convert converts a quantity of a product between measures.
+//
+//	@Summary		Convert a quantity
+//	@Description	Converts a quantity between measures. Measures of different dimensions are converted
+//	@Description	by the density and the piece weight of the product.
+//	@Tags			Measures
+//	@Accept			json
+//	@Produce		json
+//	@Param			query	query		dto.ConvertQuantity	true	"Quantity to convert"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		422		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/measures/convert [get]
+func (h *MeasureController) Convert(ctx *fiber.Ctx) error {
+	payload := new(params.ConvertQuantity)
+	if err := utils.ParseFilterAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.conv.Convert(ctx.Context(), payload)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrMeasureNotFound) {
+			return ctx.Status(http.StatusNotFound).
+				JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+		}
+		if errs.Is(err, errors.ErrMeasuresNotConvertible) {
+			return ctx.Status(http.StatusUnprocessableEntity).
+				JSON(controllers.HTTPError{Error: fiber.ErrUnprocessableEntity.Error()})
+		}
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"conversion": result},
+	})
+}
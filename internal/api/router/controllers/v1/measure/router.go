@@ -6,9 +6,11 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
 	service "github.com/romankravchuk/muerta/internal/services/measure"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	repository "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
 )
 
 func NewRouter(
@@ -19,8 +21,9 @@ func NewRouter(
 	router := fiber.New()
 	repo := repository.New(client)
 	svc := service.New(repo)
-	handler := New(svc, log)
+	handler := New(svc, conversion.New(repo, product.New(client)), log)
 	router.Get("/", handler.FindMany)
+	router.Get("/convert", handler.Convert)
 	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
 	router.Route(context.MeasureID.Path(), func(router fiber.Router) {
 		router.Use(context.New(log, context.MeasureID))
@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/notification"
+)
+
+type NotificationController struct {
+	svc service.NotificationServicer
+	log logger.Logger
+}
+
+func New(svc service.NotificationServicer, log logger.Logger) *NotificationController {
+	return &NotificationController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// FindMany godoc
+//
+//	@Summary		Find user notifications
+//	@Description	Find notifications from the user inbox
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int						true	"User ID"
+//	@Param			filter	query		dto.NotificationFilter	true	"Filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/notifications [get]
+//	@Security		Bearer
+func (h *NotificationController) FindMany(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	filter := new(params.NotificationFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindNotifications(ctx.Context(), id, filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	count, err := h.svc.Count(ctx.Context(), id, *filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"notifications": result, "count": count},
+	})
+}
+
+// MarkRead godoc
+//
+//	@Summary		Mark notification read
+//	@Description	Mark notification from the user inbox as read
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user			path		int	true	"User ID"
+//	@Param			id_notification	path		int	true	"Notification ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/notifications/{id_notification}/read [post]
+//	@Security		Bearer
+func (h *NotificationController) MarkRead(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	notificationID := ctx.Locals(context.NotificationID).(int)
+	if err := h.svc.MarkRead(ctx.Context(), id, notificationID); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// MarkUnread godoc
+//
+//	@Summary		Mark notification unread
+//	@Description	Mark notification from the user inbox as unread
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user			path		int	true	"User ID"
+//	@Param			id_notification	path		int	true	"Notification ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/notifications/{id_notification}/unread [post]
+//	@Security		Bearer
+func (h *NotificationController) MarkUnread(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.UserID).(int)
+	notificationID := ctx.Locals(context.NotificationID).(int)
+	if err := h.svc.MarkUnread(ctx.Context(), id, notificationID); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
@@ -206,3 +206,130 @@ func (h *ProductCategoryController) Restore(ctx *fiber.Ctx) error {
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// FindDurations finds default durations of a product category
+//
+//	@Summary		Find default durations of a product category
+//	@Description	Finds the usual lifetimes of products of a category used when a shelf life is created without an end date
+//	@Tags			Product Categories
+//	@Param			category_id	path		integer	true	"Category ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/product-categories/{category_id}/durations [get]
+func (h *ProductCategoryController) FindDurations(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.CategoryID).(int)
+	result, err := h.svc.FindCategoryDurations(ctx.Context(), id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"durations": result},
+	})
+}
+
+// UpdateDurations replaces default durations of a product category
+//
+//	@Summary		Update default durations of a product category
+//	@Description	Replaces the usual lifetimes of products of a category, optionally per storage type
+//	@Tags			Product Categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			category_id	path		integer							true	"Category ID"
+//	@Param			payload		body		dto.UpdateDefaultDurations	true	"Durations"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/product-categories/{category_id}/durations [put]
+//	@Security		Bearer
+func (h *ProductCategoryController) UpdateDurations(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.CategoryID).(int)
+	payload := new(params.UpdateDefaultDurations)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.UpdateCategoryDurations(ctx.Context(), id, payload)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"durations": result},
+	})
+}
+
+// FindTips finds tips of a product category
+//
+//	@Summary		Find tips of a product category
+//	@Description	Finds tips shown for the shelf lives of products of the category
+//	@Tags			Product Categories
+//	@Param			category_id	path		integer	true	"Category ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/product-categories/{category_id}/tips [get]
+func (h *ProductCategoryController) FindTips(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.CategoryID).(int)
+	result, err := h.svc.FindTips(ctx.Context(), id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"tips": result}})
+}
+
+// AddTip adds a tip to a product category
+//
+//	@Summary		Add tip to a product category
+//	@Description	Adds a tip to a product category
+//	@Tags			Product Categories
+//	@Param			category_id	path		integer	true	"Category ID"
+//	@Param			id_tip		path		integer	true	"Tip ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/product-categories/{category_id}/tips/{id_tip} [post]
+//	@Security		Bearer
+func (h *ProductCategoryController) AddTip(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.CategoryID).(int)
+	tipID := ctx.Locals(context.TipID).(int)
+	result, err := h.svc.CreateTip(ctx.Context(), id, tipID)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"tip": result}})
+}
+
+// RemoveTip removes a tip from a product category
+//
+//	@Summary		Remove tip from a product category
+//	@Description	Removes a tip from a product category
+//	@Tags			Product Categories
+//	@Param			category_id	path		integer	true	"Category ID"
+//	@Param			id_tip		path		integer	true	"Tip ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/product-categories/{category_id}/tips/{id_tip} [delete]
+//	@Security		Bearer
+func (h *ProductCategoryController) RemoveTip(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.CategoryID).(int)
+	tipID := ctx.Locals(context.TipID).(int)
+	if err := h.svc.DeleteTip(ctx.Context(), id, tipID); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
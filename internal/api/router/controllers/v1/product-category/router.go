@@ -25,6 +25,18 @@ func NewRouter(
 	router.Route(context.CategoryID.Path(), func(router fiber.Router) {
 		router.Use(context.New(log, context.CategoryID))
 		router.Get("/", handler.FindOne)
+		router.Route("/durations", func(router fiber.Router) {
+			router.Get("/", handler.FindDurations)
+			router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.UpdateDurations)
+		})
+		router.Route("/tips", func(router fiber.Router) {
+			router.Get("/", handler.FindTips)
+			router.Route(context.TipID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.TipID))
+				router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.AddTip)
+				router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.RemoveTip)
+			})
+		})
 		router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.Update)
 		router.Patch("/", jware.DeserializeUser, access.AdminOnly(log), handler.Restore)
 		router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.Delete)
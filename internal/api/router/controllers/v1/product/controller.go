@@ -366,3 +366,127 @@ func (h *ProductController) RemoveTip(ctx *fiber.Ctx) error {
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// FindDurations finds default durations of a product
+//
+//	@Summary		Find default durations of a product
+//	@Description	Finds the usual lifetimes of a product used when a shelf life is created without an end date
+//	@Tags			Products
+//	@Param			product_id	path		integer	true	"Product ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/{product_id}/durations [get]
+func (h *ProductController) FindDurations(ctx *fiber.Ctx) error {
+	productID := ctx.Locals(context.ProductID).(int)
+	result, err := h.svc.FindProductDurations(ctx.Context(), productID)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"durations": result},
+	})
+}
+
+// UpdateDurations replaces default durations of a product
+//
+//	@Summary		Update default durations of a product
+//	@Description	Replaces the usual lifetimes of a product, optionally per storage type
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		integer							true	"Product ID"
+//	@Param			payload		body		dto.UpdateDefaultDurations	true	"Durations"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/{product_id}/durations [put]
+//	@Security		Bearer
+func (h *ProductController) UpdateDurations(ctx *fiber.Ctx) error {
+	productID := ctx.Locals(context.ProductID).(int)
+	payload := new(params.UpdateDefaultDurations)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.UpdateProductDurations(ctx.Context(), productID, payload)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"durations": result},
+	})
+}
+
+// FindConversion finds conversion of a product between measure dimensions
+//
+//	@Summary		Find conversion of a product
+//	@Description	Finds the density and the piece weight of a product used to convert its mass, volume and count
+//	@Tags			Products
+//	@Param			product_id	path		integer	true	"Product ID"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/{product_id}/conversion [get]
+func (h *ProductController) FindConversion(ctx *fiber.Ctx) error {
+	productID := ctx.Locals(context.ProductID).(int)
+	result, err := h.svc.FindProductConversion(ctx.Context(), productID)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"conversion": result},
+	})
+}
+
+// UpdateConversion replaces conversion of a product between measure dimensions
+//
+//	@Summary		Update conversion of a product
+//	@Description	Replaces the density and the piece weight of a product. Zero is unknown.
+//	@Tags			Products
+//	@Accept			json
+//	@Produce		json
+//	@Param			product_id	path		integer					true	"Product ID"
+//	@Param			payload		body		dto.ProductConversion	true	"Conversion"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/products/{product_id}/conversion [put]
+//	@Security		Bearer
+func (h *ProductController) UpdateConversion(ctx *fiber.Ctx) error {
+	productID := ctx.Locals(context.ProductID).(int)
+	payload := new(params.ProductConversion)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.UpdateProductConversion(ctx.Context(), productID, payload)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"conversion": result},
+	})
+}
@@ -38,6 +38,14 @@ func NewRouter(
 				)
 			})
 		})
+		router.Route("/durations", func(router fiber.Router) {
+			router.Get("/", handler.FindDurations)
+			router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.UpdateDurations)
+		})
+		router.Route("/conversion", func(router fiber.Router) {
+			router.Get("/", handler.FindConversion)
+			router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.UpdateConversion)
+		})
 		router.Route("/recipes", func(router fiber.Router) {
 			router.Get("/", handler.FindRecipes)
 		})
@@ -208,9 +208,32 @@ func (h *RecipesController) Restore(ctx *fiber.Ctx) error {
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
 
+// FindRecipeIngredients finds ingredients of a recipe.
+//
+//	@Summary		Find ingredients of a recipe
+//	@Description	Finds ingredients of a recipe. The quantities are scaled to the servings if they are set.
+//	@Tags			Recipes
+//	@Produce		json
+//	@Param			recipe_id	path		int							true	"Recipe ID"
+//	@Param			filter		query		dto.RecipeIngredientsFilter	false	"Servings"
+//	@Success		200			{object}	handlers.HTTPSuccess
+//	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		502			{object}	handlers.HTTPError
+//	@Router			/recipes/{recipe_id}/ingredients [get]
 func (h *RecipesController) FindRecipeIngredients(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.RecipeID).(int)
-	result, err := h.svc.FindRecipeIngredients(ctx.Context(), id)
+	filter := new(params.RecipeIngredientsFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindRecipeIngredients(ctx.Context(), id, filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -6,8 +6,11 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
 	svc "github.com/romankravchuk/muerta/internal/services/recipe"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
 	repo "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
 )
 
@@ -18,7 +21,10 @@ func NewRouter(
 ) *fiber.App {
 	router := fiber.New()
 	repository := repo.New(client)
-	service := svc.New(repository)
+	service := svc.New(
+		repository,
+		conversion.New(measure.New(client), product.New(client)),
+	)
 	handler := New(service, log)
 	router.Get("/", handler.FindMany)
 	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
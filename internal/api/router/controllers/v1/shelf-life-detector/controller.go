@@ -1,81 +1,360 @@
 package shelflifedetector
 
 import (
+	"bytes"
+	errs "errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
 )
 
 type ShelfLifeDetectorController struct {
-	svc       sldetector.DateDetectorServicer
+	history   sldetector.DetectionHistoryServicer
+	jobs      sldetector.DetectionJobServicer
+	scans     sldetector.ScanServicer
+	labels    sldetector.LabelServicer
 	log       logger.Logger
 	limitSize int64
+	// retryAfter is sent to the clients when the detector is busy.
+	retryAfter time.Duration
 }
 
-func New(svc sldetector.DateDetectorServicer, log logger.Logger) *ShelfLifeDetectorController {
+func New(
+	history sldetector.DetectionHistoryServicer,
+	jobs sldetector.DetectionJobServicer,
+	scans sldetector.ScanServicer,
+	labels sldetector.LabelServicer,
+	log logger.Logger,
+	retryAfter time.Duration,
+) *ShelfLifeDetectorController {
 	return &ShelfLifeDetectorController{
-		svc: svc,
-		log: log,
-		// Limit - 512KB
-		limitSize: 1024 * 512,
+		history: history,
+		jobs:    jobs,
+		scans:   scans,
+		labels:  labels,
+		log:     log,
+		// Limit - 8MB, the photos are downscaled before the recognition
+		limitSize:  1024 * 1024 * 8,
+		retryAfter: retryAfter,
 	}
 }
 
 // DetectDates - detects shelf life dates from file
 //
 //	@Summary		Detect shelf life dates from file
-//	@Description	detect shelf life dates from file
+//	@Description	detect shelf life dates from file, in background if async is set
+//	@Description	the job is then polled or posted to the callback url once it is finished
+//	@Description	the detection is recorded so that the user can correct the dates
+//	@Description	with debug set all the recognized words are returned with their boxes, even if there are no dates
+//	@Description	the product, measure and quantity read from the label are suggested to prefill the shelf life
 //	@Tags			Shelf Life Detector
-//	@Accept			json
+//	@Accept			mpfd
 //	@Produce		json
 //	@Param			fileToDetect	formData	file	true	"file to detect"
-//	@Success		200				{object}	handlers.HTTPError
+//	@Param			callback_url	formData	string	false	"https url the finished job is posted to, internal addresses are refused"
+//	@Param			async			query		bool	false	"detect in background"
+//	@Param			debug			query		bool	false	"return the recognized words"
+//	@Success		200				{object}	handlers.HTTPSuccess{data=handlers.Data{dates=[]params.DetectedDate,id_detection=int,suggestion=params.LabelSuggestion,words=[]params.RecognizedWord}}
+//	@Success		202				{object}	handlers.HTTPSuccess{data=handlers.Data{job=params.FindDetectionJob}}
 //	@Failure		400				{object}	handlers.HTTPError
-//	@Failure		500				{object}	handlers.HTTPError
+//	@Failure		413				{object}	handlers.HTTPError
+//	@Failure		422				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Failure		503				{object}	handlers.HTTPError
+//	@Failure		504				{object}	handlers.HTTPError
 //	@Router			/shelf-life-detector [post]
 //	@Security		Bearer
 func (h *ShelfLifeDetectorController) DetectDates(ctx *fiber.Ctx) error {
-	file, err := ctx.FormFile("fileToDetect")
+	data, err := h.readImage(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.QueryBool("async") {
+		return h.createJob(ctx, data)
+	}
+	user, _ := access.Payload(ctx)
+	debug := ctx.QueryBool("debug")
+	detection, err := h.history.Detect(ctx.Context(), user.UserID, data)
+	if err != nil && !(debug && errs.Is(err, errors.ErrNoDatesDetected)) {
+		return h.fail(ctx, err)
+	}
+	result := controllers.Data{"dates": detection.Dates, "id_detection": detection.ID}
+	// The detection is already recorded, so it is returned without the
+	// suggestion rather than failed.
+	if suggestion, err := h.labels.Suggest(ctx.Context(), detection.Text); err != nil {
+		h.log.Error(ctx, logger.Server, err)
+	} else {
+		result["suggestion"] = suggestion
+	}
+	if debug {
+		result["words"] = detection.Words
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    result,
+	})
+}
+
+func (h *ShelfLifeDetectorController) createJob(ctx *fiber.Ctx, data []byte) error {
+	payload := new(params.CreateDetectionJob)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	user, _ := access.Payload(ctx)
+	job, err := h.jobs.CreateJob(ctx.Context(), user, data, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	ctx.Set(fiber.HeaderLocation, fmt.Sprintf("%s/jobs/%d", strings.TrimSuffix(ctx.Path(), "/"), job.ID))
+	return ctx.Status(http.StatusAccepted).JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"job": job},
+	})
+}
+
+// Scan godoc
+//
+//	@Summary		Create shelf life from file
+//	@Description	create shelf life with the dates detected from file and link the file to it
+//	@Description	if the dates are ambiguous the draft is returned, it is created once scanned again with the end date
+//	@Tags			Shelf Life Detector
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			fileToDetect	formData	file	true	"file to detect"
+//	@Param			id_product		formData	int		true	"Product ID"
+//	@Param			id_storage		formData	int		true	"Storage ID"
+//	@Param			id_measure		formData	int		true	"Measure ID"
+//	@Param			quantity		formData	number	true	"Quantity"
+//	@Param			purchase_date	formData	string	false	"purchase date, today by default"
+//	@Param			end_date		formData	string	false	"confirmed end date"
+//	@Success		200				{object}	handlers.HTTPSuccess{data=handlers.Data{shelf_life=params.FindShelfLife,image=string}}
+//	@Success		202				{object}	handlers.HTTPSuccess{data=handlers.Data{draft=params.ShelfLifeDraft}}
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		413				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Failure		503				{object}	handlers.HTTPError
+//	@Failure		504				{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/scan [post]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) Scan(ctx *fiber.Ctx) error {
+	data, err := h.readImage(ctx)
+	if err != nil {
+		return err
+	}
+	payload := new(params.ScanShelfLife)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	user, _ := access.Payload(ctx)
+	result, draft, err := h.scans.ScanShelfLife(ctx.Context(), user, data, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	if draft != nil {
+		return ctx.Status(http.StatusAccepted).JSON(controllers.HTTPSuccess{
+			Success: true,
+			Data:    controllers.Data{"draft": draft},
+		})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data: controllers.Data{
+			"shelf_life": result,
+			"image":      fmt.Sprintf("%s/api/v1/shelf-lives/%d/image", ctx.BaseURL(), result.ID),
+		},
+	})
+}
+
+// FindJob godoc
+//
+//	@Summary		Find detection job
+//	@Description	Find detection job of the user with the dates once it is succeeded
+//	@Tags			Shelf Life Detector
+//	@Accept			json
+//	@Produce		json
+//	@Param			job_id	path		int	true	"Job ID"
+//	@Success		200		{object}	handlers.HTTPSuccess{data=handlers.Data{job=params.FindDetectionJob}}
+//	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/jobs/{job_id} [get]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) FindJob(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.JobID).(int)
+	user, _ := access.Payload(ctx)
+	job, err := h.jobs.FindJob(ctx.Context(), user, id)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"job": job},
+	})
+}
+
+// FindDetections godoc
+//
+//	@Summary		Find detections
+//	@Description	Find detections of the user, the latest first
+//	@Tags			Shelf Life Detector
+//	@Accept			json
+//	@Produce		json
+//	@Param			filter	query		params.DetectionFilter	true	"Detection Filter"
+//	@Success		200		{object}	handlers.HTTPSuccess{data=handlers.Data{detections=[]params.FindDetection}}
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/detections [get]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) FindDetections(ctx *fiber.Ctx) error {
+	filter := new(params.DetectionFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	user, _ := access.Payload(ctx)
+	detections, err := h.history.FindDetections(ctx.Context(), user, filter)
 	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"detections": detections},
+	})
+}
+
+// CorrectDetection godoc
+//
+//	@Summary		Correct detection
+//	@Description	Confirm or correct the dates detected on the image, a missing date tells there is none
+//	@Tags			Shelf Life Detector
+//	@Accept			json
+//	@Produce		json
+//	@Param			detection_id	path		int						true	"Detection ID"
+//	@Param			payload			body		params.CorrectDetection	true	"Dates printed on the image"
+//	@Success		200				{object}	handlers.HTTPSuccess{data=handlers.Data{detection=params.FindDetection}}
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		502				{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/detections/{detection_id} [put]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) CorrectDetection(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.DetectionID).(int)
+	payload := new(params.CorrectDetection)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
 		h.log.Error(ctx, logger.Client, err)
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
+	user, _ := access.Payload(ctx)
+	detection, err := h.history.CorrectDetection(ctx.Context(), user, id, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"detection": detection},
+	})
+}
+
+// ExportDataset godoc
+//
+//	@Summary		Export dataset
+//	@Description	Export the corrected detections as the zip archive of the labelled dataset directory
+//	@Tags			Shelf Life Detector
+//	@Produce		application/zip
+//	@Success		200	{file}		binary
+//	@Failure		403	{object}	handlers.HTTPError
+//	@Failure		502	{object}	handlers.HTTPError
+//	@Router			/shelf-life-detector/detections/dataset [get]
+//	@Security		Bearer
+func (h *ShelfLifeDetectorController) ExportDataset(ctx *fiber.Ctx) error {
+	var archive bytes.Buffer
+	if _, err := h.history.ExportDataset(ctx.Context(), &archive); err != nil {
+		return h.fail(ctx, err)
+	}
+	ctx.Attachment("dataset.zip")
+	return ctx.Send(archive.Bytes())
+}
+
+// readImage reads the uploaded file. The error returned if it can't is the
+// fiber error the handlers return to respond to the client.
+func (h *ShelfLifeDetectorController) readImage(ctx *fiber.Ctx) ([]byte, error) {
+	file, err := ctx.FormFile("fileToDetect")
+	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		return nil, fiber.ErrBadRequest
+	}
 	if file.Size > h.limitSize {
 		h.log.Error(ctx, logger.Client, fmt.Errorf("file size is too large: %d", file.Size))
-		return fiber.ErrRequestEntityTooLarge
+		return nil, fiber.ErrRequestEntityTooLarge
 	}
 	fileContent, err := file.Open()
 	if err != nil {
 		h.log.Error(ctx, logger.Client, err)
-		return ctx.Status(http.StatusBadRequest).
-			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		return nil, fiber.ErrBadRequest
 	}
 	defer fileContent.Close()
 	data, err := io.ReadAll(fileContent)
 	if err != nil {
+		h.log.Error(ctx, logger.Client, err)
+		return nil, fiber.ErrBadRequest
+	}
+	return data, nil
+}
+
+func (h *ShelfLifeDetectorController) fail(ctx *fiber.Ctx, err error) error {
+	switch {
+	case errs.Is(err, errors.ErrInvalidImage):
 		h.log.Error(ctx, logger.Client, err)
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
-	}
-	dates, err := h.svc.Detect(data)
-	if err != nil {
+	case errs.Is(err, errors.ErrUnsafeURL):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: errors.ErrUnsafeURL.Error()})
+	case errs.Is(err, errors.ErrNotOwner):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	case errs.Is(err, errors.ErrJobNotFound), errs.Is(err, errors.ErrDetectionNotFound):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	case errs.Is(err, errors.ErrNoDatesDetected):
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: fiber.ErrUnprocessableEntity.Error()})
+	case errs.Is(err, errors.ErrDetectorBusy):
+		h.log.Error(ctx, logger.Server, err)
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(h.retryAfter.Seconds()))))
+		return ctx.Status(http.StatusServiceUnavailable).
+			JSON(controllers.HTTPError{Error: fiber.ErrServiceUnavailable.Error()})
+	case errs.Is(err, errors.ErrDetectionTimeout):
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusGatewayTimeout).
+			JSON(controllers.HTTPError{Error: fiber.ErrGatewayTimeout.Error()})
+	default:
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	if dates == nil {
-		h.log.Error(ctx, logger.Server, fmt.Errorf("dates is nil"))
-		return ctx.Status(http.StatusBadGateway).
-			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
-	}
-	return ctx.JSON(fiber.Map{
-		"success": true,
-		"data":    dates,
-	})
 }
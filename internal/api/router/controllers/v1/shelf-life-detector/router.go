@@ -1,17 +1,88 @@
 package shelflifedetector
 
 import (
+	stdcontext "context"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
 	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	shelflifesvc "github.com/romankravchuk/muerta/internal/services/shelf-life"
 	sldetector "github.com/romankravchuk/muerta/internal/services/shelf-life-detector"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	detectionrepo "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
+	jobrepo "github.com/romankravchuk/muerta/internal/storage/postgres/detection-job"
+	measurerepo "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	productrepo "github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	shelfliferepo "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
 )
 
-func NewRouter(cfg *config.Config, log logger.Logger, jware *jware.JWTMiddleware) *fiber.App {
+func NewRouter(
+	cfg *config.Config,
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
 	router := fiber.New()
-	service := sldetector.New(cfg.ShutdownShelfDetectorChan)
-	handler := New(service, log)
-	router.Post("/", jware.DeserializeUser, handler.DetectDates)
+	service := sldetector.New(newEngine(cfg, log), cfg.Detector.Timeout)
+	history := sldetector.NewHistoryService(service, detectionrepo.New(client))
+	jobs := jobrepo.New(client)
+	runJobs(cfg, sldetector.NewJobWorker(
+		jobs,
+		history,
+		cfg.Detector.JobAttempts,
+		cfg.Detector.JobBackoff,
+		2*cfg.Detector.Timeout,
+		cfg.Detector.JobRetention,
+		log.GetLogger(),
+	), service)
+	scans := sldetector.NewScanService(history, shelflifesvc.New(shelfliferepo.New(client)))
+	labels := sldetector.NewLabelService(productrepo.New(client), measurerepo.New(client))
+	handler := New(history, sldetector.NewJobService(jobs), scans, labels, log, cfg.Detector.Timeout)
+	router.Use(jware.DeserializeUser)
+	router.Post("/", handler.DetectDates)
+	router.Post("/scan", handler.Scan)
+	router.Get("/jobs"+context.JobID.Path(), context.New(log, context.JobID), handler.FindJob)
+	router.Route("/detections", func(router fiber.Router) {
+		router.Get("/", handler.FindDetections)
+		router.Get("/dataset", access.AdminOnly(log), handler.ExportDataset)
+		router.Put(context.DetectionID.Path(), context.New(log, context.DetectionID), handler.CorrectDetection)
+	})
 	return router
 }
+
+// newEngine creates the OCR engine chosen in the config. It exits if the
+// Tesseract engine is chosen and the binary is built without it.
+func newEngine(cfg *config.Config, log logger.Logger) sldetector.OCREngine {
+	switch cfg.Detector.Engine {
+	case sldetector.EngineHTTP:
+		return sldetector.NewHTTPEngine(cfg.Detector.URL, cfg.Detector.Token)
+	case sldetector.EngineFake:
+		return sldetector.NewFakeEngine(cfg.Detector.FakeText)
+	default:
+		engine, err := sldetector.NewTesseractEngine(cfg.Detector.PoolSize, cfg.Detector.QueueTimeout)
+		if err != nil {
+			log.GetLogger().Fatal().Err(err).Msg("OCR engine create")
+		}
+		return engine
+	}
+}
+
+// runJobs runs the detection jobs with the same detector as the requests
+// until the detector is shut down, then closes the detector.
+func runJobs(cfg *config.Config, worker sldetector.JobWorker, service *sldetector.DateDetectorService) {
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		worker.Run(ctx, cfg.Detector.Workers)
+	}()
+	go func() {
+		<-cfg.ShutdownShelfDetectorChan
+		cancel()
+		<-stopped
+		service.Close()
+	}()
+}
@@ -1,40 +1,51 @@
 package shelflife
 
 import (
+	errs "errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/api/router/utils"
 	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	service "github.com/romankravchuk/muerta/internal/services/shelf-life"
 )
 
 type ShelfLifeController struct {
-	svc service.ShelfLifeServicer
-	log logger.Logger
+	svc       service.ShelfLifeServicer
+	evaluator service.StatusEvaluator
+	log       logger.Logger
 }
 
-func New(svc service.ShelfLifeServicer, log logger.Logger) ShelfLifeController {
+func New(
+	svc service.ShelfLifeServicer,
+	evaluator service.StatusEvaluator,
+	log logger.Logger,
+) ShelfLifeController {
 	return ShelfLifeController{
-		svc: svc,
-		log: log,
+		svc:       svc,
+		evaluator: evaluator,
+		log:       log,
 	}
 }
 
 // Create godoc
 //
 //	@Summary		Create shelf life
-//	@Description	Create shelf life
+//	@Description	Create shelf life, the end date defaults to the duration of the product or its category
 //	@Tags			Shelf Lives
 //	@Accept			json
 //	@Produce		json
 //	@Param			payload	body		dto.CreateShelfLife	true	"Shelf Life"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		403		{object}	handlers.HTTPError
+//	@Failure		422		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
 //	@Router			/shelf-lives [post]
 //	@Security		Bearer
@@ -50,12 +61,22 @@ func (h *ShelfLifeController) Create(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.CreateShelfLife(ctx.Context(), payload); err != nil {
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.CreateShelfLife(ctx.Context(), user, payload)
+	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		if errs.Is(err, errors.ErrNoDefaultDuration) {
+			return ctx.Status(http.StatusUnprocessableEntity).
+				JSON(controllers.HTTPError{Error: fiber.ErrUnprocessableEntity.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf-life": result}})
 }
 
 // FindOne godoc
@@ -68,14 +89,21 @@ func (h *ShelfLifeController) Create(ctx *fiber.Ctx) error {
 //	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
 //	@Failure		404				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id} [get]
+//	@Security		Bearer
 func (h *ShelfLifeController) FindOne(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ShelfLifeID).(int)
-	result, err := h.svc.FindShelfLifeByID(ctx.Context(), id)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLifeByID(ctx.Context(), user, id)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return fiber.ErrNotFound
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf_life": result}})
@@ -88,11 +116,12 @@ func (h *ShelfLifeController) FindOne(ctx *fiber.Ctx) error {
 //	@Tags			Shelf Lives
 //	@Accept			json
 //	@Produce		json
-//	@Param			payload	body		dto.ShelfLifeFilter	true	"Shelf Life Filter"
+//	@Param			filter	query		dto.ShelfLifeFilter	true	"Shelf Life Filter"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
 //	@Router			/shelf-lives [get]
+//	@Security		Bearer
 func (h *ShelfLifeController) FindMany(ctx *fiber.Ctx) error {
 	filter := new(params.ShelfLifeFilter)
 	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
@@ -105,13 +134,14 @@ func (h *ShelfLifeController) FindMany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	result, err := h.svc.FindShelfLifes(ctx.Context(), filter)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLifes(ctx.Context(), user, filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	count, err := h.svc.Count(ctx.Context(), *filter)
+	count, err := h.svc.Count(ctx.Context(), user, *filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -136,6 +166,7 @@ func (h *ShelfLifeController) FindMany(ctx *fiber.Ctx) error {
 //	@Param			payload			body		dto.UpdateShelfLife	true	"Shelf Life"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id} [put]
 //	@Security		Bearer
@@ -152,8 +183,13 @@ func (h *ShelfLifeController) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.UpdateShelfLife(ctx.Context(), id, payload); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.UpdateShelfLife(ctx.Context(), user, id, payload); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -170,13 +206,19 @@ func (h *ShelfLifeController) Update(ctx *fiber.Ctx) error {
 //	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id} [delete]
 //	@Security		Bearer
 func (h *ShelfLifeController) Delete(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ShelfLifeID).(int)
-	if err := h.svc.DeleteShelfLife(ctx.Context(), id); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.DeleteShelfLife(ctx.Context(), user, id); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -193,13 +235,24 @@ func (h *ShelfLifeController) Delete(ctx *fiber.Ctx) error {
 //	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		409				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id} [patch]
 //	@Security		Bearer
 func (h *ShelfLifeController) Restore(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ShelfLifeID).(int)
-	if err := h.svc.RestoreShelfLife(ctx.Context(), id); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.RestoreShelfLife(ctx.Context(), user, id); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		if errs.Is(err, errors.ErrShelfLifeClosed) {
+			return ctx.Status(http.StatusConflict).
+				JSON(controllers.HTTPError{Error: errors.ErrShelfLifeClosed.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -216,13 +269,20 @@ func (h *ShelfLifeController) Restore(ctx *fiber.Ctx) error {
 //	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id}/statuses [get]
+//	@Security		Bearer
 func (h *ShelfLifeController) FindStatuses(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ShelfLifeID).(int)
-	result, err := h.svc.FindShelfLifeStatuses(ctx.Context(), id)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLifeStatuses(ctx.Context(), user, id)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -240,15 +300,21 @@ func (h *ShelfLifeController) FindStatuses(ctx *fiber.Ctx) error {
 //	@Param			status_id		path		int	true	"Status ID"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id}/statuses/{status_id} [post]
 //	@Security		Bearer
 func (h *ShelfLifeController) AddStatus(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ShelfLifeID).(int)
 	statusID := ctx.Locals(context.StatusID).(int)
-	result, err := h.svc.CreateShelfLifeStatus(ctx.Context(), id, statusID)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.CreateShelfLifeStatus(ctx.Context(), user, id, statusID)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -266,16 +332,187 @@ func (h *ShelfLifeController) AddStatus(ctx *fiber.Ctx) error {
 //	@Param			status_id		path		int	true	"Status ID"
 //	@Success		200				{object}	handlers.HTTPSuccess
 //	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
 //	@Failure		500				{object}	handlers.HTTPError
 //	@Router			/shelf-lives/{shelf_life_id}/statuses/{status_id} [delete]
 //	@Security		Bearer
 func (h *ShelfLifeController) RemoveStatus(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.ShelfLifeID).(int)
 	statusID := ctx.Locals(context.StatusID).(int)
-	if err := h.svc.DeleteShelfLifeStatus(ctx.Context(), id, statusID); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.DeleteShelfLifeStatus(ctx.Context(), user, id, statusID); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
 	return ctx.JSON(controllers.HTTPSuccess{Success: true})
 }
+
+// FindEvents godoc
+//
+//	@Summary		Find shelf life events
+//	@Description	Find consumption history of shelf life
+//	@Tags			Shelf Lives
+//	@Accept			json
+//	@Produce		json
+//	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		500				{object}	handlers.HTTPError
+//	@Router			/shelf-lives/{shelf_life_id}/events [get]
+//	@Security		Bearer
+func (h *ShelfLifeController) FindEvents(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.ShelfLifeID).(int)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLifeEvents(ctx.Context(), user, id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"events": result}})
+}
+
+// FindTips godoc
+//
+//	@Summary		Find shelf life tips
+//	@Description	Find tips of the product, its categories, the storage and the storage type applicable to the shelf life.
+//	@Description	Conditional tips are shown only while the item expires soon or the storage is too warm or too cold
+//	@Tags			Shelf Lives
+//	@Accept			json
+//	@Produce		json
+//	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		500				{object}	handlers.HTTPError
+//	@Router			/shelf-lives/{shelf_life_id}/tips [get]
+//	@Security		Bearer
+func (h *ShelfLifeController) FindTips(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.ShelfLifeID).(int)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLifeTips(ctx.Context(), user, id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"tips": result}})
+}
+
+// FindImage godoc
+//
+//	@Summary		Find shelf life image
+//	@Description	Find the photo the shelf life was scanned from
+//	@Tags			Shelf Lives
+//	@Produce		png,jpeg,webp
+//	@Param			shelf_life_id	path		int	true	"Shelf Life ID"
+//	@Success		200				{file}		binary
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		404				{object}	handlers.HTTPError
+//	@Failure		500				{object}	handlers.HTTPError
+//	@Router			/shelf-lives/{shelf_life_id}/image [get]
+//	@Security		Bearer
+func (h *ShelfLifeController) FindImage(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.ShelfLifeID).(int)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLifeScan(ctx.Context(), user, id)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		if errs.Is(err, errors.ErrScanNotFound) {
+			return ctx.Status(http.StatusNotFound).
+				JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+		}
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	ctx.Set(fiber.HeaderContentType, result.ContentType)
+	return ctx.Send(result.Image)
+}
+
+// CreateEvent godoc
+//
+//	@Summary		Create shelf life event
+//	@Description	Record consumed, discarded or given away quantity of shelf life. Shelf life is closed once nothing remains
+//	@Tags			Shelf Lives
+//	@Accept			json
+//	@Produce		json
+//	@Param			shelf_life_id	path		int							true	"Shelf Life ID"
+//	@Param			payload			body		dto.CreateShelfLifeEvent	true	"Event"
+//	@Success		200				{object}	handlers.HTTPSuccess
+//	@Failure		400				{object}	handlers.HTTPError
+//	@Failure		403				{object}	handlers.HTTPError
+//	@Failure		409				{object}	handlers.HTTPError
+//	@Failure		500				{object}	handlers.HTTPError
+//	@Router			/shelf-lives/{shelf_life_id}/events [post]
+//	@Security		Bearer
+func (h *ShelfLifeController) CreateEvent(ctx *fiber.Ctx) error {
+	id := ctx.Locals(context.ShelfLifeID).(int)
+	payload := new(params.CreateShelfLifeEvent)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.CreateShelfLifeEvent(ctx.Context(), user, id, payload)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		if errs.Is(err, errors.ErrNotEnoughQuantity) {
+			return ctx.Status(http.StatusConflict).
+				JSON(controllers.HTTPError{Error: errors.ErrNotEnoughQuantity.Error()})
+		}
+		if errs.Is(err, errors.ErrShelfLifeClosed) {
+			return ctx.Status(http.StatusConflict).
+				JSON(controllers.HTTPError{Error: errors.ErrShelfLifeClosed.Error()})
+		}
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"result": result}})
+}
+
+// RecomputeStatuses godoc
+//
+//	@Summary		Recompute shelf life statuses
+//	@Description	Recompute fresh, expiring soon and expired statuses of all shelf lives
+//	@Tags			Shelf Lives
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	handlers.HTTPSuccess
+//	@Failure		500	{object}	handlers.HTTPError
+//	@Router			/shelf-lives/statuses/recompute [post]
+//	@Security		Bearer
+func (h *ShelfLifeController) RecomputeStatuses(ctx *fiber.Ctx) error {
+	result, err := h.evaluator.Evaluate(ctx.Context())
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"evaluation": result}})
+}
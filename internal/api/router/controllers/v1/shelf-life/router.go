@@ -5,13 +5,16 @@ import (
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/config"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	service "github.com/romankravchuk/muerta/internal/services/shelf-life"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+	statusrepo "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life-status"
 )
 
 func NewRouter(
+	cfg *config.Config,
 	client postgres.Client,
 	log logger.Logger,
 	jware *jware.JWTMiddleware,
@@ -19,28 +22,37 @@ func NewRouter(
 	router := fiber.New()
 	repo := repository.New(client)
 	svc := service.New(repo)
-	handler := New(svc, log)
+	evaluator := service.NewStatusEvaluator(
+		repo,
+		statusrepo.New(client),
+		cfg.ShelfLife.ExpiringThreshold,
+		log.GetLogger(),
+	)
+	handler := New(svc, evaluator, log)
+	router.Use(jware.DeserializeUser)
 	router.Get("/", handler.FindMany)
-	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
+	router.Post("/", handler.Create)
+	router.Post("/statuses/recompute", access.AdminOnly(log), handler.RecomputeStatuses)
 	router.Route(context.ShelfLifeID.Path(), func(router fiber.Router) {
 		router.Use(context.New(log, context.ShelfLifeID))
 		router.Get("/", handler.FindOne)
-		router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.Update)
-		router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.Delete)
-		router.Patch("/", jware.DeserializeUser, access.AdminOnly(log), handler.Restore)
+		router.Put("/", handler.Update)
+		router.Delete("/", handler.Delete)
+		router.Patch("/", handler.Restore)
 		router.Route("/statuses", func(router fiber.Router) {
 			router.Get("/", handler.FindStatuses)
 			router.Route(context.StatusID.Path(), func(router fiber.Router) {
 				router.Use(context.New(log, context.StatusID))
-				router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.AddStatus)
-				router.Delete(
-					"/",
-					jware.DeserializeUser,
-					access.AdminOnly(log),
-					handler.RemoveStatus,
-				)
+				router.Post("/", handler.AddStatus)
+				router.Delete("/", handler.RemoveStatus)
 			})
 		})
+		router.Route("/events", func(router fiber.Router) {
+			router.Get("/", handler.FindEvents)
+			router.Post("/", handler.CreateEvent)
+		})
+		router.Get("/tips", handler.FindTips)
+		router.Get("/image", handler.FindImage)
 	})
 	return router
 }
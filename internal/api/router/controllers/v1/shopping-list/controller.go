@@ -0,0 +1,325 @@
+package shoppinglist
+
+import (
+	errs "errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/shopping-list"
+)
+
+type ShoppingListController struct {
+	svc service.ShoppingListServicer
+	log logger.Logger
+}
+
+func New(svc service.ShoppingListServicer, log logger.Logger) *ShoppingListController {
+	return &ShoppingListController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// FindMany godoc
+//
+//	@Summary		Find shopping lists
+//	@Description	Find shopping lists of the user
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int	true	"User ID"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists [get]
+//	@Security		Bearer
+func (h *ShoppingListController) FindMany(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	result, err := h.svc.FindShoppingLists(ctx.Context(), userID)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-lists": result},
+	})
+}
+
+// FindOne godoc
+//
+//	@Summary		Find shopping list
+//	@Description	Find shopping list of the user with its items
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int	true	"User ID"
+//	@Param			id_shopping_list	path		int	true	"Shopping list ID"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list} [get]
+//	@Security		Bearer
+func (h *ShoppingListController) FindOne(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	result, err := h.svc.FindShoppingList(ctx.Context(), userID, id)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// Create godoc
+//
+//	@Summary		Create shopping list
+//	@Description	Create an empty shopping list for the user
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int						true	"User ID"
+//	@Param			payload	body		dto.CreateShoppingList	true	"Shopping list"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists [post]
+//	@Security		Bearer
+func (h *ShoppingListController) Create(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	payload := new(params.CreateShoppingList)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.CreateShoppingList(ctx.Context(), userID, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// Delete godoc
+//
+//	@Summary		Delete shopping list
+//	@Description	Delete shopping list of the user with its items
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int	true	"User ID"
+//	@Param			id_shopping_list	path		int	true	"Shopping list ID"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list} [delete]
+//	@Security		Bearer
+func (h *ShoppingListController) Delete(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	if err := h.svc.DeleteShoppingList(ctx.Context(), userID, id); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// Generate godoc
+//
+//	@Summary		Generate shopping list items
+//	@Description	Add ingredients of the recipes which are missing in the shelf lives of the user
+//	@Description	and on the list to the shopping list
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int							true	"User ID"
+//	@Param			id_shopping_list	path		int							true	"Shopping list ID"
+//	@Param			payload				body		dto.GenerateShoppingList	true	"Recipes"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list}/generate [post]
+//	@Security		Bearer
+func (h *ShoppingListController) Generate(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	payload := new(params.GenerateShoppingList)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.GenerateItems(ctx.Context(), userID, id, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shopping-list": result},
+	})
+}
+
+// CreateItem godoc
+//
+//	@Summary		Create shopping list item
+//	@Description	Add the product to the shopping list
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int							true	"User ID"
+//	@Param			id_shopping_list	path		int							true	"Shopping list ID"
+//	@Param			payload				body		dto.CreateShoppingListItem	true	"Item"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list}/items [post]
+//	@Security		Bearer
+func (h *ShoppingListController) CreateItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	payload := new(params.CreateShoppingListItem)
+	if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+		return h.badRequest(ctx, err)
+	}
+	result, err := h.svc.CreateItem(ctx.Context(), userID, id, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"item": result},
+	})
+}
+
+// DeleteItem godoc
+//
+//	@Summary		Delete shopping list item
+//	@Description	Remove the item from the shopping list
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int	true	"User ID"
+//	@Param			id_shopping_list	path		int	true	"Shopping list ID"
+//	@Param			id_item				path		int	true	"Item ID"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list}/items/{id_item} [delete]
+//	@Security		Bearer
+func (h *ShoppingListController) DeleteItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	itemID := ctx.Locals(context.ItemID).(int)
+	if err := h.svc.DeleteItem(ctx.Context(), userID, id, itemID); err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true})
+}
+
+// CheckItem godoc
+//
+//	@Summary		Check shopping list item off
+//	@Description	Check the item off. If the storage is set, the item is also stored
+//	@Description	as a shelf life. The end date falls back to the default duration of the product.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int							true	"User ID"
+//	@Param			id_shopping_list	path		int							true	"Shopping list ID"
+//	@Param			id_item				path		int							true	"Item ID"
+//	@Param			payload				body		dto.CheckShoppingListItem	false	"Shelf life"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		400					{object}	handlers.HTTPError
+//	@Failure		403					{object}	handlers.HTTPError
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		409					{object}	handlers.HTTPError
+//	@Failure		422					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list}/items/{id_item}/check [post]
+//	@Security		Bearer
+func (h *ShoppingListController) CheckItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	itemID := ctx.Locals(context.ItemID).(int)
+	payload := new(params.CheckShoppingListItem)
+	if len(ctx.Body()) > 0 {
+		if err := utils.ParseBodyAndValidate(ctx, payload); err != nil {
+			return h.badRequest(ctx, err)
+		}
+	}
+	result, err := h.svc.CheckItem(ctx.Context(), userID, id, itemID, payload)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	data := controllers.Data{"item": result.Item}
+	if result.ShelfLife != nil {
+		data["shelf-life"] = result.ShelfLife
+	}
+	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: data})
+}
+
+// UncheckItem godoc
+//
+//	@Summary		Uncheck shopping list item
+//	@Description	Return the item to the shopping list. The stored shelf life is kept.
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user				path		int	true	"User ID"
+//	@Param			id_shopping_list	path		int	true	"Shopping list ID"
+//	@Param			id_item				path		int	true	"Item ID"
+//	@Success		200					{object}	handlers.HTTPSuccess
+//	@Failure		404					{object}	handlers.HTTPError
+//	@Failure		502					{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/shopping-lists/{id_shopping_list}/items/{id_item}/uncheck [post]
+//	@Security		Bearer
+func (h *ShoppingListController) UncheckItem(ctx *fiber.Ctx) error {
+	userID := ctx.Locals(context.UserID).(int)
+	id := ctx.Locals(context.ShoppingListID).(int)
+	itemID := ctx.Locals(context.ItemID).(int)
+	result, err := h.svc.UncheckItem(ctx.Context(), userID, id, itemID)
+	if err != nil {
+		return h.fail(ctx, err)
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"item": result},
+	})
+}
+
+func (h *ShoppingListController) badRequest(ctx *fiber.Ctx, err error) error {
+	if err, ok := err.(validator.ValidationErrors); ok {
+		h.log.Error(ctx, logger.Validation, err)
+	} else {
+		h.log.Error(ctx, logger.Client, err)
+	}
+	return ctx.Status(http.StatusBadRequest).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+}
+
+func (h *ShoppingListController) fail(ctx *fiber.Ctx, err error) error {
+	h.log.Error(ctx, logger.Server, err)
+	switch {
+	case errs.Is(err, errors.ErrShoppingListNotFound):
+		return ctx.Status(http.StatusNotFound).
+			JSON(controllers.HTTPError{Error: fiber.ErrNotFound.Error()})
+	case errs.Is(err, errors.ErrNotOwner):
+		return ctx.Status(http.StatusForbidden).
+			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+	case errs.Is(err, errors.ErrItemAlreadyStocked):
+		return ctx.Status(http.StatusConflict).
+			JSON(controllers.HTTPError{Error: fiber.ErrConflict.Error()})
+	case errs.Is(err, errors.ErrNoDefaultDuration):
+		return ctx.Status(http.StatusUnprocessableEntity).
+			JSON(controllers.HTTPError{Error: fiber.ErrUnprocessableEntity.Error()})
+	}
+	return ctx.Status(http.StatusBadGateway).
+		JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+}
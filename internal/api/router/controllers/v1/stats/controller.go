@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/api/router/utils"
+	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	service "github.com/romankravchuk/muerta/internal/services/stats"
+)
+
+type StatsController struct {
+	svc service.StatsServicer
+	log logger.Logger
+}
+
+func New(svc service.StatsServicer, log logger.Logger) *StatsController {
+	return &StatsController{
+		svc: svc,
+		log: log,
+	}
+}
+
+// UserWaste godoc
+//
+//	@Summary		Find user waste stats
+//	@Description	Find consumed, discarded, given away and expired food of the user per period
+//	@Tags			Users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id_user	path		int					true	"User ID"
+//	@Param			filter	query		dto.WasteFilter	true	"Filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/users/{id_user}/stats/waste [get]
+//	@Security		Bearer
+func (h *StatsController) UserWaste(ctx *fiber.Ctx) error {
+	return h.waste(ctx, ctx.Locals(context.UserID).(int))
+}
+
+// Waste godoc
+//
+//	@Summary		Find waste stats
+//	@Description	Find consumed, discarded, given away and expired food of all users per period
+//	@Tags			Stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			filter	query		dto.WasteFilter	true	"Filter"
+//	@Success		200		{object}	handlers.HTTPSuccess
+//	@Failure		400		{object}	handlers.HTTPError
+//	@Failure		502		{object}	handlers.HTTPError
+//	@Router			/stats/waste [get]
+//	@Security		Bearer
+func (h *StatsController) Waste(ctx *fiber.Ctx) error {
+	return h.waste(ctx, 0)
+}
+
+func (h *StatsController) waste(ctx *fiber.Ctx, id int) error {
+	filter := new(params.WasteFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.Waste(ctx.Context(), id, filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"waste": result},
+	})
+}
@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
+	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
+	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	svc "github.com/romankravchuk/muerta/internal/services/stats"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	measurerepo "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	productrepo "github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	repo "github.com/romankravchuk/muerta/internal/storage/postgres/stats"
+)
+
+func NewRouter(
+	client postgres.Client,
+	log logger.Logger,
+	jware *jware.JWTMiddleware,
+) *fiber.App {
+	r := fiber.New()
+	conversions := conversion.New(measurerepo.New(client), productrepo.New(client))
+	h := New(svc.New(repo.New(client), conversions), log)
+	r.Use(jware.DeserializeUser)
+	r.Use(access.AdminOnly(log))
+	r.Get("/waste", h.Waste)
+	return r
+}
@@ -1,14 +1,17 @@
 package user
 
 import (
+	errs "errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/api/router/utils"
 	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	service "github.com/romankravchuk/muerta/internal/services/user"
 )
@@ -243,7 +246,13 @@ func (h *UserController) UpdateSetting(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	result, err := h.svc.UpdateSetting(ctx.Context(), id, payload)
+	settingID := ctx.Locals(context.SettingID).(int)
+	result, err := h.svc.UpdateSetting(ctx.Context(), id, settingID, payload)
+	if errs.Is(err, errors.ErrUnsafeURL) {
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: errors.ErrUnsafeURL.Error()})
+	}
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -289,6 +298,7 @@ func (h *UserController) FindRoles(ctx *fiber.Ctx) error {
 //	@Failure		404		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
 //	@Router			/users/{id_user}/storages [get]
+//	@Security		Bearer
 func (h *UserController) FindStorages(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.UserID).(int)
 	result, err := h.svc.FindStorages(ctx.Context(), id)
@@ -303,7 +313,7 @@ func (h *UserController) FindStorages(ctx *fiber.Ctx) error {
 // AddStorage godoc
 //
 //	@Summary		Add user storage
-//	@Description	Add user storage
+//	@Description	Add user storage. Users can add storages only to themselves, the storages are shared with the others through the households.
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
@@ -311,6 +321,7 @@ func (h *UserController) FindStorages(ctx *fiber.Ctx) error {
 //	@Param			id_storage	query		int	true	"Storage ID"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		404			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/users/{id_user}/storages/{id_storage} [post]
@@ -318,9 +329,14 @@ func (h *UserController) FindStorages(ctx *fiber.Ctx) error {
 func (h *UserController) AddStorage(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.UserID).(int)
 	storageID := ctx.Locals(context.StorageID).(int)
-	result, err := h.svc.AddStorage(ctx.Context(), id, storageID)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.AddStorage(ctx.Context(), user, id, storageID)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			SendString("Bad Gateway")
 	}
@@ -361,27 +377,49 @@ func (h *UserController) RemoveStorage(ctx *fiber.Ctx) error {
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
-//	@Param			id_user	query		int	true	"User ID"
+//	@Param			id_user	query		int					true	"User ID"
+//	@Param			filter	query		dto.ShelfLifeFilter	true	"Shelf Life Filter"
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
 //	@Failure		404		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
 //	@Router			/users/{id_user}/shelf-lives [get]
+//	@Security		Bearer
 func (h *UserController) FindShelfLives(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.UserID).(int)
-	result, err := h.svc.FindShelfLives(ctx.Context(), id)
+	filter := new(params.ShelfLifeFilter)
+	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
+		if err, ok := err.(validator.ValidationErrors); ok {
+			h.log.Error(ctx, logger.Validation, err)
+			return ctx.Status(http.StatusBadRequest).
+				JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+		}
+		h.log.Error(ctx, logger.Client, err)
+		return ctx.Status(http.StatusBadRequest).
+			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
+	}
+	result, err := h.svc.FindShelfLives(ctx.Context(), id, filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			SendString("Bad Gateway")
 	}
-	return ctx.JSON(controllers.HTTPSuccess{Success: true, Data: controllers.Data{"shelf-lives": result}})
+	count, err := h.svc.CountShelfLives(ctx.Context(), id, *filter)
+	if err != nil {
+		h.log.Error(ctx, logger.Server, err)
+		return ctx.Status(http.StatusBadGateway).
+			SendString("Bad Gateway")
+	}
+	return ctx.JSON(controllers.HTTPSuccess{
+		Success: true,
+		Data:    controllers.Data{"shelf-lives": result, "count": count},
+	})
 }
 
 // CreateShelfLife godoc
 //
 //	@Summary		Create user shelf life
-//	@Description	Create user shelf life
+//	@Description	Create user shelf life, the end date defaults to the duration of the product or its category
 //	@Tags			Users
 //	@Accept			json
 //	@Produce		json
@@ -390,6 +428,7 @@ func (h *UserController) FindShelfLives(ctx *fiber.Ctx) error {
 //	@Success		200		{object}	handlers.HTTPSuccess
 //	@Failure		400		{object}	handlers.HTTPError
 //	@Failure		404		{object}	handlers.HTTPError
+//	@Failure		422		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
 //	@Router			/users/{id_user}/shelf-lives [post]
 //	@Security		Bearer
@@ -410,6 +449,14 @@ func (h *UserController) CreateShelfLife(ctx *fiber.Ctx) error {
 	result, err := h.svc.CreateShelfLife(ctx.Context(), id, payload)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
+		if errs.Is(err, errors.ErrNoDefaultDuration) {
+			return ctx.Status(http.StatusUnprocessableEntity).
+				JSON(controllers.HTTPError{Error: fiber.ErrUnprocessableEntity.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			SendString("Bad Gateway")
 	}
@@ -450,6 +497,10 @@ func (h *UserController) UpdateShelfLife(ctx *fiber.Ctx) error {
 	result, err := h.svc.UpdateShelfLife(ctx.Context(), id, payload)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			SendString("Bad Gateway")
 	}
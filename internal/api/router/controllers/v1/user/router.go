@@ -2,12 +2,30 @@ package user
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/calendar"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/notification"
+	shoppinglist "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shopping-list"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/stats"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/suggestion"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	jware "github.com/romankravchuk/muerta/internal/api/router/middleware/jwt"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
+	calendarsvc "github.com/romankravchuk/muerta/internal/services/calendar"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	notificationsvc "github.com/romankravchuk/muerta/internal/services/notification"
+	shoppinglistsvc "github.com/romankravchuk/muerta/internal/services/shopping-list"
+	statssvc "github.com/romankravchuk/muerta/internal/services/stats"
+	suggestionsvc "github.com/romankravchuk/muerta/internal/services/suggestion"
 	svc "github.com/romankravchuk/muerta/internal/services/user"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	measurerepo "github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	notificationrepo "github.com/romankravchuk/muerta/internal/storage/postgres/notification"
+	productrepo "github.com/romankravchuk/muerta/internal/storage/postgres/product"
+	reciperepo "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+	shoppinglistrepo "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+	statsrepo "github.com/romankravchuk/muerta/internal/storage/postgres/stats"
 	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
 )
 
@@ -18,8 +36,28 @@ func NewRouter(
 ) *fiber.App {
 	r := fiber.New()
 	repo := repo.New(client)
-	svc := svc.New(repo)
+	shelfLives := shelflife.New(client)
+	recipes := reciperepo.New(client)
+	conversions := conversion.New(measurerepo.New(client), productrepo.New(client))
+	svc := svc.New(repo, shelfLives)
 	h := New(svc, log)
+	nh := notification.New(notificationsvc.New(notificationrepo.New(client)), log)
+	ch := calendar.New(calendarsvc.New(repo), log)
+	sh := stats.New(statssvc.New(statsrepo.New(client), conversions), log)
+	rh := suggestion.New(
+		suggestionsvc.New(repo, recipes, conversions),
+		log,
+	)
+	lh := shoppinglist.New(
+		shoppinglistsvc.New(
+			shoppinglistrepo.New(client),
+			recipes,
+			repo,
+			shelfLives,
+			conversions,
+		),
+		log,
+	)
 	r.Get("/", h.FindMany)
 	r.Post("/", jware.DeserializeUser, access.AdminOnly(log), h.Create)
 	r.Route(context.UserID.Path(), func(r fiber.Router) {
@@ -29,7 +67,7 @@ func NewRouter(
 		r.Patch("/", jware.DeserializeUser, access.OwnerOnly(log), h.Restore)
 		r.Delete("/", jware.DeserializeUser, access.OwnerOnly(log), h.Delete)
 		r.Route("/shelf-lives", func(router fiber.Router) {
-			router.Get("/", h.FindShelfLives)
+			router.Get("/", jware.DeserializeUser, access.OwnerOnly(log), h.FindShelfLives)
 			router.Post("/", jware.DeserializeUser, access.OwnerOnly(log), h.CreateShelfLife)
 			router.Route(context.ShelfLifeID.Path(), func(router fiber.Router) {
 				router.Use(context.New(log, context.ShelfLifeID))
@@ -41,18 +79,55 @@ func NewRouter(
 		r.Route("/settings", func(router fiber.Router) {
 			router.Get("/", jware.DeserializeUser, access.OwnerOnly(log), h.FindSettings)
 			router.Route(context.SettingID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.SettingID))
 				router.Put("/", jware.DeserializeUser, access.OwnerOnly(log), h.UpdateSetting)
 			})
 		})
+		r.Route("/notifications", func(router fiber.Router) {
+			router.Use(jware.DeserializeUser)
+			router.Use(access.OwnerOnly(log))
+			router.Get("/", nh.FindMany)
+			router.Route(context.NotificationID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.NotificationID))
+				router.Post("/read", nh.MarkRead)
+				router.Post("/unread", nh.MarkUnread)
+			})
+		})
+		r.Route("/calendar/token", func(router fiber.Router) {
+			router.Use(jware.DeserializeUser)
+			router.Use(access.OwnerOnly(log))
+			router.Post("/", ch.CreateToken)
+			router.Delete("/", ch.RevokeToken)
+		})
+		r.Get("/stats/waste", jware.DeserializeUser, access.OwnerOnly(log), sh.UserWaste)
+		r.Get("/recipe-suggestions", jware.DeserializeUser, access.OwnerOnly(log), rh.FindMany)
+		r.Route("/shopping-lists", func(router fiber.Router) {
+			router.Use(jware.DeserializeUser)
+			router.Use(access.OwnerOnly(log))
+			router.Get("/", lh.FindMany)
+			router.Post("/", lh.Create)
+			router.Route(context.ShoppingListID.Path(), func(router fiber.Router) {
+				router.Use(context.New(log, context.ShoppingListID))
+				router.Get("/", lh.FindOne)
+				router.Delete("/", lh.Delete)
+				router.Post("/generate", lh.Generate)
+				router.Post("/items", lh.CreateItem)
+				router.Route("/items"+context.ItemID.Path(), func(router fiber.Router) {
+					router.Use(context.New(log, context.ItemID))
+					router.Delete("/", lh.DeleteItem)
+					router.Post("/check", lh.CheckItem)
+					router.Post("/uncheck", lh.UncheckItem)
+				})
+			})
+		})
 		r.Get("/roles", h.FindRoles)
 		r.Route("/storages", func(router fiber.Router) {
-			router.Get("/", h.FindStorages)
+			router.Get("/", jware.DeserializeUser, access.OwnerOnly(log), h.FindStorages)
 			router.Route(context.StorageID.Path(), func(router fiber.Router) {
 				router.Use(context.New(log, context.StorageID))
 				router.Use(jware.DeserializeUser)
-				router.Use(access.OwnerOnly(log))
-				router.Post("/", h.AddStorage)
-				router.Delete("/", h.RemoveStorage)
+				router.Post("/", access.OwnerOnly(log), h.AddStorage)
+				router.Delete("/", access.OwnerOnly(log), h.RemoveStorage)
 			})
 		})
 	})
@@ -3,6 +3,8 @@ package v1
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/auth"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/calendar"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/household"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/measure"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/product"
 	productcategory "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/product-category"
@@ -11,6 +13,7 @@ import (
 	shelflife "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shelf-life"
 	shelflifedetector "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shelf-life-detector"
 	shelflifestatus "github.com/romankravchuk/muerta/internal/api/router/controllers/v1/shelf-life-status"
+	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/stats"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/step"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/tip"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers/v1/user"
@@ -33,18 +36,21 @@ func New(
 ) {
 	jware := jware.New(cfg, log)
 	app.Mount("/auth", auth.NewRouter(cfg, db, log, cache, jware))
-	app.Mount("/shelf-life-detector", shelflifedetector.NewRouter(cfg, log, jware))
+	app.Mount("/shelf-life-detector", shelflifedetector.NewRouter(cfg, db, log, jware))
 	app.Mount("/recipes", recipe.NewRouter(db, log, jware))
 	app.Mount("/users", user.NewRouter(db, log, jware))
 	app.Mount("/settings", usersetting.NewRouter(db, log, jware))
 	app.Mount("/storages", vault.NewRouter(db, log, jware))
+	app.Mount("/households", household.NewRouter(db, log, jware))
 	app.Mount("/products", product.NewRouter(db, log, jware))
 	app.Mount("/roles", role.NewRouter(db, log, jware))
 	app.Mount("/product-categories", productcategory.NewRouter(db, log, jware))
 	app.Mount("/tips", tip.NewRouter(db, log, jware))
 	app.Mount("/measures", measure.NewRouter(db, log, jware))
 	app.Mount("/steps", step.NewRouter(db, log, jware))
-	app.Mount("/shelf-lives", shelflife.NewRouter(db, log, jware))
+	app.Mount("/shelf-lives", shelflife.NewRouter(cfg, db, log, jware))
 	app.Mount("/shelf-life-statuses", shelflifestatus.NewRouter(db, log, jware))
 	app.Mount("/storage-types", storagetype.NewRouter(db, log, jware))
+	app.Mount("/stats", stats.NewRouter(db, log, jware))
+	app.Mount("/calendar", calendar.NewRouter(db, log))
 }
@@ -1,14 +1,17 @@
 package vault
 
 import (
+	errs "errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/romankravchuk/muerta/internal/api/router/controllers"
+	"github.com/romankravchuk/muerta/internal/api/router/middleware/access"
 	"github.com/romankravchuk/muerta/internal/api/router/middleware/context"
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/api/router/utils"
 	"github.com/romankravchuk/muerta/internal/api/validator"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 	service "github.com/romankravchuk/muerta/internal/services/storage"
 )
@@ -37,6 +40,7 @@ func New(svc service.StorageServicer, log logger.Logger) *VaultController {
 //	@Failure		400		{object}	handlers.HTTPError
 //	@Failure		500		{object}	handlers.HTTPError
 //	@Router			/storages [get]
+//	@Security		Bearer
 func (h *VaultController) FindMany(ctx *fiber.Ctx) error {
 	filter := new(params.StorageFilter)
 	if err := utils.ParseFilterAndValidate(ctx, filter); err != nil {
@@ -49,13 +53,14 @@ func (h *VaultController) FindMany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	result, err := h.svc.FindStorages(ctx.Context(), filter)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindStorages(ctx.Context(), user, filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
-	count, err := h.svc.Count(ctx.Context(), *filter)
+	count, err := h.svc.Count(ctx.Context(), user, *filter)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
@@ -79,13 +84,20 @@ func (h *VaultController) FindMany(ctx *fiber.Ctx) error {
 //	@Param			id_storage	path		int	true	"Storage ID"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage} [get]
+//	@Security		Bearer
 func (h *VaultController) FindOne(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.StorageID).(int)
-	result, err := h.svc.FindStorageByID(ctx.Context(), id)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindStorageByID(ctx.Context(), user, id)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -117,7 +129,8 @@ func (h *VaultController) Create(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.CreateStorage(ctx.Context(), payload); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.CreateStorage(ctx.Context(), user, payload); err != nil {
 		h.log.Error(ctx, logger.Server, err)
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
@@ -136,6 +149,7 @@ func (h *VaultController) Create(ctx *fiber.Ctx) error {
 //	@Param			payload		body		dto.UpdateStorage	true	"Storage"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage} [put]
 //	@Security		Bearer
@@ -152,8 +166,13 @@ func (h *VaultController) Update(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadRequest.Error()})
 	}
-	if err := h.svc.UpdateStorage(ctx.Context(), id, payload); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.UpdateStorage(ctx.Context(), user, id, payload); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -170,13 +189,19 @@ func (h *VaultController) Update(ctx *fiber.Ctx) error {
 //	@Param			id_storage	path		int	true	"Storage ID"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage} [delete]
 //	@Security		Bearer
 func (h *VaultController) Delete(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.StorageID).(int)
-	if err := h.svc.DeleteStorage(ctx.Context(), id); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.DeleteStorage(ctx.Context(), user, id); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -193,13 +218,19 @@ func (h *VaultController) Delete(ctx *fiber.Ctx) error {
 //	@Param			id_storage	path		int	true	"Storage ID"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage} [patch]
 //	@Security		Bearer
 func (h *VaultController) Restore(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.StorageID).(int)
-	if err := h.svc.RestoreStorage(ctx.Context(), id); err != nil {
+	user, _ := access.Payload(ctx)
+	if err := h.svc.RestoreStorage(ctx.Context(), user, id); err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -216,13 +247,20 @@ func (h *VaultController) Restore(ctx *fiber.Ctx) error {
 //	@Param			id_storage	path		int	true	"Storage ID"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage}/tips [get]
+//	@Security		Bearer
 func (h *VaultController) FindTips(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.StorageID).(int)
-	result, err := h.svc.FindTips(ctx.Context(), id)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindTips(ctx.Context(), user, id)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -290,13 +328,20 @@ func (h *VaultController) RemoveTip(ctx *fiber.Ctx) error {
 //	@Param			id_storage	path		int	true	"Storage ID"
 //	@Success		200			{object}	handlers.HTTPSuccess
 //	@Failure		400			{object}	handlers.HTTPError
+//	@Failure		403			{object}	handlers.HTTPError
 //	@Failure		500			{object}	handlers.HTTPError
 //	@Router			/storages/{id_storage}/shelf-lives [get]
+//	@Security		Bearer
 func (h *VaultController) FindShelfLives(ctx *fiber.Ctx) error {
 	id := ctx.Locals(context.StorageID).(int)
-	result, err := h.svc.FindShelfLives(ctx.Context(), id)
+	user, _ := access.Payload(ctx)
+	result, err := h.svc.FindShelfLives(ctx.Context(), user, id)
 	if err != nil {
 		h.log.Error(ctx, logger.Server, err)
+		if errs.Is(err, errors.ErrNotOwner) {
+			return ctx.Status(http.StatusForbidden).
+				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
+		}
 		return ctx.Status(http.StatusBadGateway).
 			JSON(controllers.HTTPError{Error: fiber.ErrBadGateway.Error()})
 	}
@@ -20,20 +20,21 @@ func NewRouter(
 	repo := repo.New(client)
 	svc := svc.New(repo)
 	handler := New(svc, log)
+	router.Use(jware.DeserializeUser)
 	router.Get("/", handler.FindMany)
-	router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.Create)
+	router.Post("/", handler.Create)
 	router.Route(context.StorageID.Path(), func(router fiber.Router) {
 		router.Use(context.New(log, context.StorageID))
 		router.Get("/", handler.FindOne)
-		router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.Delete)
-		router.Put("/", jware.DeserializeUser, access.AdminOnly(log), handler.Update)
-		router.Patch("/", jware.DeserializeUser, access.AdminOnly(log), handler.Restore)
+		router.Delete("/", handler.Delete)
+		router.Put("/", handler.Update)
+		router.Patch("/", handler.Restore)
 		router.Route("/tips", func(router fiber.Router) {
 			router.Get("/", handler.FindTips)
 			router.Route(context.TipID.Path(), func(router fiber.Router) {
 				router.Use(context.New(log, context.TipID))
-				router.Post("/", jware.DeserializeUser, access.AdminOnly(log), handler.AddTip)
-				router.Delete("/", jware.DeserializeUser, access.AdminOnly(log), handler.RemoveTip)
+				router.Post("/", access.AdminOnly(log), handler.AddTip)
+				router.Delete("/", access.AdminOnly(log), handler.RemoveTip)
 			})
 		})
 		router.Route("/shelf-lives", func(router fiber.Router) {
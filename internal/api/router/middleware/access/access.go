@@ -11,18 +11,22 @@ import (
 	"github.com/romankravchuk/muerta/internal/pkg/logger"
 )
 
+// Payload returns the token payload stored by the jwt middleware.
+func Payload(ctx *fiber.Ctx) (*params.TokenPayload, bool) {
+	payload, ok := ctx.Locals("user").(*params.TokenPayload)
+	return payload, ok
+}
+
 func AdminOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
 	return func(ctx *fiber.Ctx) error {
-		payload, ok := ctx.Locals("user").(*params.TokenPayload)
+		payload, ok := Payload(ctx)
 		if !ok {
 			l.Error(ctx, logger.Client, errors.ErrFailedToGetTokenPayload)
 			return ctx.Status(http.StatusForbidden).
 				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
 		}
-		for _, role := range payload.Roles {
-			if role == "admin" {
-				return ctx.Next()
-			}
+		if payload.IsAdmin() {
+			return ctx.Next()
 		}
 		l.Error(ctx, logger.Client, errors.ErrNotAdmin)
 		return ctx.Status(http.StatusForbidden).
@@ -38,20 +42,15 @@ func OwnerOnly(l logger.Logger) func(ctx *fiber.Ctx) error {
 			return ctx.Status(http.StatusForbidden).
 				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
 		}
-		payload, ok := ctx.Locals("user").(*params.TokenPayload)
+		payload, ok := Payload(ctx)
 		if !ok {
 			l.Error(ctx, logger.Client, errors.ErrFailedToGetTokenPayload)
 			return ctx.Status(http.StatusForbidden).
 				JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
 		}
-		if payload.UserID == id {
+		if payload.UserID == id || payload.IsAdmin() {
 			return ctx.Next()
 		}
-		for _, role := range payload.Roles {
-			if role == "admin" {
-				return ctx.Next()
-			}
-		}
 		l.Error(ctx, logger.Client, errors.ErrNotOwner)
 		return ctx.Status(http.StatusForbidden).
 			JSON(controllers.HTTPError{Error: fiber.ErrForbidden.Error()})
@@ -34,17 +34,24 @@ func (id idKey) String() string {
 }
 
 const (
-	ShelfLifeID idKey = "shelf_life_id"
-	StatusID    idKey = "status_id"
-	StorageID   idKey = "storage_id"
-	TypeID      idKey = "type_id"
-	ProductID   idKey = "product_id"
-	MeasureID   idKey = "measure_id"
-	CategoryID  idKey = "category_id"
-	RecipeID    idKey = "recipe_id"
-	StepID      idKey = "step_id"
-	TipID       idKey = "tip_id"
-	UserID      idKey = "user_id"
-	SettingID   idKey = "setting_id"
-	RoleID      idKey = "role_id"
+	ShelfLifeID    idKey = "shelf_life_id"
+	StatusID       idKey = "status_id"
+	StorageID      idKey = "storage_id"
+	TypeID         idKey = "type_id"
+	ProductID      idKey = "product_id"
+	MeasureID      idKey = "measure_id"
+	CategoryID     idKey = "category_id"
+	RecipeID       idKey = "recipe_id"
+	StepID         idKey = "step_id"
+	TipID          idKey = "tip_id"
+	UserID         idKey = "user_id"
+	SettingID      idKey = "setting_id"
+	RoleID         idKey = "role_id"
+	NotificationID idKey = "notification_id"
+	ShoppingListID idKey = "shopping_list_id"
+	ItemID         idKey = "item_id"
+	HouseholdID    idKey = "household_id"
+	MemberID       idKey = "member_id"
+	JobID          idKey = "job_id"
+	DetectionID    idKey = "detection_id"
 )
@@ -18,6 +18,16 @@ type TokenPayload struct {
 	Roles    []string
 }
 
+// IsAdmin reports whether the token grants the admin role.
+func (p *TokenPayload) IsAdmin() bool {
+	for _, role := range p.Roles {
+		if role == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
 type TokenDetails struct {
 	Token     string
 	UUID      string
@@ -5,6 +5,13 @@ type Paging struct {
 	Offset int `query:"offset" example:"0"  validate:"omitempty,gte=0"`
 }
 
+// OptionalPaging is Paging for the lists returned whole unless the limit is
+// set.
+type OptionalPaging struct {
+	Limit  int `query:"limit"  example:"10" validate:"omitempty,oneof=5 10 15 20 25 30"`
+	Offset int `query:"offset" example:"0"  validate:"omitempty,gte=0"`
+}
+
 type ProductCategoryFilter struct {
 	Paging
 	Name string `query:"name" example:"овощь" validate:"omitempty,gte=1,notblank"`
@@ -25,6 +32,12 @@ type RecipeFilter struct {
 	Name string `query:"name" example:"салат" validate:"omitempty,gte=1,notblank"`
 }
 
+// RecipeIngredientsFilter scales the ingredients of a recipe to the
+// servings. Zero keeps the quantities of the recipe.
+type RecipeIngredientsFilter struct {
+	Servings int `query:"servings" example:"6" validate:"omitempty,gte=1,lte=100"`
+}
+
 type StepFilter struct {
 	Paging
 	Name string `query:"name" example:"налить воду в емкость" validate:"omitempty,gte=1,notblank"`
@@ -41,7 +54,18 @@ type ShelfLifeStatusFilter struct {
 }
 
 type ShelfLifeFilter struct {
-	Paging
+	OptionalPaging
+	ProductID     int    `query:"id_product"     example:"1"          validate:"omitempty,gt=0"`
+	StorageID     int    `query:"id_storage"     example:"1"          validate:"omitempty,gt=0"`
+	MeasureID     int    `query:"id_measure"     example:"1"          validate:"omitempty,gt=0"`
+	StatusID      int    `query:"id_status"      example:"1"          validate:"omitempty,gt=0"`
+	PurchasedFrom string `query:"purchased_from" example:"2023-01-01" validate:"omitempty,datetime=2006-01-02"`
+	PurchasedTo   string `query:"purchased_to"   example:"2023-01-31" validate:"omitempty,datetime=2006-01-02"`
+	EndFrom       string `query:"end_from"       example:"2023-02-01" validate:"omitempty,datetime=2006-01-02"`
+	EndTo         string `query:"end_to"         example:"2023-02-28" validate:"omitempty,datetime=2006-01-02"`
+	ExpiresWithin *int   `query:"expires_within" example:"3"          validate:"omitempty,gte=0"`
+	SortBy        string `query:"sort_by"        example:"end_date"   validate:"omitempty,oneof=end_date purchase_date product"`
+	Order         string `query:"order"          example:"asc"        validate:"omitempty,oneof=asc desc"`
 }
 
 type StorageTypeFilter struct {
@@ -68,3 +92,20 @@ type SettingFilter struct {
 	Paging
 	Name string `query:"name" example:"получать рассылку" validate:"omitempty,gte=1,notblank"`
 }
+
+type NotificationFilter struct {
+	Paging
+	Unread bool `query:"unread" example:"true"`
+}
+
+type WasteFilter struct {
+	From    string `query:"from"     example:"2023-01-01" validate:"omitempty,datetime=2006-01-02"`
+	To      string `query:"to"       example:"2023-12-31" validate:"omitempty,datetime=2006-01-02"`
+	Period  string `query:"period"   example:"month"      validate:"omitempty,oneof=day week month"`
+	GroupBy string `query:"group_by" example:"product"    validate:"omitempty,oneof=product category storage"`
+}
+
+type DetectionFilter struct {
+	Paging
+	Corrected *bool `query:"corrected" example:"false"`
+}
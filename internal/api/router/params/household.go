@@ -0,0 +1,47 @@
+package params
+
+import "time"
+
+type CreateHousehold struct {
+	Name string `json:"name" validate:"required,gte=2,lte=100" example:"Дом"`
+}
+
+type UpdateHousehold struct {
+	Name string `json:"name" validate:"required,gte=2,lte=100" example:"Дача"`
+}
+
+// FindHousehold is the household with the role of the user requesting it.
+type FindHousehold struct {
+	ID        int        `json:"id"                   example:"1"`
+	Name      string     `json:"name"                 example:"Дом"`
+	Role      string     `json:"role,omitempty"       example:"owner"`
+	CreatedAt *time.Time `json:"created_at,omitempty" example:"2023-01-01T00:00:00Z"`
+}
+
+type FindHouseholdMember struct {
+	ID       int        `json:"id"                  example:"1"`
+	Name     string     `json:"name"                example:"user"`
+	Role     string     `json:"role"                example:"editor"`
+	JoinedAt *time.Time `json:"joined_at,omitempty" example:"2023-01-01T00:00:00Z"`
+}
+
+type UpdateHouseholdMember struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer" example:"editor"`
+}
+
+// CreateHouseholdInvitation creates a single use code to join the household
+// with the role. Owners are promoted by the other owners instead.
+type CreateHouseholdInvitation struct {
+	Role string `json:"role" validate:"required,oneof=editor viewer" example:"editor"`
+}
+
+// FindHouseholdInvitation holds the code which is shown only once.
+type FindHouseholdInvitation struct {
+	Code      string    `json:"code"       example:"hHk3b0iZ2Zc8dQp2Qf1R3w"`
+	Role      string    `json:"role"       example:"editor"`
+	ExpiresAt time.Time `json:"expires_at" example:"2023-01-08T00:00:00Z"`
+}
+
+type JoinHousehold struct {
+	Code string `json:"code" validate:"required" example:"hHk3b0iZ2Zc8dQp2Qf1R3w"`
+}
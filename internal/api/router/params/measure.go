@@ -1,14 +1,38 @@
 package params
 
 type CreateMeasure struct {
-	Name string `json:"name" validate:"required,gte=1,notblank" example:"кг"`
+	Name      string  `json:"name"      validate:"required,gte=1,notblank"                example:"кг"`
+	Dimension string  `json:"dimension" validate:"omitempty,oneof=mass volume count"      example:"mass"`
+	Factor    float32 `json:"factor"    validate:"required_with=Dimension,omitempty,gt=0" example:"1000"`
 }
 
+// UpdateMeasure keeps the dimension and the factor of the measure unless
+// they are sent. The factor is required with a new dimension.
 type UpdateMeasure struct {
-	Name string `json:"name" validate:"required,gte=1,notblank" example:"л"`
+	Name      string   `json:"name"      validate:"required,gte=1,notblank"                example:"л"`
+	Dimension *string  `json:"dimension" validate:"omitempty,oneof=mass volume count"      example:"volume"`
+	Factor    *float32 `json:"factor"    validate:"required_with=Dimension,omitempty,gt=0" example:"1000"`
 }
 
 type FindMeasure struct {
-	ID   int    `json:"id"   example:"1"`
-	Name string `json:"name" example:"кг"`
+	ID        int     `json:"id"                  example:"1"`
+	Name      string  `json:"name"                example:"кг"`
+	Dimension string  `json:"dimension,omitempty" example:"mass"`
+	Factor    float32 `json:"factor,omitempty"    example:"1000"`
+}
+
+// ConvertQuantity converts the quantity of the product between the
+// measures. The product is needed to convert between dimensions only.
+type ConvertQuantity struct {
+	ProductID int     `query:"id_product" validate:"gte=0"         example:"1"`
+	FromID    int     `query:"id_from"    validate:"required,gt=0" example:"1"`
+	ToID      int     `query:"id_to"      validate:"required,gt=0" example:"2"`
+	Quantity  float32 `query:"quantity"   validate:"gte=0"         example:"500"`
+}
+
+type ConvertedQuantity struct {
+	From     FindMeasure `json:"from"`
+	To       FindMeasure `json:"to"`
+	Quantity float32     `json:"quantity" example:"500"`
+	Result   float32     `json:"result"   example:"0.5"`
 }
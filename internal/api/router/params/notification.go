@@ -0,0 +1,20 @@
+package params
+
+import "time"
+
+type FindNotification struct {
+	ID          int        `json:"id"                example:"1"`
+	ShelfLifeID int        `json:"id_shelf_life"     example:"1"`
+	Threshold   int        `json:"threshold"         example:"3"`
+	Title       string     `json:"title"             example:"Срок годности истекает"`
+	Message     string     `json:"message"           example:"Молоко: осталось 3 дн."`
+	Read        bool       `json:"read"              example:"false"`
+	ReadAt      *time.Time `json:"read_at,omitempty" example:"2020-01-01T00:00:00Z"`
+	CreatedAt   *time.Time `json:"created_at"        example:"2020-01-01T00:00:00Z"`
+}
+
+type NotificationRun struct {
+	Users  int `json:"users"  example:"10"`
+	Sent   int `json:"sent"   example:"4"`
+	Failed int `json:"failed" example:"0"`
+}
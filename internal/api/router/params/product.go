@@ -12,3 +12,26 @@ type FindProduct struct {
 	ID   int    `json:"id"   example:"1"`
 	Name string `json:"name" example:"Морковь"`
 }
+
+// DefaultDuration is the usual lifetime in days of a product kept in a
+// storage of the type. Zero storage type applies to any storage.
+type DefaultDuration struct {
+	StorageTypeID int `json:"id_storage_type" validate:"gte=0"         example:"1"`
+	Days          int `json:"days"            validate:"required,gt=0" example:"7"`
+}
+
+type UpdateDefaultDurations struct {
+	Durations []DefaultDuration `json:"durations" validate:"unique=StorageTypeID,dive"`
+}
+
+// ProductConversion relates mass, volume and count of a product. Density is
+// grams per millilitre and piece weight is grams per piece. Zero is unknown.
+type ProductConversion struct {
+	Density     float32 `json:"density"      validate:"gte=0" example:"1.03"`
+	PieceWeight float32 `json:"piece_weight" validate:"gte=0" example:"55"`
+}
+
+type FindDefaultDuration struct {
+	StorageType *FindStorageType `json:"storage_type,omitempty"`
+	Days        int              `json:"days"                   example:"7"`
+}
@@ -1,9 +1,12 @@
 package params
 
+import "time"
+
 type CreateRecipe struct {
 	UserID      int          `json:"id_user"               validate:"required,gt=0"                   exmaple:"1"`
 	Name        string       `json:"name"                  validate:"required,gte=2,lte=100,notblank"             example:"Салат"`
 	Description string       `json:"description,omitempty" validate:"lte=200"                                     example:"Салат из миндаля"`
+	Servings    int          `json:"servings,omitempty"    validate:"omitempty,gte=1,lte=100"                     example:"2"`
 	Steps       []RecipeStep `json:"steps"                 validate:"required"`
 	Ingredients []Ingredient `json:"ingredients"           validate:"required"`
 }
@@ -12,12 +15,14 @@ type FindRecipe struct {
 	ID          int        `json:"id"                    example:"1"`
 	Name        string     `json:"name"                  example:"Салат"`
 	Description string     `json:"description,omitempty" example:"Салат из миндаля"`
+	Servings    int        `json:"servings,omitempty"    example:"2"`
 	Steps       []FindStep `json:"steps,omitempty"`
 }
 
 type UpdateRecipe struct {
-	Name        string `json:"name"        validate:"gte=2,lte=100" example:"Салат"`
-	Description string `json:"description" validate:"lte=200"       example:"Салат из миндаля"`
+	Name        string `json:"name"        validate:"gte=2,lte=100"            example:"Салат"`
+	Description string `json:"description" validate:"lte=200"                  example:"Салат из миндаля"`
+	Servings    int    `json:"servings"    validate:"omitempty,gte=1,lte=100" example:"2"`
 }
 
 type DeleteRecipeStep struct {
@@ -27,3 +32,27 @@ type DeleteRecipeStep struct {
 type CreateRecipeStep struct {
 	Place int `json:"place" validate:"required,gt=0" example:"1"`
 }
+
+type RecipeSuggestionFilter struct {
+	Limit int `query:"limit" validate:"omitempty,gte=1,lte=100" example:"10"`
+}
+
+// RecipeSuggestion is a recipe ranked by the ingredients the user has in
+// stock, weighted toward the ones expiring soonest.
+type RecipeSuggestion struct {
+	Recipe    FindRecipe            `json:"recipe"`
+	Score     float64               `json:"score"     example:"1.25"`
+	Available []SuggestedIngredient `json:"available"`
+	Missing   []SuggestedIngredient `json:"missing"`
+}
+
+// SuggestedIngredient is the ingredient of the suggested recipe and the
+// quantity of the product the user has, converted to the measure of the
+// ingredient.
+type SuggestedIngredient struct {
+	Product  FindProduct `json:"product"`
+	Measure  FindMeasure `json:"measure"`
+	Required float32     `json:"required"           example:"200"`
+	InStock  float32     `json:"in_stock"           example:"150"`
+	EndDate  *time.Time  `json:"end_date,omitempty" example:"2023-01-02T00:00:00Z"`
+}
@@ -0,0 +1,112 @@
+package params
+
+import "time"
+
+// DetectedDate is a date found on a photo of a package. Kind tells whether
+// it is the manufacturing or the expiry date, or unknown if neither the
+// surrounding words nor the other dates tell. Confidence is between 0 and 1.
+// Derived dates are not printed but computed from the manufacturing date and
+// the shelf life, e.g. "годен 30 суток", in which case Text is the latter.
+// Box is where Text is on the photo, unless the engine told no positions.
+type DetectedDate struct {
+	Date       time.Time `json:"date"          example:"2022-09-24T00:00:00Z"`
+	Kind       string    `json:"kind"          example:"expiry"`
+	Confidence float64   `json:"confidence"    example:"0.9"`
+	Text       string    `json:"text"          example:"24.09.22"`
+	Derived    bool      `json:"derived"       example:"false"`
+	Box        *Box      `json:"box,omitempty"`
+}
+
+// Box is a rectangle on the photo in pixels from its top left corner, as the
+// photo is stored, before it is turned according to its EXIF orientation.
+type Box struct {
+	X      int `json:"x"      example:"120"`
+	Y      int `json:"y"      example:"340"`
+	Width  int `json:"width"  example:"160"`
+	Height int `json:"height" example:"40"`
+}
+
+// RecognizedWord is a word recognized on the photo, returned to debug the
+// detection. Confidence is between 0 and 1.
+type RecognizedWord struct {
+	Text       string  `json:"text"       example:"24.09.22"`
+	Confidence float64 `json:"confidence" example:"0.93"`
+	Box        Box     `json:"box"`
+}
+
+// LabelSuggestion prefills the shelf life from the label of the product:
+// the product whose name is the most similar to the text, the net weight in
+// the measure it reads best in and the EAN barcodes. The ids are zero if
+// nothing is found.
+type LabelSuggestion struct {
+	ProductID  int      `json:"id_product,omitempty" example:"1"`
+	Product    string   `json:"product,omitempty"    example:"Молоко"`
+	Similarity float64  `json:"similarity,omitempty" example:"0.92"`
+	MeasureID  int      `json:"id_measure,omitempty" example:"1"`
+	Quantity   float32  `json:"quantity,omitempty"   example:"0.9"`
+	Barcodes   []string `json:"barcodes,omitempty"   example:"4607001771234"`
+}
+
+type CreateDetectionJob struct {
+	CallbackURL string `json:"callback_url" form:"callback_url" validate:"omitempty,url" example:"https://example.com/callback"`
+}
+
+// FindDetectionJob is the state of the detection in background. It is also
+// posted to the callback URL once the job is finished.
+type FindDetectionJob struct {
+	ID         int            `json:"id"                    example:"1"`
+	Status     string         `json:"status"                example:"succeeded"`
+	Attempts   int            `json:"attempts"              example:"1"`
+	Dates      []DetectedDate `json:"dates,omitempty"`
+	Error      string         `json:"error,omitempty"       example:"no dates detected"`
+	CreatedAt  *time.Time     `json:"created_at"            example:"2020-01-01T00:00:00Z"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty" example:"2020-01-01T00:00:00Z"`
+}
+
+// ScanShelfLife creates a shelf life from the dates detected on the photo.
+// The purchase date defaults to today. The dates are not detected if the end
+// date is set, e.g. to confirm a draft.
+type ScanShelfLife struct {
+	ProductID    int        `json:"id_product"    form:"id_product"    validate:"required,gt=0" example:"1"`
+	StorageID    int        `json:"id_storage"    form:"id_storage"    validate:"required,gt=0" example:"1"`
+	MeasureID    int        `json:"id_measure"    form:"id_measure"    validate:"required,gt=0" example:"1"`
+	Quantity     float32    `json:"quantity"      form:"quantity"      validate:"required,gt=0" example:"1"`
+	PurchaseDate *time.Time `json:"purchase_date" form:"purchase_date" validate:"omitempty"     example:"2020-01-01T00:00:00Z"`
+	EndDate      *time.Time `json:"end_date"      form:"end_date"      validate:"omitempty"     example:"2020-01-02T00:00:00Z"`
+}
+
+// ShelfLifeDraft is the shelf life the dates of which could not be told
+// apart for sure. It is created once the user scans the photo again with the
+// dates confirmed.
+type ShelfLifeDraft struct {
+	ShelfLife CreateShelfLife `json:"shelf_life"`
+	Dates     []DetectedDate  `json:"dates"`
+	Reason    string          `json:"reason"     example:"no expiry date"`
+}
+
+// FindDetection is a detection of the user with the dates the user
+// confirmed or corrected them with, if any. The recognized words are only
+// set right after the detection and are not recorded.
+type FindDetection struct {
+	ID         int                  `json:"id"                   example:"1"`
+	ImageHash  string               `json:"image_hash"           example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Text       string               `json:"text"                 example:"Годен до: 24.09.22"`
+	Dates      []DetectedDate       `json:"dates"`
+	LatencyMs  int64                `json:"latency_ms"           example:"850"`
+	Correction *DetectionCorrection `json:"correction,omitempty"`
+	CreatedAt  *time.Time           `json:"created_at"           example:"2020-01-01T00:00:00Z"`
+	Words      []RecognizedWord     `json:"words,omitempty"`
+}
+
+// CorrectDetection sets the dates actually printed on the image, confirming
+// or correcting the detected ones. A missing date tells there is none.
+type CorrectDetection struct {
+	Manufactured *time.Time `json:"manufactured" validate:"omitempty" example:"2022-09-15T00:00:00Z"`
+	Expiry       *time.Time `json:"expiry"       validate:"omitempty" example:"2022-09-24T00:00:00Z"`
+}
+
+type DetectionCorrection struct {
+	Manufactured *time.Time `json:"manufactured,omitempty" example:"2022-09-15T00:00:00Z"`
+	Expiry       *time.Time `json:"expiry,omitempty"       example:"2022-09-24T00:00:00Z"`
+	CorrectedAt  *time.Time `json:"corrected_at"           example:"2020-01-01T00:00:00Z"`
+}
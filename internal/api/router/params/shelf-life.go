@@ -2,6 +2,8 @@ package params
 
 import "time"
 
+// CreateShelfLife creates a shelf life. The end date is derived from the
+// default durations of the product if omitted.
 type CreateShelfLife struct {
 	ProductID    int        `json:"id_product"    validate:"required,gt=0"                 example:"1"`
 	UserID       int        `json:"id_user"       validate:"required,gt=0"                 example:"1"`
@@ -9,17 +11,48 @@ type CreateShelfLife struct {
 	MeasureID    int        `json:"id_measure"    validate:"required,gt=0"                 example:"1"`
 	Quantity     float32    `json:"quantity"      validate:"required,gt=0"                 example:"1"`
 	PurchaseDate *time.Time `json:"purchase_date" validate:"required"                      example:"2020-01-01T00:00:00Z"`
-	EndDate      *time.Time `json:"end_date"      validate:"required,gtfield=PurchaseDate"                                exmple:"2020-01-02T00:00:00Z"`
+	EndDate      *time.Time `json:"end_date"      validate:"omitempty,gtfield=PurchaseDate" example:"2020-01-02T00:00:00Z"`
+}
+
+// ShelfLifeRule tells which default duration the end date was derived from.
+type ShelfLifeRule struct {
+	Source      string           `json:"source"                 example:"product"`
+	ID          int              `json:"id"                     example:"1"`
+	Name        string           `json:"name"                   example:"Молоко"`
+	StorageType *FindStorageType `json:"storage_type,omitempty"`
+	Days        int              `json:"days"                   example:"7"`
 }
 
 type FindShelfLife struct {
-	ID           int         `json:"id"            example:"1"`
-	Product      FindProduct `json:"product"`
-	Storage      FindStorage `json:"storage"`
-	Measure      FindMeasure `json:"measure"`
-	Quantity     float32     `json:"quantity"      example:"1"`
-	PurchaseDate *time.Time  `json:"purchase_date" example:"2020-01-01T00:00:00Z"`
-	EndDate      *time.Time  `json:"end_date"      example:"2020-01-02T00:00:00Z"`
+	ID           int                  `json:"id"            example:"1"`
+	Product      FindProduct          `json:"product"`
+	Storage      FindStorage          `json:"storage"`
+	Measure      FindMeasure          `json:"measure"`
+	Quantity     float32              `json:"quantity"      example:"1"`
+	PurchaseDate *time.Time           `json:"purchase_date" example:"2020-01-01T00:00:00Z"`
+	EndDate      *time.Time           `json:"end_date"      example:"2020-01-02T00:00:00Z"`
+	Events       []FindShelfLifeEvent `json:"events,omitempty"`
+	EndDateRule  *ShelfLifeRule       `json:"end_date_rule,omitempty"`
+}
+
+type CreateShelfLifeEvent struct {
+	Type       string     `json:"type"        validate:"required,oneof=consumed discarded given_away" example:"consumed"`
+	Quantity   float32    `json:"quantity"    validate:"required,gt=0"                                example:"0.5"`
+	OccurredAt *time.Time `json:"occurred_at" validate:"omitempty"                                    example:"2020-01-01T12:00:00Z"`
+}
+
+type FindShelfLifeEvent struct {
+	ID         int        `json:"id"          example:"1"`
+	Type       string     `json:"type"        example:"consumed"`
+	Quantity   float32    `json:"quantity"    example:"0.5"`
+	OccurredAt *time.Time `json:"occurred_at" example:"2020-01-01T12:00:00Z"`
+}
+
+// ShelfLifeEventResult is the state of a shelf life after an event.
+type ShelfLifeEventResult struct {
+	Event     FindShelfLifeEvent `json:"event"`
+	Remaining float32            `json:"remaining" example:"0.5"`
+	Closed    bool               `json:"closed"    example:"false"`
 }
 
 type UpdateShelfLife struct {
@@ -30,3 +63,19 @@ type UpdateShelfLife struct {
 	PurchaseDate *time.Time `json:"purchase_date" validate:"required_with=EndDate,ltfield=EndDate"           example:"2020-01-01T00:00:00Z"`
 	EndDate      *time.Time `json:"end_date"      validate:"required_with=PurchaseDate,gtfield=PurchaseDate" example:"2020-01-02T00:00:00Z"`
 }
+
+// ShelfLifeScan is the photo a shelf life is created from with the dates
+// detected on it.
+type ShelfLifeScan struct {
+	Image       []byte
+	ContentType string
+	Dates       []DetectedDate
+}
+
+type StatusEvaluation struct {
+	Processed    int `json:"processed"     example:"10"`
+	Changed      int `json:"changed"       example:"2"`
+	Fresh        int `json:"fresh"         example:"7"`
+	ExpiringSoon int `json:"expiring_soon" example:"2"`
+	Expired      int `json:"expired"       example:"1"`
+}
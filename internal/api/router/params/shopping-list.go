@@ -0,0 +1,49 @@
+package params
+
+import "time"
+
+type CreateShoppingList struct {
+	Name string `json:"name" validate:"required,gte=2,lte=100" example:"На неделю"`
+}
+
+type FindShoppingList struct {
+	ID        int                    `json:"id"                   example:"1"`
+	Name      string                 `json:"name"                 example:"На неделю"`
+	CreatedAt *time.Time             `json:"created_at,omitempty" example:"2023-01-01T00:00:00Z"`
+	Items     []FindShoppingListItem `json:"items,omitempty"`
+}
+
+type CreateShoppingListItem struct {
+	ProductID int     `json:"id_product" validate:"required,gt=0" example:"1"`
+	MeasureID int     `json:"id_measure" validate:"required,gt=0" example:"1"`
+	Quantity  float32 `json:"quantity"   validate:"required,gt=0" example:"1.5"`
+}
+
+type FindShoppingListItem struct {
+	ID          int         `json:"id"                      example:"1"`
+	Product     FindProduct `json:"product"`
+	Measure     FindMeasure `json:"measure"`
+	Quantity    float32     `json:"quantity"                example:"1.5"`
+	Checked     bool        `json:"checked"                 example:"false"`
+	CheckedAt   *time.Time  `json:"checked_at,omitempty"    example:"2023-01-01T00:00:00Z"`
+	ShelfLifeID int         `json:"id_shelf_life,omitempty" example:"1"`
+}
+
+// GenerateShoppingList adds the ingredients of the recipes the user lacks.
+type GenerateShoppingList struct {
+	RecipeIDs []int `json:"id_recipes" validate:"required,min=1,dive,gt=0" example:"1,2"`
+}
+
+// CheckShoppingListItem checks the item off. The item becomes a shelf life
+// kept in the storage if the storage is set. The purchase date defaults to
+// now and the end date to the default duration of the product.
+type CheckShoppingListItem struct {
+	StorageID    int        `json:"id_storage"    validate:"omitempty,gt=0" example:"1"`
+	PurchaseDate *time.Time `json:"purchase_date" validate:"omitempty"      example:"2023-01-01T00:00:00Z"`
+	EndDate      *time.Time `json:"end_date"      validate:"omitempty"      example:"2023-01-08T00:00:00Z"`
+}
+
+type CheckedShoppingListItem struct {
+	Item      FindShoppingListItem `json:"item"`
+	ShelfLife *FindShelfLife       `json:"shelf_life,omitempty"`
+}
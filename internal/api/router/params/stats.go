@@ -0,0 +1,43 @@
+package params
+
+import "time"
+
+type WasteAmount struct {
+	Count    int     `json:"count"    example:"2"`
+	Quantity float32 `json:"quantity" example:"1.5"`
+}
+
+// WasteMeasure holds the outcomes of shelf lives measured in the same measure.
+// The quantities are in the base unit of the measure when it is known:
+// grams, millilitres or pieces.
+type WasteMeasure struct {
+	Measure  FindMeasure `json:"measure"`
+	Consumed WasteAmount `json:"consumed"`
+	// ConsumedExpired is the consumption after the end date.
+	ConsumedExpired WasteAmount `json:"consumed_expired"`
+	Discarded       WasteAmount `json:"discarded"`
+	GivenAway       WasteAmount `json:"given_away"`
+	Expired         WasteAmount `json:"expired"`
+	// WasteRatio is the share of discarded and expired quantity.
+	WasteRatio float32 `json:"waste_ratio" example:"0.25"`
+}
+
+type WasteGroup struct {
+	ID       int            `json:"id"   example:"1"`
+	Name     string         `json:"name" example:"молоко"`
+	Measures []WasteMeasure `json:"measures"`
+}
+
+type WasteBucket struct {
+	Start  time.Time    `json:"start"  example:"2023-01-01T00:00:00Z"`
+	Groups []WasteGroup `json:"groups"`
+}
+
+type WasteStats struct {
+	From    time.Time      `json:"from"     example:"2023-01-01T00:00:00Z"`
+	To      time.Time      `json:"to"       example:"2024-01-01T00:00:00Z"`
+	Period  string         `json:"period"   example:"month"`
+	GroupBy string         `json:"group_by" example:"product"`
+	Buckets []WasteBucket  `json:"buckets"`
+	Totals  []WasteMeasure `json:"totals"`
+}
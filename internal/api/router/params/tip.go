@@ -1,14 +1,34 @@
 package params
 
 type FindTip struct {
-	ID          int    `json:"id"          example:"1"`
-	Description string `json:"description" example:"Хранить в Холодильнике"`
+	ID          int      `json:"id"             example:"1"`
+	Description string   `json:"description"    example:"Хранить в Холодильнике"`
+	Rule        *TipRule `json:"rule,omitempty"`
 }
 
+// TipRule shows the tip for a shelf life only if all of the set conditions
+// hold.
+type TipRule struct {
+	ExpiresWithin    *int     `json:"expires_within,omitempty"    validate:"omitempty,gte=0,lte=365" example:"2"`
+	TemperatureAbove *float32 `json:"temperature_above,omitempty" example:"8"`
+	TemperatureBelow *float32 `json:"temperature_below,omitempty" example:"0"`
+}
+
+// UpdateTip replaces the rule of the tip if it is set. An empty rule makes
+// the tip unconditional.
 type UpdateTip struct {
-	Description string `json:"description" validate:"required,gte=3,lte=200" example:"Хранить в Холодильнике"`
+	Description string   `json:"description" validate:"required,gte=3,lte=200" example:"Хранить в Холодильнике"`
+	Rule        *TipRule `json:"rule"`
 }
 
 type CreateTip struct {
-	Description string `json:"description" validate:"required,gte=3,lte=200" example:"Хранить в Холодильнике"`
+	Description string   `json:"description" validate:"required,gte=3,lte=200" example:"Хранить в Холодильнике"`
+	Rule        *TipRule `json:"rule"`
+}
+
+// FindShelfLifeTip is a tip for the shelf life with the sources it is
+// attached to, e.g. the product and the storage type.
+type FindShelfLifeTip struct {
+	FindTip
+	Sources []string `json:"sources" example:"product,storage_type"`
 }
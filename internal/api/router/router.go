@@ -35,6 +35,8 @@ func NewV1(
 			AppName:     "Muerta API v1.0",
 			JSONEncoder: sonic.Marshal,
 			JSONDecoder: sonic.Unmarshal,
+			// Photos for the shelf life detector are up to 8MB
+			BodyLimit: 10 * 1024 * 1024,
 		}),
 	}
 	r.mountAPIMiddlewares(cfg, logger)
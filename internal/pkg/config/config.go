@@ -14,6 +14,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -49,6 +51,59 @@ type Config struct {
 		// Password for the redis authentication
 		Password string
 	}
+	ShelfLife struct {
+		// Interval between automatic recomputations of shelf life statuses
+		EvaluateInterval time.Duration
+		// Time before the end date when a shelf life is expiring soon
+		ExpiringThreshold time.Duration
+	}
+	Notifications struct {
+		// Interval between notification runs
+		Interval time.Duration
+		// Days before the end date when users are notified, e.g. 3, 1 and 0
+		Thresholds []int
+		// Timeout of webhook requests
+		WebhookTimeout time.Duration
+		// Outgoing mail server for notifications by email
+		SMTP struct {
+			// Host name of the SMTP server, notifications by email are disabled if empty
+			Host string
+			// Port number of the SMTP server
+			Port string
+			// Username for the SMTP authentication
+			User string
+			// Password for the SMTP authentication
+			Password string
+			// Sender address
+			From string
+			// Timeout of sending an email
+			Timeout time.Duration
+		}
+	}
+	Detector struct {
+		// OCR engine recognizing the text: tesseract, http or fake
+		Engine string
+		// Number of Tesseract clients recognizing images in parallel
+		PoolSize int
+		// Timeout of the recognition of an image
+		Timeout time.Duration
+		// Time a request waits for a free client before it is rejected
+		QueueTimeout time.Duration
+		// URL of the OCR service used by the http engine
+		URL string
+		// Bearer token for the OCR service authentication
+		Token string
+		// Text recognized on any image by the fake engine
+		FakeText string
+		// Number of workers running the detection jobs
+		Workers int
+		// Number of attempts to run a detection job before it fails
+		JobAttempts int
+		// Delay before the first retry of a detection job, doubled after each retry
+		JobBackoff time.Duration
+		// Time the finished detection jobs are kept
+		JobRetention time.Duration
+	}
 	// Private key for signing access tokens
 	AccessTokenPrivateKey []byte
 	// Public key for verifying access tokens
@@ -145,5 +200,154 @@ func New() (*Config, error) {
 		),
 		ShutdownShelfDetectorChan: make(chan struct{}, 1),
 	}
+	cfg.ShelfLife.EvaluateInterval, err = positiveDurationFromEnv("SHELF_LIFE_EVALUATE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ShelfLife.ExpiringThreshold, err = durationFromEnv(
+		"SHELF_LIFE_EXPIRING_THRESHOLD",
+		time.Hour*72,
+	)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notifications.Interval, err = positiveDurationFromEnv("NOTIFY_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notifications.Thresholds, err = intsFromEnv("NOTIFY_THRESHOLDS", []int{3, 1, 0})
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notifications.WebhookTimeout, err = positiveDurationFromEnv("NOTIFY_WEBHOOK_TIMEOUT", time.Second*10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notifications.SMTP.Host = os.Getenv("SMTP_HOST")
+	cfg.Notifications.SMTP.Port = os.Getenv("SMTP_PORT")
+	cfg.Notifications.SMTP.User = os.Getenv("SMTP_USER")
+	cfg.Notifications.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	cfg.Notifications.SMTP.From = os.Getenv("SMTP_FROM")
+	cfg.Notifications.SMTP.Timeout, err = positiveDurationFromEnv("SMTP_TIMEOUT", time.Second*30)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.Engine = os.Getenv("DETECTOR_ENGINE")
+	switch cfg.Detector.Engine {
+	case "":
+		cfg.Detector.Engine = "tesseract"
+	case "tesseract", "fake":
+	case "http":
+		if os.Getenv("DETECTOR_OCR_URL") == "" {
+			return nil, fmt.Errorf("DETECTOR_OCR_URL is required by the http engine")
+		}
+	default:
+		return nil, fmt.Errorf("unknown DETECTOR_ENGINE: %s", cfg.Detector.Engine)
+	}
+	cfg.Detector.URL = os.Getenv("DETECTOR_OCR_URL")
+	cfg.Detector.Token = os.Getenv("DETECTOR_OCR_TOKEN")
+	cfg.Detector.FakeText = os.Getenv("DETECTOR_FAKE_TEXT")
+	cfg.Detector.PoolSize, err = positiveIntFromEnv("DETECTOR_POOL_SIZE", runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.Timeout, err = positiveDurationFromEnv("DETECTOR_TIMEOUT", time.Second*10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.QueueTimeout, err = positiveDurationFromEnv("DETECTOR_QUEUE_TIMEOUT", time.Second)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.Workers, err = positiveIntFromEnv("DETECTOR_WORKERS", 1)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.JobAttempts, err = positiveIntFromEnv("DETECTOR_JOB_ATTEMPTS", 3)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.JobBackoff, err = positiveDurationFromEnv("DETECTOR_JOB_BACKOFF", time.Second*10)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Detector.JobRetention, err = positiveDurationFromEnv("DETECTOR_JOB_RETENTION", time.Hour*24*7)
+	if err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
+
+// intFromEnv parses the environment variable as an integer, falling back to
+// the given value when the variable is not set.
+func intFromEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", key, err)
+	}
+	return n, nil
+}
+
+// positiveIntFromEnv parses the environment variable like intFromEnv and
+// rejects zero and negative values, which leave the pools and workers idle.
+func positiveIntFromEnv(key string, fallback int) (int, error) {
+	n, err := intFromEnv(key, fallback)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be positive: %d", key, n)
+	}
+	return n, nil
+}
+
+// intsFromEnv parses the environment variable as a comma separated list of
+// integers, falling back to the given value when the variable is not set.
+func intsFromEnv(key string, fallback []int) ([]int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	pieces := strings.Split(value, ",")
+	result := make([]int, len(pieces))
+	for i, piece := range pieces {
+		n, err := strconv.Atoi(strings.TrimSpace(piece))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", key, err)
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+// durationFromEnv parses the environment variable as a duration, falling back
+// to the given value when the variable is not set.
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// positiveDurationFromEnv parses the environment variable like
+// durationFromEnv and rejects zero and negative durations, which the tickers
+// panic on.
+func positiveDurationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	d, err := durationFromEnv(key, fallback)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive: %s", key, d)
+	}
+	return d, nil
+}
@@ -3,6 +3,16 @@ package errors
 var (
 	ErrNotAdmin = New("user is not admin")
 	ErrNotOwner = New("user is not owner")
+
+	ErrInvalidCalendarToken = New("invalid calendar token")
+	ErrInvalidInvitation    = New("invalid invitation")
+	ErrLastOwner            = New("household must keep an owner")
+	ErrUnsafeURL            = New("url must be https and must not point to an internal address")
+
+	ErrInvalidImage     = New("invalid image")
+	ErrNoDatesDetected  = New("no dates detected")
+	ErrDetectorBusy     = New("detector is busy")
+	ErrDetectionTimeout = New("detection timed out")
 )
 
 var (
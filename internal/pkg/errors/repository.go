@@ -37,4 +37,26 @@ var (
 	ErrFailedToUpdateShelfLife  = New("failed to update shelf life")
 	ErrFailedToDeleteShelfLife  = New("failed to delete shelf life")
 	ErrFailedToRestoreShelfLife = New("failed to restore shelf life")
+	ErrNotEnoughQuantity        = New("not enough quantity")
+	ErrShelfLifeClosed          = New("shelf life is closed")
+	ErrNoDefaultDuration        = New("no default duration")
+	ErrItemAlreadyStocked       = New("item is already in stock")
+	ErrShoppingListNotFound     = New("shopping list not found")
+	ErrScanNotFound             = New("shelf life scan not found")
+)
+
+var (
+	ErrMeasureNotFound        = New("measure not found")
+	ErrMeasuresNotConvertible = New("measures are not convertible")
+)
+
+var (
+	ErrHouseholdNotFound  = New("household not found")
+	ErrMemberNotFound     = New("household member not found")
+	ErrStorageInHousehold = New("storage already belongs to a household")
+)
+
+var (
+	ErrJobNotFound       = New("detection job not found")
+	ErrDetectionNotFound = New("detection not found")
 )
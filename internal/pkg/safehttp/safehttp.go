@@ -0,0 +1,92 @@
+// Package safehttp makes the requests to the URLs given by the users, e.g.
+// the webhooks, which must not reach the internal network of the server.
+package safehttp
+
+import (
+	errs "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+)
+
+// maxRedirects is how many redirects are followed, like the default client.
+const maxRedirects = 10
+
+// sharedAddressSpace is the carrier-grade NAT range, internal like the
+// private ranges.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ValidateURL checks that the URL is an https one and that its host is not
+// an internal address. The host names are not resolved, so the client
+// checks the addresses again when it connects.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrUnsafeURL, err)
+	}
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: %s", errors.ErrUnsafeURL, raw)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", errors.ErrUnsafeURL, raw)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && isInternal(addr) {
+		return fmt.Errorf("%w: %s", errors.ErrUnsafeURL, raw)
+	}
+	return nil
+}
+
+// NewClient returns the client refusing to connect to the internal
+// addresses. The addresses are checked once the host name is resolved, so
+// a public name resolving to an internal address is refused too, and the
+// redirects are validated like the URLs.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: refuseInternal}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect to the addresses instead of the dialer.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errs.New("too many redirects")
+			}
+			return ValidateURL(req.URL.String())
+		},
+	}
+}
+
+// refuseInternal is called by the dialer with the resolved address before
+// connecting to it.
+func refuseInternal(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrUnsafeURL, err)
+	}
+	if isInternal(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errors.ErrUnsafeURL, address)
+	}
+	return nil
+}
+
+func isInternal(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr)
+}
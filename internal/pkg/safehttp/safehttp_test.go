@@ -0,0 +1,55 @@
+package safehttp
+
+import (
+	"context"
+	errs "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+)
+
+func Test_ValidateURL(t *testing.T) {
+	testCases := []struct {
+		url  string
+		safe bool
+	}{
+		{url: "https://example.com/hook", safe: true},
+		{url: "https://93.184.216.34:8443/hook", safe: true},
+		{url: "http://example.com/hook"},
+		{url: "ftp://example.com/hook"},
+		{url: "https:///hook"},
+		{url: "https://localhost/hook"},
+		{url: "https://api.localhost./hook"},
+		{url: "https://127.0.0.1/hook"},
+		{url: "https://10.0.0.5/hook"},
+		{url: "https://169.254.169.254/latest/meta-data"},
+		{url: "https://100.64.0.1/hook"},
+		{url: "https://[::1]/hook"},
+		{url: "https://[::ffff:192.168.0.1]/hook"},
+		{url: "https://0.0.0.0/hook"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			err := ValidateURL(tc.url)
+			if tc.safe {
+				assert.Nil(t, err)
+			} else {
+				assert.True(t, errs.Is(err, errors.ErrUnsafeURL), err)
+			}
+		})
+	}
+}
+
+func Test_NewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, nil)
+	assert.Nil(t, err)
+	_, err = NewClient(time.Second).Do(req)
+	assert.True(t, errs.Is(err, errors.ErrUnsafeURL), err)
+}
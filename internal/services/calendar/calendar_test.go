@@ -0,0 +1,114 @@
+package calendar
+
+import (
+	"context"
+	errs "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUsers struct {
+	user.UserStorage
+	tokens     map[string]int
+	shelfLives []models.ShelfLife
+}
+
+func (u *fakeUsers) SaveCalendarToken(_ context.Context, id int, hash string) error {
+	for token, owner := range u.tokens {
+		if owner == id {
+			delete(u.tokens, token)
+		}
+	}
+	u.tokens[hash] = id
+	return nil
+}
+
+func (u *fakeUsers) DeleteCalendarToken(ctx context.Context, id int) error {
+	for token, owner := range u.tokens {
+		if owner == id {
+			delete(u.tokens, token)
+		}
+	}
+	return nil
+}
+
+func (u *fakeUsers) FindByCalendarToken(_ context.Context, hash string) (models.User, error) {
+	id, ok := u.tokens[hash]
+	if !ok {
+		return models.User{}, pgx.ErrNoRows
+	}
+	return models.User{ID: id, Name: "user"}, nil
+}
+
+func (u *fakeUsers) FindShelfLives(_ context.Context, _ int) ([]models.ShelfLife, error) {
+	return u.shelfLives, nil
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func Test_Encode(t *testing.T) {
+	shelfLives := []models.ShelfLife{
+		{
+			ID:       7,
+			Product:  models.Product{Name: "молоко, 3,2%"},
+			Measure:  models.Measure{Name: "л"},
+			Storage:  models.Vault{Name: "холодильник"},
+			Quantity: 1.5,
+			EndDate:  date(2023, 5, 31),
+		},
+		{ID: 8, Product: models.Product{Name: "соль"}},
+	}
+	now := time.Date(2023, 5, 17, 10, 0, 0, 0, time.UTC)
+
+	feed := string(Encode("user", shelfLives, now))
+
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 1, strings.Count(feed, "BEGIN:VEVENT"))
+	assert.Contains(t, feed, "UID:shelf-life-7@muerta\r\n")
+	assert.Contains(t, feed, "DTSTART;VALUE=DATE:20230531\r\n")
+	assert.Contains(t, feed, "DTEND;VALUE=DATE:20230601\r\n")
+	assert.Contains(t, feed, "DTSTAMP:20230517T100000Z\r\n")
+	assert.Contains(t, feed, "TRIGGER:-PT15H\r\n")
+	assert.Contains(t, feed, `1.5 л\, холодильник`)
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+	unfolded := strings.ReplaceAll(feed, "\r\n ", "")
+	assert.Contains(t, unfolded, `SUMMARY:Срок годности истекает: молоко\, 3\,2%`)
+}
+
+func Test_Token(t *testing.T) {
+	users := &fakeUsers{
+		tokens:     map[string]int{},
+		shelfLives: []models.ShelfLife{{ID: 1, EndDate: date(2023, 5, 31)}},
+	}
+	svc := New(users)
+	ctx := context.Background()
+
+	first, err := svc.CreateToken(ctx, 1)
+	assert.Nil(t, err)
+	feed, err := svc.Feed(ctx, first)
+	assert.Nil(t, err)
+	assert.Contains(t, string(feed), EventUID(1))
+
+	second, err := svc.CreateToken(ctx, 1)
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+	_, err = svc.Feed(ctx, first)
+	assert.True(t, errs.Is(err, errors.ErrInvalidCalendarToken))
+
+	assert.Nil(t, svc.RevokeToken(ctx, 1))
+	_, err = svc.Feed(ctx, second)
+	assert.True(t, errs.Is(err, errors.ErrInvalidCalendarToken))
+}
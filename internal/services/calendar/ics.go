@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+const (
+	// maxLineOctets is the limit of a content line before folding (RFC 5545 3.1).
+	maxLineOctets = 75
+	// alarmTrigger fires at 09:00 the day before the all-day event.
+	alarmTrigger = "-PT15H"
+)
+
+// EventUID is the unique identifier of the event of the shelf life. It
+// depends on the shelf life only, so calendar clients replace the event when
+// the end date changes instead of adding another one.
+func EventUID(shelfLifeID int) string {
+	return fmt.Sprintf("shelf-life-%d@muerta", shelfLifeID)
+}
+
+// Encode renders the shelf lives as an iCalendar feed with an all-day event
+// on the end date of each shelf life. Shelf lives without an end date are
+// skipped.
+func Encode(name string, shelfLives []models.ShelfLife, now time.Time) []byte {
+	w := &icsWriter{}
+	w.line("BEGIN:VCALENDAR")
+	w.line("VERSION:2.0")
+	w.line("PRODID:-//muerta//shelf lives//RU")
+	w.line("CALSCALE:GREGORIAN")
+	w.line("METHOD:PUBLISH")
+	w.line("X-WR-CALNAME:" + escape(name))
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, shelfLife := range shelfLives {
+		if shelfLife.EndDate == nil {
+			continue
+		}
+		end := *shelfLife.EndDate
+		summary := fmt.Sprintf("Срок годности истекает: %s", shelfLife.Product.Name)
+		w.line("BEGIN:VEVENT")
+		w.line("UID:" + EventUID(shelfLife.ID))
+		w.line("DTSTAMP:" + stamp)
+		w.line("DTSTART;VALUE=DATE:" + end.Format("20060102"))
+		w.line("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format("20060102"))
+		w.line("SUMMARY:" + escape(summary))
+		w.line("DESCRIPTION:" + escape(fmt.Sprintf(
+			"%g %s, %s",
+			shelfLife.Quantity,
+			shelfLife.Measure.Name,
+			shelfLife.Storage.Name,
+		)))
+		w.line("TRANSP:TRANSPARENT")
+		w.line("BEGIN:VALARM")
+		w.line("ACTION:DISPLAY")
+		w.line("DESCRIPTION:" + escape(summary))
+		w.line("TRIGGER:" + alarmTrigger)
+		w.line("END:VALARM")
+		w.line("END:VEVENT")
+	}
+	w.line("END:VCALENDAR")
+	return []byte(w.String())
+}
+
+type icsWriter struct {
+	strings.Builder
+}
+
+// line writes the content line terminated by CRLF, folding it into lines of
+// at most 75 octets without splitting multi-byte characters.
+func (w *icsWriter) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+		// the leading space of a continuation line counts towards the limit
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// escape escapes the TEXT value (RFC 5545 3.3.11).
+func escape(s string) string {
+	return escaper.Replace(s)
+}
@@ -0,0 +1,87 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	errs "errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+// tokenBytes is the amount of random bytes in a calendar token.
+const tokenBytes = 32
+
+// CalendarServicer serves the expiry dates of the user as an iCalendar feed.
+// Calendar clients can't send the Authorization header, so the feed is
+// protected by a random token instead of the JWT. Only the hash of the token
+// is stored and creating a new token revokes the previous one.
+type CalendarServicer interface {
+	CreateToken(ctx context.Context, userID int) (string, error)
+	RevokeToken(ctx context.Context, userID int) error
+	// Feed returns errors.ErrInvalidCalendarToken if the token is unknown or revoked.
+	Feed(ctx context.Context, token string) ([]byte, error)
+}
+
+type calendarService struct {
+	users user.UserStorage
+	now   func() time.Time
+}
+
+// CreateToken implements CalendarServicer
+func (s *calendarService) CreateToken(ctx context.Context, userID int) (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating calendar token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	if err := s.users.SaveCalendarToken(ctx, userID, hashToken(token)); err != nil {
+		return "", fmt.Errorf("error saving calendar token: %w", err)
+	}
+	return token, nil
+}
+
+// RevokeToken implements CalendarServicer
+func (s *calendarService) RevokeToken(ctx context.Context, userID int) error {
+	if err := s.users.DeleteCalendarToken(ctx, userID); err != nil {
+		return fmt.Errorf("error revoking calendar token: %w", err)
+	}
+	return nil
+}
+
+// Feed implements CalendarServicer
+func (s *calendarService) Feed(ctx context.Context, token string) ([]byte, error) {
+	if token == "" {
+		return nil, errors.ErrInvalidCalendarToken
+	}
+	user, err := s.users.FindByCalendarToken(ctx, hashToken(token))
+	if errs.Is(err, pgx.ErrNoRows) {
+		return nil, errors.ErrInvalidCalendarToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error finding calendar token: %w", err)
+	}
+	shelfLives, err := s.users.FindShelfLives(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shelf lives: %w", err)
+	}
+	return Encode(user.Name, shelfLives, s.now()), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func New(users user.UserStorage) CalendarServicer {
+	return &calendarService{
+		users: users,
+		now:   time.Now,
+	}
+}
@@ -21,6 +21,15 @@ type CategoryServicer interface {
 	DeleteCategory(ctx context.Context, id int) error
 	RestoreCategory(ctx context.Context, id int) error
 	Count(ctx context.Context, filter params.ProductCategoryFilter) (int, error)
+	FindCategoryDurations(ctx context.Context, id int) ([]params.FindDefaultDuration, error)
+	UpdateCategoryDurations(
+		ctx context.Context,
+		id int,
+		payload *params.UpdateDefaultDurations,
+	) ([]params.FindDefaultDuration, error)
+	FindTips(ctx context.Context, id int) ([]params.FindTip, error)
+	CreateTip(ctx context.Context, id, tipID int) (params.FindTip, error)
+	DeleteTip(ctx context.Context, id, tipID int) error
 }
 
 type categoryService struct {
@@ -117,8 +126,59 @@ func (svc *categoryService) UpdateCategory(
 	return nil
 }
 
+// FindCategoryDurations implements CategoryServicer
+func (svc *categoryService) FindCategoryDurations(
+	ctx context.Context,
+	id int,
+) ([]params.FindDefaultDuration, error) {
+	durations, err := svc.repo.FindDurations(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding category durations: %w", err)
+	}
+	return utils.DefaultDurationModelsToFinds(durations), nil
+}
+
+// UpdateCategoryDurations implements CategoryServicer
+func (svc *categoryService) UpdateCategoryDurations(
+	ctx context.Context,
+	id int,
+	payload *params.UpdateDefaultDurations,
+) ([]params.FindDefaultDuration, error) {
+	durations := utils.DefaultDurationsToModels(payload.Durations)
+	if err := svc.repo.UpdateDurations(ctx, id, durations); err != nil {
+		return nil, fmt.Errorf("error updating category durations: %w", err)
+	}
+	return svc.FindCategoryDurations(ctx, id)
+}
+
 func New(repo repository.CategoryRepositorer) CategoryServicer {
 	return &categoryService{
 		repo: repo,
 	}
 }
+
+// FindTips implements CategoryServicer
+func (svc *categoryService) FindTips(ctx context.Context, id int) ([]params.FindTip, error) {
+	result, err := svc.repo.FindTips(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding tips: %w", err)
+	}
+	return utils.TipModelsToFinds(result), nil
+}
+
+// CreateTip implements CategoryServicer
+func (svc *categoryService) CreateTip(ctx context.Context, id, tipID int) (params.FindTip, error) {
+	result, err := svc.repo.CreateTip(ctx, id, tipID)
+	if err != nil {
+		return params.FindTip{}, fmt.Errorf("error adding tip: %w", err)
+	}
+	return utils.TipModelToFind(&result), nil
+}
+
+// DeleteTip implements CategoryServicer
+func (svc *categoryService) DeleteTip(ctx context.Context, id, tipID int) error {
+	if err := svc.repo.DeleteTip(ctx, id, tipID); err != nil {
+		return fmt.Errorf("error removing tip: %w", err)
+	}
+	return nil
+}
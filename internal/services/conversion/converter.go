@@ -0,0 +1,134 @@
+package conversion
+
+import (
+	"strings"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// Converter converts the quantity of the product from one measure to
+// another. It reports false if the measures are not convertible.
+type Converter interface {
+	Convert(productID int, quantity float32, from, to models.Measure) (float32, bool)
+	// Readable converts the quantity to the measure of the same dimension
+	// which reads best and rounds it, so 1000 г becomes 1 кг.
+	Readable(quantity float32, measure models.Measure) (float32, models.Measure)
+	// Base returns the measure of the base unit of the dimension of the
+	// measure: grams, millilitres or pieces. It reports false if there is
+	// none among the measures.
+	Base(measure models.Measure) (models.Measure, bool)
+}
+
+// units are the common measures known by their names. They are used for
+// the measures without the dimension.
+var units = map[string]models.Measure{
+	"г":  {Dimension: models.DimensionMass, Factor: 1},
+	"гр": {Dimension: models.DimensionMass, Factor: 1},
+	"кг": {Dimension: models.DimensionMass, Factor: 1000},
+	"мл": {Dimension: models.DimensionVolume, Factor: 1},
+	"л":  {Dimension: models.DimensionVolume, Factor: 1000},
+	"шт": {Dimension: models.DimensionCount, Factor: 1},
+}
+
+type converter struct {
+	list        []models.Measure
+	measures    map[int]models.Measure
+	conversions map[int]models.ProductConversion
+}
+
+// NewConverter returns the converter of the measures. Measures of the same
+// dimension are converted by their factors. Mass, volume and count of a
+// product are converted by the density and the piece weight of the product.
+func NewConverter(measures []models.Measure, conversions []models.ProductConversion) Converter {
+	c := &converter{
+		list:        measures,
+		measures:    make(map[int]models.Measure, len(measures)),
+		conversions: make(map[int]models.ProductConversion, len(conversions)),
+	}
+	for _, measure := range measures {
+		c.measures[measure.ID] = measure
+	}
+	for _, conversion := range conversions {
+		c.conversions[conversion.ProductID] = conversion
+	}
+	return c
+}
+
+// Convert implements Converter
+func (c *converter) Convert(productID int, quantity float32, from, to models.Measure) (float32, bool) {
+	if from.ID != 0 && from.ID == to.ID {
+		return quantity, true
+	}
+	from, ok := c.unit(from)
+	if !ok {
+		return 0, false
+	}
+	to, ok = c.unit(to)
+	if !ok {
+		return 0, false
+	}
+	base, ok := c.cross(productID, quantity*from.Factor, from.Dimension, to.Dimension)
+	if !ok {
+		return 0, false
+	}
+	return base / to.Factor, true
+}
+
+// Base implements Converter
+func (c *converter) Base(measure models.Measure) (models.Measure, bool) {
+	from, ok := c.unit(measure)
+	if !ok {
+		return models.Measure{}, false
+	}
+	for _, candidate := range c.list {
+		unit, ok := c.unit(candidate)
+		if ok && unit.Dimension == from.Dimension && unit.Factor == 1 {
+			candidate.Dimension, candidate.Factor = unit.Dimension, unit.Factor
+			return candidate, true
+		}
+	}
+	return models.Measure{}, false
+}
+
+// unit returns the measure with the dimension and the factor. The measure
+// is looked up by the ID first and by the name then.
+func (c *converter) unit(measure models.Measure) (models.Measure, bool) {
+	if known, ok := c.measures[measure.ID]; ok {
+		measure = known
+	}
+	if measure.Dimension != "" && measure.Factor > 0 {
+		return measure, true
+	}
+	unit, ok := units[normalize(measure.Name)]
+	return unit, ok
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// cross converts the quantity in the base unit of one dimension to the
+// base unit of another one.
+func (c *converter) cross(productID int, quantity float32, from, to string) (float32, bool) {
+	if from == to {
+		return quantity, true
+	}
+	conversion := c.conversions[productID]
+	ratio := func(dimension string) float32 {
+		switch dimension {
+		case models.DimensionMass:
+			return 1
+		case models.DimensionVolume:
+			return conversion.Density
+		case models.DimensionCount:
+			return conversion.PieceWeight
+		}
+		return 0
+	}
+	// grams per the base unit of the dimensions
+	a, b := ratio(from), ratio(to)
+	if a <= 0 || b <= 0 {
+		return 0, false
+	}
+	return quantity * a / b, true
+}
@@ -0,0 +1,144 @@
+package conversion
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	gram   = models.Measure{ID: 1, Name: "г"}
+	kilo   = models.Measure{ID: 2, Name: "кг"}
+	piece  = models.Measure{ID: 3, Name: "шт"}
+	litre  = models.Measure{ID: 4, Name: "л"}
+	dozen  = models.Measure{ID: 5, Name: "дюжина"}
+	bunch  = models.Measure{ID: 6, Name: "пучок"}
+	pound  = models.Measure{ID: 7, Name: "фунт"}
+	glass  = models.Measure{ID: 8, Name: "стакан"}
+	milkID = 1
+	eggsID = 2
+)
+
+func Test_Converter(t *testing.T) {
+	converter := NewConverter(
+		[]models.Measure{
+			{ID: dozen.ID, Name: dozen.Name, Dimension: models.DimensionCount, Factor: 12},
+			{ID: pound.ID, Name: pound.Name, Dimension: models.DimensionMass, Factor: 453.6},
+			{ID: glass.ID, Name: glass.Name, Dimension: models.DimensionVolume, Factor: 250},
+		},
+		[]models.ProductConversion{
+			{ProductID: milkID, Density: 1.03},
+			{ProductID: eggsID, PieceWeight: 55},
+		},
+	)
+	testCases := []struct {
+		name      string
+		productID int
+		from, to  models.Measure
+		quantity  float32
+		expected  float32
+		ok        bool
+	}{
+		{name: "same measure", from: bunch, to: bunch, quantity: 2, expected: 2, ok: true},
+		{name: "kilograms to grams", from: kilo, to: gram, quantity: 0.5, expected: 500, ok: true},
+		{name: "grams to kilograms", from: gram, to: kilo, quantity: 250, expected: 0.25, ok: true},
+		{name: "measure with dimension", from: pound, to: gram, quantity: 2, expected: 907.2, ok: true},
+		{name: "dozens to pieces", from: dozen, to: piece, quantity: 1.5, expected: 18, ok: true},
+		{name: "unknown measure", from: bunch, to: gram, quantity: 1},
+		{name: "pieces to grams without weight", from: piece, to: gram, quantity: 1},
+		{
+			name:      "pieces to kilograms",
+			productID: eggsID,
+			from:      dozen,
+			to:        kilo,
+			quantity:  1,
+			expected:  0.66,
+			ok:        true,
+		},
+		{
+			name:      "litres to grams",
+			productID: milkID,
+			from:      litre,
+			to:        gram,
+			quantity:  1,
+			expected:  1030,
+			ok:        true,
+		},
+		{
+			name:      "grams to glasses",
+			productID: milkID,
+			from:      gram,
+			to:        glass,
+			quantity:  515,
+			expected:  2,
+			ok:        true,
+		},
+		{name: "litres to pieces without weight", productID: milkID, from: litre, to: piece, quantity: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, ok := converter.Convert(tc.productID, tc.quantity, tc.from, tc.to)
+			assert.Equal(t, tc.ok, ok)
+			assert.InDelta(t, tc.expected, result, 1e-3)
+		})
+	}
+}
+
+func Test_Readable(t *testing.T) {
+	teaspoon := models.Measure{ID: 9, Name: "ч. л.", Dimension: models.DimensionVolume, Factor: 5}
+	millilitre := models.Measure{ID: 10, Name: "мл"}
+	converter := NewConverter(
+		[]models.Measure{gram, kilo, piece, litre, millilitre, pound, teaspoon},
+		nil,
+	)
+	testCases := []struct {
+		name     string
+		measure  models.Measure
+		quantity float32
+		expected float32
+		unit     string
+	}{
+		{name: "grams to kilograms", measure: gram, quantity: 1000, expected: 1, unit: "кг"},
+		{name: "rounded to kilograms", measure: gram, quantity: 999.6, expected: 1, unit: "кг"},
+		{name: "grams kept", measure: gram, quantity: 994, expected: 994, unit: "г"},
+		{name: "kilograms to grams", measure: kilo, quantity: 0.25, expected: 250, unit: "г"},
+		{name: "millilitres to litres", measure: millilitre, quantity: 1500, expected: 1.5, unit: "л"},
+		{name: "pounds kept", measure: pound, quantity: 3, expected: 3, unit: "фунт"},
+		{name: "teaspoons kept", measure: teaspoon, quantity: 300, expected: 300, unit: "ч. л."},
+		{name: "pieces rounded to halves", measure: piece, quantity: 1.3, expected: 1.5, unit: "шт"},
+		{name: "pieces never zero", measure: piece, quantity: 0.1, expected: 0.5, unit: "шт"},
+		{name: "small quantity kept", measure: gram, quantity: 0.3333, expected: 0.333, unit: "г"},
+		{name: "unknown measure", measure: bunch, quantity: 1.3333, expected: 1.33, unit: "пучок"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, measure := converter.Readable(tc.quantity, tc.measure)
+			assert.InDelta(t, tc.expected, result, 1e-4)
+			assert.Equal(t, tc.unit, measure.Name)
+		})
+	}
+}
+
+func Test_Base(t *testing.T) {
+	converter := NewConverter([]models.Measure{kilo, gram, litre, pound, bunch}, nil)
+	testCases := []struct {
+		name    string
+		measure models.Measure
+		base    models.Measure
+		ok      bool
+	}{
+		{name: "kilograms", measure: kilo, base: gram, ok: true},
+		{name: "grams", measure: gram, base: gram, ok: true},
+		{name: "pounds", measure: pound, ok: false},
+		{name: "no millilitres", measure: litre, ok: false},
+		{name: "unknown measure", measure: bunch, ok: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			base, ok := converter.Base(tc.measure)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.base.ID, base.ID)
+		})
+	}
+}
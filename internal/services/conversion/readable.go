@@ -0,0 +1,68 @@
+package conversion
+
+import (
+	"math"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// significantDigits of the mass and the volume are kept when they are
+// rounded.
+const significantDigits = 3
+
+// Readable implements Converter
+//
+// Only the measures whose factors differ from the factor of the measure by
+// a power of ten are considered, so grams become kilograms but not pounds.
+// The largest measure keeping the quantity at least 1 is chosen.
+func (c *converter) Readable(quantity float32, measure models.Measure) (float32, models.Measure) {
+	from, ok := c.unit(measure)
+	if !ok {
+		return Round(quantity, measure), measure
+	}
+	var (
+		base   = Round(quantity*from.Factor, models.Measure{Dimension: from.Dimension})
+		best   = measure
+		factor = from.Factor
+	)
+	for _, candidate := range c.list {
+		unit, ok := c.unit(candidate)
+		if !ok || unit.Dimension != from.Dimension || !powerOfTen(unit.Factor/from.Factor) {
+			continue
+		}
+		if base/unit.Factor < 1-1e-6 {
+			continue
+		}
+		if base/factor < 1-1e-6 || unit.Factor > factor {
+			best, factor = candidate, unit.Factor
+		}
+	}
+	best.Dimension, best.Factor = from.Dimension, factor
+	return Round(base/factor, best), best
+}
+
+// Round rounds the quantity sensibly for the measure. Counts are rounded to
+// halves but never to zero, mass and volume to three significant digits and
+// the quantities of unknown dimensions to two decimals.
+func Round(quantity float32, measure models.Measure) float32 {
+	if unit, ok := units[normalize(measure.Name)]; ok && measure.Dimension == "" {
+		measure.Dimension = unit.Dimension
+	}
+	q := float64(quantity)
+	if q <= 0 {
+		return 0
+	}
+	switch measure.Dimension {
+	case models.DimensionCount:
+		return float32(math.Max(math.Round(q*2)/2, 0.5))
+	case models.DimensionMass, models.DimensionVolume:
+		scale := math.Pow(10, significantDigits-1-math.Floor(math.Log10(q)))
+		return float32(math.Round(q*scale) / scale)
+	}
+	return float32(math.Round(q*100) / 100)
+}
+
+func powerOfTen(ratio float32) bool {
+	exp := math.Log10(float64(ratio))
+	return math.Abs(exp-math.Round(exp)) < 1e-4
+}
@@ -0,0 +1,101 @@
+package conversion
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+)
+
+// ConversionServicer normalizes quantities of the products given in
+// different measures.
+type ConversionServicer interface {
+	// Converter returns the converter of all the measures and of the
+	// conversions of the products.
+	Converter(ctx context.Context, productIDs []int) (Converter, error)
+	Convert(ctx context.Context, payload *params.ConvertQuantity) (params.ConvertedQuantity, error)
+}
+
+type conversionService struct {
+	measures measure.MeasureRepositorer
+	products product.ProductRepositorer
+}
+
+// Converter implements ConversionServicer
+func (s *conversionService) Converter(ctx context.Context, productIDs []int) (Converter, error) {
+	measures, err := s.measures.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error finding measures: %w", err)
+	}
+	var conversions []models.ProductConversion
+	if len(productIDs) > 0 {
+		conversions, err = s.products.FindConversions(ctx, productIDs)
+		if err != nil {
+			return nil, fmt.Errorf("error finding product conversions: %w", err)
+		}
+	}
+	return NewConverter(measures, conversions), nil
+}
+
+// Convert implements ConversionServicer
+func (s *conversionService) Convert(
+	ctx context.Context,
+	payload *params.ConvertQuantity,
+) (params.ConvertedQuantity, error) {
+	from, err := s.findMeasure(ctx, payload.FromID)
+	if err != nil {
+		return params.ConvertedQuantity{}, err
+	}
+	to, err := s.findMeasure(ctx, payload.ToID)
+	if err != nil {
+		return params.ConvertedQuantity{}, err
+	}
+	var productIDs []int
+	if payload.ProductID != 0 {
+		productIDs = []int{payload.ProductID}
+	}
+	converter, err := s.Converter(ctx, productIDs)
+	if err != nil {
+		return params.ConvertedQuantity{}, err
+	}
+	result, ok := converter.Convert(payload.ProductID, payload.Quantity, from, to)
+	if !ok {
+		return params.ConvertedQuantity{}, fmt.Errorf(
+			"%s to %s: %w",
+			from.Name,
+			to.Name,
+			errors.ErrMeasuresNotConvertible,
+		)
+	}
+	return params.ConvertedQuantity{
+		From:     utils.MeasureModelToFind(&from),
+		To:       utils.MeasureModelToFind(&to),
+		Quantity: payload.Quantity,
+		Result:   result,
+	}, nil
+}
+
+func (s *conversionService) findMeasure(ctx context.Context, id int) (models.Measure, error) {
+	measure, err := s.measures.FindByID(ctx, id)
+	if errs.Is(err, pgx.ErrNoRows) {
+		return models.Measure{}, fmt.Errorf("measure %d: %w", id, errors.ErrMeasureNotFound)
+	}
+	if err != nil {
+		return models.Measure{}, fmt.Errorf("error finding measure: %w", err)
+	}
+	return measure, nil
+}
+
+func New(measures measure.MeasureRepositorer, products product.ProductRepositorer) ConversionServicer {
+	return &conversionService{
+		measures: measures,
+		products: products,
+	}
+}
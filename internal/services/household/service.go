@@ -0,0 +1,340 @@
+package household
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/household"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/storage"
+)
+
+const (
+	// codeBytes is the amount of random bytes in an invitation code.
+	codeBytes = 16
+	// invitationTTL is how long an invitation code can be used.
+	invitationTTL = 7 * 24 * time.Hour
+)
+
+// HouseholdServicer manages the households of the users. Every member sees
+// the household, its members and storages. Only owners change the household,
+// its members and storages and invite new members. Households the user is
+// not a member of are reported as errors.ErrHouseholdNotFound, members
+// lacking the role get errors.ErrNotOwner. Admins can do anything.
+type HouseholdServicer interface {
+	FindHouseholds(ctx context.Context, user *params.TokenPayload) ([]params.FindHousehold, error)
+	FindHousehold(ctx context.Context, user *params.TokenPayload, id int) (params.FindHousehold, error)
+	CreateHousehold(
+		ctx context.Context,
+		user *params.TokenPayload,
+		payload *params.CreateHousehold,
+	) (params.FindHousehold, error)
+	UpdateHousehold(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+		payload *params.UpdateHousehold,
+	) (params.FindHousehold, error)
+	DeleteHousehold(ctx context.Context, user *params.TokenPayload, id int) error
+	FindMembers(ctx context.Context, user *params.TokenPayload, id int) ([]params.FindHouseholdMember, error)
+	// UpdateMember returns errors.ErrLastOwner if the last owner is demoted.
+	UpdateMember(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id, memberID int,
+		payload *params.UpdateHouseholdMember,
+	) error
+	// RemoveMember lets the members leave the household on their own. It
+	// returns errors.ErrLastOwner if the last owner is removed.
+	RemoveMember(ctx context.Context, user *params.TokenPayload, id, memberID int) error
+	FindStorages(ctx context.Context, user *params.TokenPayload, id int) ([]params.FindStorage, error)
+	// AddStorage requires the owner to be able to change the storage as well.
+	AddStorage(ctx context.Context, user *params.TokenPayload, id, storageID int) error
+	RemoveStorage(ctx context.Context, user *params.TokenPayload, id, storageID int) error
+	CreateInvitation(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+		payload *params.CreateHouseholdInvitation,
+	) (params.FindHouseholdInvitation, error)
+	// Join returns errors.ErrInvalidInvitation if the code is unknown, used
+	// or expired.
+	Join(ctx context.Context, user *params.TokenPayload, payload *params.JoinHousehold) (params.FindHousehold, error)
+}
+
+type householdService struct {
+	repo     household.HouseholdRepositorer
+	storages storage.StorageRepositorer
+	now      func() time.Time
+}
+
+// authorize returns the household if the user is a member with one of the
+// roles, any role will do if none are given.
+func (s *householdService) authorize(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+	roles ...string,
+) (models.Household, error) {
+	result, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return models.Household{}, fmt.Errorf("error finding household: %w", err)
+	}
+	result.Role, err = s.repo.FindRole(ctx, id, user.UserID)
+	if err != nil {
+		return models.Household{}, fmt.Errorf("error finding household role: %w", err)
+	}
+	if user.IsAdmin() {
+		return result, nil
+	}
+	if result.Role == "" {
+		return models.Household{}, fmt.Errorf("household %d: %w", id, errors.ErrHouseholdNotFound)
+	}
+	if len(roles) == 0 {
+		return result, nil
+	}
+	for _, role := range roles {
+		if result.Role == role {
+			return result, nil
+		}
+	}
+	return models.Household{}, fmt.Errorf("household %d: %w", id, errors.ErrNotOwner)
+}
+
+// FindHouseholds implements HouseholdServicer
+func (s *householdService) FindHouseholds(
+	ctx context.Context,
+	user *params.TokenPayload,
+) ([]params.FindHousehold, error) {
+	result, err := s.repo.FindMany(ctx, user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding households: %w", err)
+	}
+	return utils.HouseholdModelsToFinds(result), nil
+}
+
+// FindHousehold implements HouseholdServicer
+func (s *householdService) FindHousehold(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) (params.FindHousehold, error) {
+	result, err := s.authorize(ctx, user, id)
+	if err != nil {
+		return params.FindHousehold{}, err
+	}
+	return utils.HouseholdModelToFind(&result), nil
+}
+
+// CreateHousehold implements HouseholdServicer
+func (s *householdService) CreateHousehold(
+	ctx context.Context,
+	user *params.TokenPayload,
+	payload *params.CreateHousehold,
+) (params.FindHousehold, error) {
+	model := models.Household{Name: payload.Name}
+	if err := s.repo.Create(ctx, &model, user.UserID); err != nil {
+		return params.FindHousehold{}, fmt.Errorf("error creating household: %w", err)
+	}
+	return utils.HouseholdModelToFind(&model), nil
+}
+
+// UpdateHousehold implements HouseholdServicer
+func (s *householdService) UpdateHousehold(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+	payload *params.UpdateHousehold,
+) (params.FindHousehold, error) {
+	model, err := s.authorize(ctx, user, id, models.RoleOwner)
+	if err != nil {
+		return params.FindHousehold{}, err
+	}
+	model.Name = payload.Name
+	if err := s.repo.Update(ctx, &model); err != nil {
+		return params.FindHousehold{}, fmt.Errorf("error updating household: %w", err)
+	}
+	return utils.HouseholdModelToFind(&model), nil
+}
+
+// DeleteHousehold implements HouseholdServicer
+func (s *householdService) DeleteHousehold(ctx context.Context, user *params.TokenPayload, id int) error {
+	if _, err := s.authorize(ctx, user, id, models.RoleOwner); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("error deleting household: %w", err)
+	}
+	return nil
+}
+
+// FindMembers implements HouseholdServicer
+func (s *householdService) FindMembers(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) ([]params.FindHouseholdMember, error) {
+	if _, err := s.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
+	result, err := s.repo.FindMembers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding household members: %w", err)
+	}
+	return utils.HouseholdMemberModelsToFinds(result), nil
+}
+
+// UpdateMember implements HouseholdServicer
+func (s *householdService) UpdateMember(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id, memberID int,
+	payload *params.UpdateHouseholdMember,
+) error {
+	if _, err := s.authorize(ctx, user, id, models.RoleOwner); err != nil {
+		return err
+	}
+	if err := s.repo.UpdateMember(ctx, id, memberID, payload.Role); err != nil {
+		return fmt.Errorf("error updating household member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember implements HouseholdServicer
+func (s *householdService) RemoveMember(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id, memberID int,
+) error {
+	roles := []string{models.RoleOwner}
+	if memberID == user.UserID {
+		roles = nil
+	}
+	if _, err := s.authorize(ctx, user, id, roles...); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteMember(ctx, id, memberID); err != nil {
+		return fmt.Errorf("error removing household member: %w", err)
+	}
+	return nil
+}
+
+// FindStorages implements HouseholdServicer
+func (s *householdService) FindStorages(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) ([]params.FindStorage, error) {
+	if _, err := s.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
+	result, err := s.repo.FindStorages(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding household storages: %w", err)
+	}
+	return utils.StorageModelsToFinds(result), nil
+}
+
+// AddStorage implements HouseholdServicer
+func (s *householdService) AddStorage(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id, storageID int,
+) error {
+	if _, err := s.authorize(ctx, user, id, models.RoleOwner); err != nil {
+		return err
+	}
+	if !user.IsAdmin() {
+		ok, err := s.storages.IsWritable(ctx, storageID, user.UserID)
+		if err != nil {
+			return fmt.Errorf("error checking storage access: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("storage %d: %w", storageID, errors.ErrNotOwner)
+		}
+	}
+	if err := s.repo.AddStorage(ctx, id, storageID); err != nil {
+		return fmt.Errorf("error adding household storage: %w", err)
+	}
+	return nil
+}
+
+// RemoveStorage implements HouseholdServicer
+func (s *householdService) RemoveStorage(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id, storageID int,
+) error {
+	if _, err := s.authorize(ctx, user, id, models.RoleOwner); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveStorage(ctx, id, storageID); err != nil {
+		return fmt.Errorf("error removing household storage: %w", err)
+	}
+	return nil
+}
+
+// CreateInvitation implements HouseholdServicer
+func (s *householdService) CreateInvitation(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+	payload *params.CreateHouseholdInvitation,
+) (params.FindHouseholdInvitation, error) {
+	if _, err := s.authorize(ctx, user, id, models.RoleOwner); err != nil {
+		return params.FindHouseholdInvitation{}, err
+	}
+	buf := make([]byte, codeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return params.FindHouseholdInvitation{}, fmt.Errorf("error generating invitation code: %w", err)
+	}
+	code := base64.RawURLEncoding.EncodeToString(buf)
+	invitation := models.HouseholdInvitation{
+		HouseholdID: id,
+		CodeHash:    hashCode(code),
+		Role:        payload.Role,
+		ExpiresAt:   s.now().Add(invitationTTL).UTC(),
+	}
+	if err := s.repo.CreateInvitation(ctx, &invitation); err != nil {
+		return params.FindHouseholdInvitation{}, fmt.Errorf("error creating invitation: %w", err)
+	}
+	return params.FindHouseholdInvitation{
+		Code:      code,
+		Role:      invitation.Role,
+		ExpiresAt: invitation.ExpiresAt,
+	}, nil
+}
+
+// Join implements HouseholdServicer
+func (s *householdService) Join(
+	ctx context.Context,
+	user *params.TokenPayload,
+	payload *params.JoinHousehold,
+) (params.FindHousehold, error) {
+	result, err := s.repo.Join(ctx, hashCode(payload.Code), user.UserID, s.now())
+	if err != nil {
+		return params.FindHousehold{}, fmt.Errorf("error joining household: %w", err)
+	}
+	return utils.HouseholdModelToFind(&result), nil
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func New(repo household.HouseholdRepositorer, storages storage.StorageRepositorer) HouseholdServicer {
+	return &householdService{
+		repo:     repo,
+		storages: storages,
+		now:      time.Now,
+	}
+}
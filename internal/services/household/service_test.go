@@ -0,0 +1,243 @@
+package household
+
+import (
+	"context"
+	errs "errors"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/household"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the roles of the members of the household 1 and the
+// invitations by the hash of the code in memory.
+type fakeRepository struct {
+	household.HouseholdRepositorer
+	members     map[int]string
+	invitations map[string]models.HouseholdInvitation
+	storages    []int
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		members: map[int]string{
+			1: models.RoleOwner,
+			2: models.RoleEditor,
+			3: models.RoleViewer,
+		},
+		invitations: map[string]models.HouseholdInvitation{},
+	}
+}
+
+func (r *fakeRepository) FindByID(_ context.Context, id int) (models.Household, error) {
+	if id != 1 {
+		return models.Household{}, errors.ErrHouseholdNotFound
+	}
+	return models.Household{ID: 1, Name: "Дом"}, nil
+}
+
+func (r *fakeRepository) FindRole(_ context.Context, _, userID int) (string, error) {
+	return r.members[userID], nil
+}
+
+// lastOwner reports whether the user is the only owner of the household.
+func (r *fakeRepository) lastOwner(userID int) bool {
+	if r.members[userID] != models.RoleOwner {
+		return false
+	}
+	for id, role := range r.members {
+		if id != userID && role == models.RoleOwner {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *fakeRepository) UpdateMember(_ context.Context, _, userID int, role string) error {
+	if role != models.RoleOwner && r.lastOwner(userID) {
+		return errors.ErrLastOwner
+	}
+	r.members[userID] = role
+	return nil
+}
+
+func (r *fakeRepository) DeleteMember(_ context.Context, _, userID int) error {
+	if r.lastOwner(userID) {
+		return errors.ErrLastOwner
+	}
+	delete(r.members, userID)
+	return nil
+}
+
+func (r *fakeRepository) AddStorage(_ context.Context, _, storageID int) error {
+	r.storages = append(r.storages, storageID)
+	return nil
+}
+
+func (r *fakeRepository) CreateInvitation(_ context.Context, invitation *models.HouseholdInvitation) error {
+	r.invitations[invitation.CodeHash] = *invitation
+	return nil
+}
+
+func (r *fakeRepository) Join(
+	_ context.Context,
+	codeHash string,
+	userID int,
+	now time.Time,
+) (models.Household, error) {
+	invitation, ok := r.invitations[codeHash]
+	if !ok || !invitation.ExpiresAt.After(now) {
+		return models.Household{}, errors.ErrInvalidInvitation
+	}
+	delete(r.invitations, codeHash)
+	if _, ok := r.members[userID]; !ok {
+		r.members[userID] = invitation.Role
+	}
+	return models.Household{ID: 1, Name: "Дом", Role: r.members[userID]}, nil
+}
+
+// fakeStorages lets the users change the storages with the same ID.
+type fakeStorages struct {
+	storage.StorageRepositorer
+}
+
+func (fakeStorages) IsWritable(_ context.Context, id, userID int) (bool, error) {
+	return id == userID, nil
+}
+
+var (
+	owner    = &params.TokenPayload{UserID: 1, Roles: []string{"user"}}
+	editor   = &params.TokenPayload{UserID: 2, Roles: []string{"user"}}
+	viewer   = &params.TokenPayload{UserID: 3, Roles: []string{"user"}}
+	stranger = &params.TokenPayload{UserID: 4, Roles: []string{"user"}}
+	admin    = &params.TokenPayload{UserID: 5, Roles: []string{"user", "admin"}}
+)
+
+func newService(repo *fakeRepository, now time.Time) *householdService {
+	return &householdService{
+		repo:     repo,
+		storages: fakeStorages{},
+		now:      func() time.Time { return now },
+	}
+}
+
+func Test_HouseholdRoles(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     *params.TokenPayload
+		readErr  error
+		writeErr error
+	}{
+		{name: "owner", user: owner},
+		{name: "admin", user: admin},
+		{name: "editor", user: editor, writeErr: errors.ErrNotOwner},
+		{name: "viewer", user: viewer, writeErr: errors.ErrNotOwner},
+		{name: "stranger", user: stranger, readErr: errors.ErrHouseholdNotFound, writeErr: errors.ErrHouseholdNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newService(newFakeRepository(), time.Now())
+			ctx := context.Background()
+
+			_, err := svc.FindHousehold(ctx, tc.user, 1)
+			assert.True(t, errs.Is(err, tc.readErr), err)
+			_, err = svc.CreateInvitation(ctx, tc.user, 1, &params.CreateHouseholdInvitation{Role: models.RoleViewer})
+			assert.True(t, errs.Is(err, tc.writeErr), err)
+			err = svc.UpdateMember(ctx, tc.user, 1, 3, &params.UpdateHouseholdMember{Role: models.RoleEditor})
+			assert.True(t, errs.Is(err, tc.writeErr), err)
+		})
+	}
+}
+
+func Test_LastOwner(t *testing.T) {
+	testCases := []struct {
+		name     string
+		owners   []int
+		action   func(svc *householdService) error
+		expected error
+	}{
+		{
+			name: "demote last owner",
+			action: func(svc *householdService) error {
+				return svc.UpdateMember(context.Background(), owner, 1, 1, &params.UpdateHouseholdMember{Role: models.RoleViewer})
+			},
+			expected: errors.ErrLastOwner,
+		},
+		{
+			name:     "last owner leaves",
+			action:   func(svc *householdService) error { return svc.RemoveMember(context.Background(), owner, 1, 1) },
+			expected: errors.ErrLastOwner,
+		},
+		{
+			name:   "owner leaves another owner",
+			owners: []int{2},
+			action: func(svc *householdService) error { return svc.RemoveMember(context.Background(), owner, 1, 1) },
+		},
+		{
+			name:   "viewer leaves",
+			action: func(svc *householdService) error { return svc.RemoveMember(context.Background(), viewer, 1, 3) },
+		},
+		{
+			name:     "viewer removes editor",
+			action:   func(svc *householdService) error { return svc.RemoveMember(context.Background(), viewer, 1, 2) },
+			expected: errors.ErrNotOwner,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			for _, id := range tc.owners {
+				repo.members[id] = models.RoleOwner
+			}
+			err := tc.action(newService(repo, time.Now()))
+			if tc.expected == nil {
+				assert.Nil(t, err)
+			} else {
+				assert.True(t, errs.Is(err, tc.expected), err)
+			}
+		})
+	}
+}
+
+func Test_AddForeignStorage(t *testing.T) {
+	repo := newFakeRepository()
+	svc := newService(repo, time.Now())
+
+	err := svc.AddStorage(context.Background(), owner, 1, 2)
+	assert.True(t, errs.Is(err, errors.ErrNotOwner))
+	err = svc.AddStorage(context.Background(), owner, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, repo.storages)
+}
+
+func Test_Join(t *testing.T) {
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakeRepository()
+	svc := newService(repo, now)
+	ctx := context.Background()
+
+	invitation, err := svc.CreateInvitation(ctx, owner, 1, &params.CreateHouseholdInvitation{Role: models.RoleEditor})
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(invitationTTL), invitation.ExpiresAt)
+	assert.NotContains(t, repo.invitations, invitation.Code)
+
+	_, err = svc.Join(ctx, stranger, &params.JoinHousehold{Code: "unknown"})
+	assert.True(t, errs.Is(err, errors.ErrInvalidInvitation))
+
+	svc.now = func() time.Time { return invitation.ExpiresAt }
+	_, err = svc.Join(ctx, stranger, &params.JoinHousehold{Code: invitation.Code})
+	assert.True(t, errs.Is(err, errors.ErrInvalidInvitation))
+
+	svc.now = func() time.Time { return now }
+	result, err := svc.Join(ctx, stranger, &params.JoinHousehold{Code: invitation.Code})
+	assert.Nil(t, err)
+	assert.Equal(t, models.RoleEditor, result.Role)
+
+	_, err = svc.Join(ctx, admin, &params.JoinHousehold{Code: invitation.Code})
+	assert.True(t, errs.Is(err, errors.ErrInvalidInvitation))
+}
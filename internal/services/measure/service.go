@@ -93,6 +93,12 @@ func (svc *measureService) UpdateMeasure(
 	if payload.Name != "" {
 		model.Name = payload.Name
 	}
+	if payload.Dimension != nil {
+		model.Dimension = *payload.Dimension
+	}
+	if payload.Factor != nil {
+		model.Factor = *payload.Factor
+	}
 	if err := svc.repo.Update(ctx, model); err != nil {
 		return err
 	}
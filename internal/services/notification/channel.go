@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// Names of the user settings that control notifications.
+const (
+	SettingSubscription = "получать рассылку"
+	SettingEmail        = "электронная почта"
+	SettingWebhook      = "адрес вебхука"
+)
+
+// Channel delivers notifications to a recipient.
+type Channel interface {
+	// Name identifies the channel in delivery records.
+	Name() string
+	// Accepts reports whether the recipient can be reached through the channel.
+	Accepts(recipient Recipient) bool
+	Send(ctx context.Context, recipient Recipient, notification Notification) error
+}
+
+// Recipient is a user together with the contacts taken from their settings.
+type Recipient struct {
+	UserID     int
+	Name       string
+	Email      string
+	WebhookURL string
+	Subscribed bool
+}
+
+// NewRecipient builds a recipient from the user and their settings.
+func NewRecipient(user models.User, settings []models.Setting) Recipient {
+	recipient := Recipient{UserID: user.ID, Name: user.Name}
+	for _, setting := range settings {
+		value := strings.TrimSpace(setting.Value)
+		switch strings.ToLower(setting.Name) {
+		case SettingSubscription:
+			recipient.Subscribed = strings.EqualFold(value, "да")
+		case SettingEmail:
+			recipient.Email = value
+		case SettingWebhook:
+			recipient.WebhookURL = value
+		}
+	}
+	return recipient
+}
+
+// Notification is an alert about a single shelf life reaching a threshold.
+type Notification struct {
+	UserID      int       `json:"id_user"`
+	ShelfLifeID int       `json:"id_shelf_life"`
+	Product     string    `json:"product"`
+	EndDate     time.Time `json:"end_date"`
+	DaysLeft    int       `json:"days_left"`
+	Threshold   int       `json:"threshold"`
+	Title       string    `json:"title"`
+	Message     string    `json:"message"`
+}
+
+// NewNotification builds the alert for the shelf life matched to the threshold.
+func NewNotification(userID int, shelfLife models.ShelfLife, daysLeft, threshold int) Notification {
+	notification := Notification{
+		UserID:      userID,
+		ShelfLifeID: shelfLife.ID,
+		Product:     shelfLife.Product.Name,
+		EndDate:     *shelfLife.EndDate,
+		DaysLeft:    daysLeft,
+		Threshold:   threshold,
+	}
+	endDate := shelfLife.EndDate.Format("02.01.2006")
+	if daysLeft < 0 {
+		notification.Title = "Срок годности истек"
+		notification.Message = fmt.Sprintf("%s: срок годности истек %s", shelfLife.Product.Name, endDate)
+	} else {
+		notification.Title = "Срок годности истекает"
+		notification.Message = fmt.Sprintf(
+			"%s: осталось %d дн. (до %s)",
+			shelfLife.Product.Name,
+			daysLeft,
+			endDate,
+		)
+	}
+	return notification
+}
@@ -0,0 +1,38 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/notification"
+)
+
+type inboxChannel struct {
+	repo repository.NotificationRepositorer
+}
+
+// NewInboxChannel returns a channel that stores notifications in the in-app inbox.
+func NewInboxChannel(repo repository.NotificationRepositorer) Channel {
+	return &inboxChannel{repo: repo}
+}
+
+// Name implements Channel
+func (c *inboxChannel) Name() string {
+	return "inbox"
+}
+
+// Accepts implements Channel
+func (c *inboxChannel) Accepts(recipient Recipient) bool {
+	return true
+}
+
+// Send implements Channel
+func (c *inboxChannel) Send(ctx context.Context, recipient Recipient, notification Notification) error {
+	return c.repo.Create(ctx, &models.Notification{
+		User:      models.User{ID: recipient.UserID},
+		ShelfLife: models.ShelfLife{ID: notification.ShelfLifeID},
+		Threshold: notification.Threshold,
+		Title:     notification.Title,
+		Message:   notification.Message,
+	})
+}
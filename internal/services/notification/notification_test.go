@@ -0,0 +1,319 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	errs "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/notification"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MatchThreshold(t *testing.T) {
+	testCases := []struct {
+		name       string
+		daysLeft   int
+		thresholds []int
+		expected   int
+		ok         bool
+	}{
+		{name: "outside thresholds", daysLeft: 5, thresholds: []int{3, 1, 0}, ok: false},
+		{name: "widest threshold", daysLeft: 3, thresholds: []int{3, 1, 0}, expected: 3, ok: true},
+		{name: "between thresholds", daysLeft: 2, thresholds: []int{3, 1, 0}, expected: 3, ok: true},
+		{name: "smallest threshold", daysLeft: 1, thresholds: []int{0, 3, 1}, expected: 1, ok: true},
+		{name: "expired", daysLeft: -2, thresholds: []int{3, 1, 0}, expected: 0, ok: true},
+		{name: "no thresholds", daysLeft: 0, thresholds: nil, ok: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, ok := MatchThreshold(tc.daysLeft, tc.thresholds)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_NewRecipient(t *testing.T) {
+	recipient := NewRecipient(models.User{ID: 1, Name: "user"}, []models.Setting{
+		{Name: "Получать рассылку", Value: "Да"},
+		{Name: SettingEmail, Value: " user@example.com "},
+		{Name: SettingWebhook, Value: "http://example.com/hook"},
+	})
+	assert.Equal(t, Recipient{
+		UserID:     1,
+		Name:       "user",
+		Email:      "user@example.com",
+		WebhookURL: "http://example.com/hook",
+		Subscribed: true,
+	}, recipient)
+}
+
+func testNotification() Notification {
+	endDate := time.Date(2023, 5, 11, 0, 0, 0, 0, time.UTC)
+	return NewNotification(1, models.ShelfLife{
+		ID:      7,
+		Product: models.Product{Name: "Молоко"},
+		EndDate: &endDate,
+	}, 1, 1)
+}
+
+// fakeSMTPServer accepts a single SMTP session and sends the received
+// message data to the returned channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 end data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				messages <- data.String()
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func Test_SMTPChannel_Send(t *testing.T) {
+	addr, messages := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	channel := NewSMTPChannel(host, port, "", "", "muerta@example.com", 5*time.Second)
+	recipient := Recipient{UserID: 1, Email: "user@example.com", Subscribed: true}
+	notification := testNotification()
+
+	assert.True(t, channel.Accepts(recipient))
+	assert.False(t, channel.Accepts(Recipient{UserID: 1}))
+	err := channel.Send(context.Background(), recipient, notification)
+	assert.Nil(t, err)
+	select {
+	case msg := <-messages:
+		assert.Contains(t, msg, "To: user@example.com")
+		assert.Contains(t, msg, notification.Message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not delivered")
+	}
+}
+
+func Test_SMTPChannel_SendTimeout(t *testing.T) {
+	// The server accepts the connection but never greets.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	channel := NewSMTPChannel(host, port, "", "", "muerta@example.com", 100*time.Millisecond)
+	start := time.Now()
+	err = channel.Send(context.Background(), Recipient{UserID: 1, Email: "user@example.com"}, testNotification())
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// fakeUsers keeps a single subscribed user with their shelf lives.
+type fakeUsers struct {
+	user.UserStorage
+	shelfLives []models.ShelfLife
+}
+
+func (u *fakeUsers) FindMany(_ context.Context, filter models.UserFilter) ([]models.User, error) {
+	if filter.Offset > 0 {
+		return nil, nil
+	}
+	return []models.User{{ID: 1, Name: "user"}}, nil
+}
+
+func (u *fakeUsers) FindSettings(_ context.Context, _ int) ([]models.Setting, error) {
+	return []models.Setting{{Name: SettingSubscription, Value: "Да"}}, nil
+}
+
+func (u *fakeUsers) FindShelfLives(_ context.Context, _ int) ([]models.ShelfLife, error) {
+	return u.shelfLives, nil
+}
+
+// fakeDeliveries records the deliveries like the unique key of the real
+// repository does.
+type fakeDeliveries struct {
+	repository.NotificationRepositorer
+	delivered map[models.NotificationDelivery]bool
+}
+
+func (r *fakeDeliveries) MarkDelivered(_ context.Context, delivery models.NotificationDelivery) (bool, error) {
+	if r.delivered[delivery] {
+		return false, nil
+	}
+	r.delivered[delivery] = true
+	return true, nil
+}
+
+func (r *fakeDeliveries) UnmarkDelivered(_ context.Context, delivery models.NotificationDelivery) error {
+	delete(r.delivered, delivery)
+	return nil
+}
+
+// fakeChannel records the sent notifications and fails while err is set.
+type fakeChannel struct {
+	sent []Notification
+	err  error
+}
+
+func (c *fakeChannel) Name() string { return "fake" }
+
+func (c *fakeChannel) Accepts(Recipient) bool { return true }
+
+func (c *fakeChannel) Send(_ context.Context, _ Recipient, notification Notification) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.sent = append(c.sent, notification)
+	return nil
+}
+
+func Test_NotifyDeduplicates(t *testing.T) {
+	today := time.Now().UTC()
+	inThreeDays, inTenDays := today.AddDate(0, 0, 3), today.AddDate(0, 0, 10)
+	users := &fakeUsers{shelfLives: []models.ShelfLife{
+		{ID: 1, EndDate: &inThreeDays},
+		{ID: 2, EndDate: &inTenDays},
+		{ID: 3},
+	}}
+	deliveries := &fakeDeliveries{delivered: map[models.NotificationDelivery]bool{}}
+	channel := &fakeChannel{err: fmt.Errorf("unavailable")}
+	log := zerolog.Nop()
+	n := NewNotifier(users, deliveries, []Channel{channel}, []int{3, 1, 0}, &log)
+
+	// The failed delivery is unmarked to be retried.
+	result, err := n.Notify(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Empty(t, deliveries.delivered)
+
+	channel.err = nil
+	result, err = n.Notify(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Sent)
+	assert.Len(t, channel.sent, 1)
+	assert.Equal(t, 1, channel.sent[0].ShelfLifeID)
+	assert.Equal(t, 3, channel.sent[0].Threshold)
+
+	// The item is alerted once per threshold.
+	result, err = n.Notify(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result.Sent)
+	assert.Len(t, channel.sent, 1)
+
+	inOneDay := today.AddDate(0, 0, 1)
+	users.shelfLives[0].EndDate = &inOneDay
+	result, err = n.Notify(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Sent)
+	assert.Len(t, channel.sent, 2)
+	assert.Equal(t, 1, channel.sent[1].Threshold)
+}
+
+func Test_WebhookChannel_Send(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "accepted", status: http.StatusOK},
+		{name: "rejected", status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			received := make(chan Notification, 1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var payload Notification
+				if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+					received <- payload
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+			// The test server listens on the loopback refused by the
+			// channel, so its own client is used.
+			channel := &webhookChannel{client: srv.Client()}
+			notification := testNotification()
+			err := channel.Send(
+				context.Background(),
+				Recipient{UserID: 1, WebhookURL: srv.URL},
+				notification,
+			)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, notification, <-received)
+		})
+	}
+}
+
+func Test_WebhookChannel_SendInternal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("internal webhook is called")
+	}))
+	defer srv.Close()
+	err := NewWebhookChannel(time.Second).Send(
+		context.Background(),
+		Recipient{UserID: 1, WebhookURL: srv.URL},
+		testNotification(),
+	)
+	assert.True(t, errs.Is(err, errors.ErrUnsafeURL), err)
+}
@@ -0,0 +1,193 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/notification"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+const usersPageSize = 100
+
+// MatchThreshold returns the smallest threshold in days that daysLeft has
+// reached. It reports false when the item is outside the widest threshold.
+func MatchThreshold(daysLeft int, thresholds []int) (int, bool) {
+	sorted := make([]int, len(thresholds))
+	copy(sorted, thresholds)
+	sort.Ints(sorted)
+	for _, threshold := range sorted {
+		if daysLeft <= threshold {
+			return threshold, true
+		}
+	}
+	return 0, false
+}
+
+type Notifier interface {
+	Notify(ctx context.Context) (params.NotificationRun, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type notifier struct {
+	users      user.UserStorage
+	repo       repository.NotificationRepositorer
+	channels   []Channel
+	thresholds []int
+	log        *zerolog.Logger
+}
+
+func NewNotifier(
+	users user.UserStorage,
+	repo repository.NotificationRepositorer,
+	channels []Channel,
+	thresholds []int,
+	log *zerolog.Logger,
+) Notifier {
+	return &notifier{
+		users:      users,
+		repo:       repo,
+		channels:   channels,
+		thresholds: thresholds,
+		log:        log,
+	}
+}
+
+// Notify implements Notifier
+func (n *notifier) Notify(ctx context.Context) (params.NotificationRun, error) {
+	var (
+		result params.NotificationRun
+		now    = time.Now().UTC()
+	)
+	for offset := 0; ; offset += usersPageSize {
+		users, err := n.users.FindMany(ctx, models.UserFilter{
+			PageFilter: models.PageFilter{Limit: usersPageSize, Offset: offset},
+		})
+		if err != nil {
+			return result, fmt.Errorf("error finding users: %w", err)
+		}
+		for _, user := range users {
+			if err := n.notifyUser(ctx, user, now, &result); err != nil {
+				return result, err
+			}
+		}
+		if len(users) < usersPageSize {
+			return result, nil
+		}
+	}
+}
+
+// Run implements Notifier
+//
+// It sends notifications immediately and then once per interval until ctx is done.
+func (n *notifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := n.Notify(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			n.log.Error().Err(err).Msg("failed to send notifications")
+		} else {
+			n.log.Info().
+				Int("users", result.Users).
+				Int("sent", result.Sent).
+				Int("failed", result.Failed).
+				Msg("notifications sent")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *notifier) notifyUser(
+	ctx context.Context,
+	user models.User,
+	now time.Time,
+	result *params.NotificationRun,
+) error {
+	settings, err := n.users.FindSettings(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error finding settings of user %d: %w", user.ID, err)
+	}
+	recipient := NewRecipient(user, settings)
+	if !recipient.Subscribed {
+		return nil
+	}
+	result.Users++
+	shelfLives, err := n.users.FindShelfLives(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error finding shelf lives of user %d: %w", user.ID, err)
+	}
+	for _, shelfLife := range shelfLives {
+		if shelfLife.EndDate == nil {
+			continue
+		}
+		daysLeft := utils.DaysLeft(*shelfLife.EndDate, now)
+		threshold, ok := MatchThreshold(daysLeft, n.thresholds)
+		if !ok {
+			continue
+		}
+		notification := NewNotification(user.ID, shelfLife, daysLeft, threshold)
+		for _, channel := range n.channels {
+			if !channel.Accepts(recipient) {
+				continue
+			}
+			if err := n.deliver(ctx, channel, recipient, notification, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deliver sends the notification through the channel unless it has already
+// been delivered there. Failed sends are unmarked to be retried on the next run.
+func (n *notifier) deliver(
+	ctx context.Context,
+	channel Channel,
+	recipient Recipient,
+	notification Notification,
+	result *params.NotificationRun,
+) error {
+	delivery := models.NotificationDelivery{
+		UserID:      recipient.UserID,
+		ShelfLifeID: notification.ShelfLifeID,
+		Threshold:   notification.Threshold,
+		Channel:     channel.Name(),
+	}
+	fresh, err := n.repo.MarkDelivered(ctx, delivery)
+	if err != nil {
+		return fmt.Errorf("error marking delivery: %w", err)
+	}
+	if !fresh {
+		return nil
+	}
+	if err := channel.Send(ctx, recipient, notification); err != nil {
+		result.Failed++
+		n.log.Error().
+			Err(err).
+			Str("channel", channel.Name()).
+			Int("user_id", recipient.UserID).
+			Int("shelf_life_id", notification.ShelfLifeID).
+			Msg("failed to send notification")
+		if err := n.repo.UnmarkDelivered(ctx, delivery); err != nil {
+			return fmt.Errorf("error unmarking delivery: %w", err)
+		}
+		return nil
+	}
+	result.Sent++
+	return nil
+}
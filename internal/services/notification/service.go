@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/notification"
+)
+
+type NotificationServicer interface {
+	FindNotifications(
+		ctx context.Context,
+		userID int,
+		filter *params.NotificationFilter,
+	) ([]params.FindNotification, error)
+	MarkRead(ctx context.Context, userID, id int) error
+	MarkUnread(ctx context.Context, userID, id int) error
+	Count(ctx context.Context, userID int, filter params.NotificationFilter) (int, error)
+}
+
+type notificationService struct {
+	repo repository.NotificationRepositorer
+}
+
+func New(repo repository.NotificationRepositorer) NotificationServicer {
+	return &notificationService{
+		repo: repo,
+	}
+}
+
+// FindNotifications implements NotificationServicer
+func (s *notificationService) FindNotifications(
+	ctx context.Context,
+	userID int,
+	filter *params.NotificationFilter,
+) ([]params.FindNotification, error) {
+	result, err := s.repo.FindMany(ctx, models.NotificationFilter{
+		PageFilter: models.PageFilter{Limit: filter.Limit, Offset: filter.Offset},
+		UserID:     userID,
+		Unread:     filter.Unread,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding notifications: %w", err)
+	}
+	return utils.NotificationModelsToFinds(result), nil
+}
+
+// MarkRead implements NotificationServicer
+func (s *notificationService) MarkRead(ctx context.Context, userID, id int) error {
+	if err := s.repo.SetRead(ctx, userID, id, true); err != nil {
+		return fmt.Errorf("error marking notification read: %w", err)
+	}
+	return nil
+}
+
+// MarkUnread implements NotificationServicer
+func (s *notificationService) MarkUnread(ctx context.Context, userID, id int) error {
+	if err := s.repo.SetRead(ctx, userID, id, false); err != nil {
+		return fmt.Errorf("error marking notification unread: %w", err)
+	}
+	return nil
+}
+
+// Count implements NotificationServicer
+func (s *notificationService) Count(
+	ctx context.Context,
+	userID int,
+	filter params.NotificationFilter,
+) (int, error) {
+	count, err := s.repo.Count(ctx, models.NotificationFilter{UserID: userID, Unread: filter.Unread})
+	if err != nil {
+		return 0, fmt.Errorf("error counting notifications: %w", err)
+	}
+	return count, nil
+}
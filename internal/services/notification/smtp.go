@@ -0,0 +1,128 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type smtpChannel struct {
+	host    string
+	addr    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+// NewSMTPChannel returns a channel that sends notifications by email.
+// Authentication is skipped when username is empty. Each email is sent
+// within the timeout.
+func NewSMTPChannel(host, port, username, password, from string, timeout time.Duration) Channel {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpChannel{
+		host:    host,
+		addr:    net.JoinHostPort(host, port),
+		from:    from,
+		auth:    auth,
+		timeout: timeout,
+	}
+}
+
+// Name implements Channel
+func (c *smtpChannel) Name() string {
+	return "smtp"
+}
+
+// Accepts implements Channel
+func (c *smtpChannel) Accepts(recipient Recipient) bool {
+	return recipient.Email != ""
+}
+
+// Send implements Channel
+//
+// It is smtp.SendMail bounded by the timeout and by ctx: the connection is
+// closed once either of them is done.
+func (c *smtpChannel) Send(ctx context.Context, recipient Recipient, notification Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	dialer := net.Dialer{Timeout: c.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to mail server: %w", err)
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set mail server deadline: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		return fmt.Errorf("failed to greet mail server: %w", err)
+	}
+	defer client.Close()
+	if err := c.send(client, recipient.Email, message(c.from, recipient.Email, notification)); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to send email: %w", ctx.Err())
+		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// send sends the message to the address like smtp.SendMail does, upgrading
+// the connection to TLS when the server supports it.
+func (c *smtpChannel) send(client *smtp.Client, to string, msg []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if c.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("mail server does not support authentication")
+		}
+		if err := client.Auth(c.auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message formats the notification as a plain text email.
+func message(from, to string, notification Notification) []byte {
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + to + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", notification.Title) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(notification.Message + "\r\n")
+	return []byte(msg.String())
+}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/safehttp"
+)
+
+type webhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel returns a channel that posts notifications as JSON to the
+// webhook URL from the user settings. The URLs pointing to the internal
+// network are refused.
+func NewWebhookChannel(timeout time.Duration) Channel {
+	return &webhookChannel{
+		client: safehttp.NewClient(timeout),
+	}
+}
+
+// Name implements Channel
+func (c *webhookChannel) Name() string {
+	return "webhook"
+}
+
+// Accepts implements Channel
+func (c *webhookChannel) Accepts(recipient Recipient) bool {
+	return recipient.WebhookURL != ""
+}
+
+// Send implements Channel
+func (c *webhookChannel) Send(ctx context.Context, recipient Recipient, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, recipient.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -29,12 +29,77 @@ type ProductServicer interface {
 	CreateProductTip(ctx context.Context, productID, tipID int) (params.FindTip, error)
 	DeleteProductTip(ctx context.Context, productID, tipID int) error
 	Count(ctx context.Context, filter params.ProductFilter) (int, error)
+	FindProductDurations(ctx context.Context, id int) ([]params.FindDefaultDuration, error)
+	UpdateProductDurations(
+		ctx context.Context,
+		id int,
+		payload *params.UpdateDefaultDurations,
+	) ([]params.FindDefaultDuration, error)
+	FindProductConversion(ctx context.Context, id int) (params.ProductConversion, error)
+	UpdateProductConversion(
+		ctx context.Context,
+		id int,
+		payload *params.ProductConversion,
+	) (params.ProductConversion, error)
 }
 
 type productService struct {
 	repo repo.ProductRepositorer
 }
 
+// FindProductDurations implements ProductServicer
+func (s *productService) FindProductDurations(
+	ctx context.Context,
+	id int,
+) ([]params.FindDefaultDuration, error) {
+	durations, err := s.repo.FindDurations(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding product durations: %w", err)
+	}
+	return utils.DefaultDurationModelsToFinds(durations), nil
+}
+
+// UpdateProductDurations implements ProductServicer
+func (s *productService) UpdateProductDurations(
+	ctx context.Context,
+	id int,
+	payload *params.UpdateDefaultDurations,
+) ([]params.FindDefaultDuration, error) {
+	durations := utils.DefaultDurationsToModels(payload.Durations)
+	if err := s.repo.UpdateDurations(ctx, id, durations); err != nil {
+		return nil, fmt.Errorf("error updating product durations: %w", err)
+	}
+	return s.FindProductDurations(ctx, id)
+}
+
+// FindProductConversion implements ProductServicer
+func (s *productService) FindProductConversion(
+	ctx context.Context,
+	id int,
+) (params.ProductConversion, error) {
+	conversions, err := s.repo.FindConversions(ctx, []int{id})
+	if err != nil {
+		return params.ProductConversion{}, fmt.Errorf("error finding product conversion: %w", err)
+	}
+	if len(conversions) == 0 {
+		return params.ProductConversion{}, nil
+	}
+	return utils.ProductConversionModelToFind(&conversions[0]), nil
+}
+
+// UpdateProductConversion implements ProductServicer
+func (s *productService) UpdateProductConversion(
+	ctx context.Context,
+	id int,
+	payload *params.ProductConversion,
+) (params.ProductConversion, error) {
+	conversion := utils.ProductConversionToModel(id, payload)
+	if err := s.repo.UpdateConversion(ctx, conversion); err != nil {
+		return params.ProductConversion{}, fmt.Errorf("error updating product conversion: %w", err)
+	}
+	return utils.ProductConversionModelToFind(&conversion), nil
+}
+
 // CreateProductTip implements ProductServicer
 func (s *productService) CreateProductTip(
 	ctx context.Context,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
 	"github.com/romankravchuk/muerta/internal/services/utils"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
@@ -17,7 +18,11 @@ type RecipeServicer interface {
 	UpdateRecipe(ctx context.Context, id int, payload *params.UpdateRecipe) error
 	DeleteRecipe(ctx context.Context, id int) error
 	RestoreRecipe(ctx context.Context, id int) error
-	FindRecipeIngredients(ctx context.Context, id int) ([]params.FindRecipeIngredient, error)
+	FindRecipeIngredients(
+		ctx context.Context,
+		id int,
+		filter *params.RecipeIngredientsFilter,
+	) ([]params.FindRecipeIngredient, error)
 	CreateIngredient(
 		ctx context.Context,
 		id int,
@@ -36,7 +41,8 @@ type RecipeServicer interface {
 }
 
 type recipeService struct {
-	repo recipes.RecipesRepositorer
+	repo        recipes.RecipesRepositorer
+	conversions conversion.ConversionServicer
 }
 
 func (s *recipeService) Count(ctx context.Context, filter params.RecipeFilter) (int, error) {
@@ -117,13 +123,45 @@ func (s *recipeService) DeleteIngredient(
 func (s *recipeService) FindRecipeIngredients(
 	ctx context.Context,
 	id int,
+	filter *params.RecipeIngredientsFilter,
 ) ([]params.FindRecipeIngredient, error) {
 	ingredients, err := s.repo.FindIngredients(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("ingredients not found: %w", err)
 	}
-	result := utils.RecipeIngredientModelsToFinds(ingredients)
-	return result, nil
+	if filter.Servings == 0 {
+		return utils.RecipeIngredientModelsToFinds(ingredients), nil
+	}
+	recipe, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("recipe not found by id: %w", err)
+	}
+	converter, err := s.conversions.Converter(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error finding conversions: %w", err)
+	}
+	scaled := scaleIngredients(ingredients, recipe.Servings, filter.Servings, converter)
+	return utils.RecipeIngredientModelsToFinds(scaled), nil
+}
+
+// scaleIngredients scales the quantities of the ingredients from the
+// servings of the recipe to the requested ones. The quantities are rounded
+// and given in the measures which read best.
+func scaleIngredients(
+	ingredients []models.RecipeIngredient,
+	from, to int,
+	converter conversion.Converter,
+) []models.RecipeIngredient {
+	if from <= 0 {
+		from = 1
+	}
+	result := make([]models.RecipeIngredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		quantity := ingredient.Quantity * float32(to) / float32(from)
+		ingredient.Quantity, ingredient.Measure = converter.Readable(quantity, ingredient.Measure)
+		result[i] = ingredient
+	}
+	return result
 }
 
 // UpdateIngredient implements RecipeServicer
@@ -141,8 +179,11 @@ func (s *recipeService) UpdateIngredient(
 	return dto, nil
 }
 
-func New(repository recipes.RecipesRepositorer) RecipeServicer {
-	return &recipeService{repo: repository}
+func New(
+	repository recipes.RecipesRepositorer,
+	conversions conversion.ConversionServicer,
+) RecipeServicer {
+	return &recipeService{repo: repository, conversions: conversions}
 }
 
 func (s *recipeService) CreateRecipe(ctx context.Context, payload *params.CreateRecipe) error {
@@ -195,6 +236,9 @@ func (s *recipeService) UpdateRecipe(
 	if payload.Description != "" {
 		recipe.Description = payload.Description
 	}
+	if payload.Servings != 0 {
+		recipe.Servings = payload.Servings
+	}
 	if err := s.repo.Update(ctx, &recipe); err != nil {
 		return fmt.Errorf("update recipe: %w", err)
 	}
@@ -0,0 +1,83 @@
+package recipe
+
+import (
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ScaleIngredients(t *testing.T) {
+	var (
+		gram  = models.Measure{ID: 1, Name: "г"}
+		kilo  = models.Measure{ID: 2, Name: "кг"}
+		piece = models.Measure{ID: 3, Name: "шт"}
+		flour = models.Product{ID: 1, Name: "мука"}
+		eggs  = models.Product{ID: 2, Name: "яйца"}
+		salt  = models.Product{ID: 3, Name: "соль"}
+	)
+	ingredients := []models.RecipeIngredient{
+		{Product: flour, Measure: gram, Quantity: 250},
+		{Product: eggs, Measure: piece, Quantity: 3},
+		{Product: salt, Measure: gram, Quantity: 0.5},
+	}
+	converter := conversion.NewConverter([]models.Measure{gram, kilo, piece}, nil)
+	testCases := []struct {
+		name     string
+		from, to int
+		expected []models.RecipeIngredient
+	}{
+		{
+			name: "doubled",
+			from: 4,
+			to:   8,
+			expected: []models.RecipeIngredient{
+				{Product: flour, Measure: gram, Quantity: 500},
+				{Product: eggs, Measure: piece, Quantity: 6},
+				{Product: salt, Measure: gram, Quantity: 1},
+			},
+		},
+		{
+			name: "to a readable measure",
+			from: 2,
+			to:   8,
+			expected: []models.RecipeIngredient{
+				{Product: flour, Measure: kilo, Quantity: 1},
+				{Product: eggs, Measure: piece, Quantity: 12},
+				{Product: salt, Measure: gram, Quantity: 2},
+			},
+		},
+		{
+			name: "rounded",
+			from: 4,
+			to:   3,
+			expected: []models.RecipeIngredient{
+				{Product: flour, Measure: gram, Quantity: 188},
+				{Product: eggs, Measure: piece, Quantity: 2.5},
+				{Product: salt, Measure: gram, Quantity: 0.375},
+			},
+		},
+		{
+			name: "unknown servings",
+			from: 0,
+			to:   2,
+			expected: []models.RecipeIngredient{
+				{Product: flour, Measure: gram, Quantity: 500},
+				{Product: eggs, Measure: piece, Quantity: 6},
+				{Product: salt, Measure: gram, Quantity: 1},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := scaleIngredients(ingredients, tc.from, tc.to, converter)
+			assert.Len(t, result, len(tc.expected))
+			for i := range tc.expected {
+				assert.Equal(t, tc.expected[i].Product, result[i].Product)
+				assert.Equal(t, tc.expected[i].Measure.ID, result[i].Measure.ID)
+				assert.InDelta(t, tc.expected[i].Quantity, result[i].Quantity, 1e-4)
+			}
+		})
+	}
+}
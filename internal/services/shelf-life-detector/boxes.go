@@ -0,0 +1,90 @@
+package shelflifedetector
+
+import (
+	"image"
+	"strings"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+)
+
+// alignWindow is how many words of the text are skipped at most looking for
+// a word told by the engine, which may leave some of them out.
+const alignWindow = 4
+
+// locate sets the boxes of the dates found in the text recognized in the
+// rotation to the union of the boxes of the words they are made of, mapped
+// back to the original image.
+func locate(candidates []candidate, result OCRResult, f frame, rotation int) {
+	fields := strings.Split(normalize(result.Text), " ")
+	words := alignWords(fields, result.Words)
+	for i := range candidates {
+		c := &candidates[i]
+		if c.end <= c.start {
+			continue
+		}
+		var box image.Rectangle
+		first, last := wordIndex(fields, c.start), wordIndex(fields, c.end-1)
+		for j := first; j <= last && j < len(words); j++ {
+			if words[j] >= 0 {
+				box = box.Union(result.Words[words[j]].Box)
+			}
+		}
+		if !box.Empty() {
+			c.Box = toBox(f.toOriginal(box, rotation))
+		}
+	}
+}
+
+// alignWords returns the indexes of the words told by the engine each of the
+// words of the normalized text is, or -1 for the ones not told.
+func alignWords(fields []string, words []Word) []int {
+	result := make([]int, len(fields))
+	for i := range result {
+		result[i] = -1
+	}
+	next := 0
+	for i, word := range words {
+		for _, part := range strings.Fields(strings.ToLower(word.Text)) {
+			for j := next; j < len(fields) && j <= next+alignWindow; j++ {
+				if fields[j] == part {
+					result[j], next = i, j+1
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// wordIndex returns the index of the word of the normalized text at the
+// offset.
+func wordIndex(fields []string, offset int) int {
+	for i, field := range fields {
+		if offset < len(field) {
+			return i
+		}
+		offset -= len(field) + 1
+	}
+	return len(fields) - 1
+}
+
+// recognizedWords maps the boxes of the words recognized in the rotation back
+// to the original image.
+func recognizedWords(words []Word, f frame, rotation int) []params.RecognizedWord {
+	result := make([]params.RecognizedWord, 0, len(words))
+	for _, word := range words {
+		recognized := params.RecognizedWord{Text: word.Text, Confidence: word.Confidence}
+		if box := toBox(f.toOriginal(word.Box, rotation)); box != nil {
+			recognized.Box = *box
+		}
+		result = append(result, recognized)
+	}
+	return result
+}
+
+func toBox(r image.Rectangle) *params.Box {
+	if r.Empty() {
+		return nil
+	}
+	return &params.Box{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()}
+}
@@ -0,0 +1,129 @@
+package shelflifedetector
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+)
+
+// datasetDir is the directory the exported dataset is archived in.
+const datasetDir = "dataset"
+
+// LabelsFile is the file of the dataset directory the images are labelled in
+// by their file names.
+const LabelsFile = "labels.json"
+
+// Label tells the dates printed on the image of a sample, a missing date is
+// not printed. The text is the one recognized when the image was detected,
+// which is also the time the dates without a year are taken in.
+type Label struct {
+	Text         string     `json:"text"`
+	Manufactured *time.Time `json:"manufactured,omitempty"`
+	Expiry       *time.Time `json:"expiry,omitempty"`
+	DetectedAt   time.Time  `json:"detected_at"`
+}
+
+// Sample is a labelled image of the dataset, named after the image file.
+type Sample struct {
+	Name  string
+	Label Label
+	Image []byte
+}
+
+// LoadDataset reads the samples of the dataset directory ordered by name.
+func LoadDataset(dir string) ([]Sample, error) {
+	data, err := os.ReadFile(filepath.Join(dir, LabelsFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+	var labels map[string]Label
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels: %w", err)
+	}
+	samples := make([]Sample, 0, len(labels))
+	for name, label := range labels {
+		image, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		samples = append(samples, Sample{Name: name, Label: label, Image: image})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Name < samples[j].Name })
+	return samples, nil
+}
+
+// WriteDataset writes the samples to w as a zip archive of the dataset
+// directory.
+func WriteDataset(w io.Writer, samples []Sample) error {
+	archive := zip.NewWriter(w)
+	labels := make(map[string]Label, len(samples))
+	for _, sample := range samples {
+		if err := archiveFile(archive, sample.Name, sample.Image); err != nil {
+			return err
+		}
+		labels[sample.Name] = sample.Label
+	}
+	data, err := json.MarshalIndent(labels, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal labels: %w", err)
+	}
+	if err := archiveFile(archive, LabelsFile, data); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+	return nil
+}
+
+func archiveFile(archive *zip.Writer, name string, data []byte) error {
+	file, err := archive.Create(path.Join(datasetDir, name))
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
+
+// Predict returns the manufacturing and the expiry date the detected dates
+// amount to, the most confident ones of each kind.
+func Predict(dates []params.DetectedDate) (manufactured, expiry *time.Time) {
+	return mostConfident(dates, DateManufactured), mostConfident(dates, DateExpiry)
+}
+
+func mostConfident(dates []params.DetectedDate, kind string) *time.Time {
+	var best *params.DetectedDate
+	for i := range dates {
+		if dates[i].Kind == kind && (best == nil || dates[i].Confidence > best.Confidence) {
+			best = &dates[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.Date
+}
+
+// imageExtension returns the file extension of the image content type.
+func imageExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".bin"
+	}
+}
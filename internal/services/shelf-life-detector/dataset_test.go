@@ -0,0 +1,91 @@
+package shelflifedetector
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// Test_Dataset extracts the dates from the recognized text of the samples
+// exported from the corrected detections. New samples are added by unpacking
+// the export into testdata.
+func Test_Dataset(t *testing.T) {
+	samples, err := LoadDataset(filepath.Join("testdata", "dataset"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, samples)
+	for _, sample := range samples {
+		t.Run(sample.Name, func(t *testing.T) {
+			manufactured, expiry := Predict(ExtractDates(sample.Label.Text, sample.Label.DetectedAt))
+			assertSameDay(t, sample.Label.Manufactured, manufactured, "manufactured")
+			assertSameDay(t, sample.Label.Expiry, expiry, "expiry")
+		})
+	}
+}
+
+// assertSameDay asserts that the predicted date is the labelled calendar day
+// or that both are missing.
+func assertSameDay(t *testing.T, expected, actual *time.Time, kind string) {
+	t.Helper()
+	if expected == nil || actual == nil {
+		assert.Equal(t, expected, actual, kind)
+		return
+	}
+	assert.True(t, sameDay(expected, actual), "%s: expected %s, got %s", kind, expected, actual)
+}
+
+type fakeDetectionRepository struct {
+	repository.DetectionRepositorer
+	corrected []models.Detection
+}
+
+func (r *fakeDetectionRepository) FindCorrected(context.Context) ([]models.Detection, error) {
+	return r.corrected, nil
+}
+
+func Test_ExportDataset(t *testing.T) {
+	detectedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiry := time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)
+	image := encodeTestPNG(t, 1, 1)
+	svc := NewHistoryService(nil, &fakeDetectionRepository{corrected: []models.Detection{{
+		Image:     models.DetectionImage{Hash: "abc", Image: image, ContentType: "image/png"},
+		Text:      "годен до 24.03",
+		Expiry:    &expiry,
+		CreatedAt: &detectedAt,
+	}}})
+
+	var archive bytes.Buffer
+	count, err := svc.ExportDataset(context.Background(), &archive)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	dir := t.TempDir()
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	assert.Nil(t, err)
+	for _, file := range reader.File {
+		content, err := file.Open()
+		assert.Nil(t, err)
+		data, err := io.ReadAll(content)
+		assert.Nil(t, err)
+		content.Close()
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file.Name)), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, file.Name), data, 0o644))
+	}
+
+	samples, err := LoadDataset(filepath.Join(dir, datasetDir))
+	assert.Nil(t, err)
+	assert.Equal(t, []Sample{{
+		Name:  "abc.png",
+		Label: Label{Text: "годен до 24.03", Expiry: &expiry, DetectedAt: detectedAt},
+		Image: image,
+	}}, samples)
+}
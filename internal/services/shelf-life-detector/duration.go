@@ -0,0 +1,138 @@
+package shelflifedetector
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+)
+
+// durationFactor lowers the confidence of the expiry dates computed from the
+// shelf life as both the manufacturing date and the duration may be misread.
+const durationFactor = 0.9
+
+// Units of the shelf life.
+const (
+	unitDays = iota
+	unitMonths
+	unitYears
+)
+
+var (
+	reDuration = regexp.MustCompile(
+		`(?:годен|годна|годно|годность|срок годности|срок хранения|хранить|` +
+			`shelf life|best within|use within|consume within|keep|expires in)` +
+			`(?:\s*[:.,-]?\s*(?:в течение|не более|within|for|up to))?\s*[:.,-]?\s*` +
+			`(\d{1,3})\s*(сут(?:ок|ки)?|д(?:ней|ня|ень|н)|мес(?:яцев|яца|яц)?|лет|года?|` +
+			`days?|months?|mon|years?|yrs?)\.?`,
+	)
+	// reOpened matches the shelf life after opening the package which has
+	// nothing to do with the expiry date.
+	reOpened = regexp.MustCompile(`^\s*(?:с момента|после|after)\s+(?:вскрытия|открытия|opening)`)
+
+	durationUnits = map[string]int{
+		"сут": unitDays, "сутки": unitDays, "суток": unitDays,
+		"дн": unitDays, "день": unitDays, "дня": unitDays, "дней": unitDays,
+		"day": unitDays, "days": unitDays,
+		"мес": unitMonths, "месяц": unitMonths, "месяца": unitMonths, "месяцев": unitMonths,
+		"mon": unitMonths, "month": unitMonths, "months": unitMonths,
+		"год": unitYears, "года": unitYears, "лет": unitYears,
+		"yr": unitYears, "yrs": unitYears, "year": unitYears, "years": unitYears,
+	}
+)
+
+// duration is a shelf life found in the text, e.g. "годен 30 суток".
+type duration struct {
+	amount     int
+	unit       int
+	text       string
+	start, end int
+}
+
+// findDurations returns the shelf lives in the text but the ones counted
+// from opening the package.
+func findDurations(text string) []duration {
+	var durations []duration
+	for _, loc := range reDuration.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if !isBoundary(text, start, end) || reOpened.MatchString(text[end:]) {
+			continue
+		}
+		amount, _ := strconv.Atoi(text[loc[2]:loc[3]])
+		if amount == 0 {
+			continue
+		}
+		durations = append(durations, duration{
+			amount: amount,
+			unit:   durationUnits[strings.TrimSuffix(text[loc[4]:loc[5]], ".")],
+			text:   strings.TrimSuffix(text[loc[2]:end], "."),
+			start:  start,
+			end:    end,
+		})
+	}
+	return durations
+}
+
+// addTo returns the date the shelf life ends if it starts at the date. The
+// months that are too short end the shelf life at their last day, e.g. a
+// month from January 31 is February 28.
+func (d duration) addTo(date time.Time) time.Time {
+	switch d.unit {
+	case unitMonths:
+		return addMonths(date, d.amount)
+	case unitYears:
+		return addMonths(date, 12*d.amount)
+	default:
+		return date.AddDate(0, 0, d.amount)
+	}
+}
+
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	day := date.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day,
+		date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
+}
+
+// deriveExpiry adds the expiry date computed from the manufacturing date and
+// the shelf life unless an expiry date is printed. A single date of unknown
+// kind next to a shelf life is taken for the manufacturing date.
+func deriveExpiry(candidates []candidate, durations []duration) []candidate {
+	if len(durations) == 0 {
+		return candidates
+	}
+	base := -1
+	for i, c := range candidates {
+		switch {
+		case c.Kind == DateExpiry:
+			return candidates
+		case c.Kind == DateManufactured && (base < 0 || c.Confidence > candidates[base].Confidence):
+			base = i
+		}
+	}
+	if base < 0 {
+		if len(candidates) != 1 {
+			return candidates
+		}
+		base = 0
+		candidates[base].Kind = DateManufactured
+		candidates[base].Confidence *= orderFactor
+	}
+	d := durations[0]
+	return append(candidates, candidate{
+		DetectedDate: params.DetectedDate{
+			Date:       d.addTo(candidates[base].Date),
+			Kind:       DateExpiry,
+			Confidence: candidates[base].Confidence * durationFactor,
+			Text:       d.text,
+			Derived:    true,
+		},
+		start: d.start,
+		end:   d.end,
+	})
+}
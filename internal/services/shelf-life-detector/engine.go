@@ -0,0 +1,73 @@
+package shelflifedetector
+
+import (
+	"context"
+	"image"
+	"strings"
+	"unicode/utf8"
+)
+
+// Names of the OCR engines.
+const (
+	EngineTesseract = "tesseract"
+	EngineHTTP      = "http"
+	EngineFake      = "fake"
+)
+
+// OCREngine recognizes the text on images.
+type OCREngine interface {
+	// Recognize returns the text on the PNG image with the words it is made
+	// of.
+	Recognize(ctx context.Context, image []byte) (OCRResult, error)
+	// Close releases the resources of the engine.
+	Close() error
+}
+
+// OCRResult is the text recognized on an image.
+type OCRResult struct {
+	Text  string
+	Words []Word
+}
+
+// Word is a word recognized on an image with its position in pixels and
+// the confidence of the engine between 0 and 1.
+type Word struct {
+	Text       string
+	Box        image.Rectangle
+	Confidence float64
+}
+
+type fakeEngine struct {
+	result OCRResult
+}
+
+// NewFakeEngine returns an engine which recognizes the same text on any
+// image, laid out in a single line of fixed width characters.
+func NewFakeEngine(text string) OCREngine {
+	const charWidth, lineHeight = 10, 20
+	result := OCRResult{Text: text}
+	x := 0
+	for _, field := range strings.Fields(text) {
+		width := utf8.RuneCountInString(field) * charWidth
+		result.Words = append(result.Words, Word{
+			Text:       field,
+			Box:        image.Rect(x, 0, x+width, lineHeight),
+			Confidence: 1,
+		})
+		x += width + charWidth
+	}
+	return &fakeEngine{result: result}
+}
+
+// Recognize implements OCREngine
+func (e *fakeEngine) Recognize(ctx context.Context, _ []byte) (OCRResult, error) {
+	if err := ctx.Err(); err != nil {
+		return OCRResult{}, err
+	}
+	return e.result, nil
+}
+
+// Close implements OCREngine
+func (e *fakeEngine) Close() error {
+	return nil
+}
@@ -0,0 +1,69 @@
+package shelflifedetector
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTTPEngine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req httpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || string(req.Image) != "image" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"text":"годен до 24.09.22","words":[` +
+			`{"text":"24.09.22","confidence":0.9,"box":{"x":10,"y":20,"width":30,"height":40}}]}`))
+	}))
+	defer server.Close()
+	testCases := []struct {
+		name     string
+		token    string
+		expected OCRResult
+		fails    bool
+	}{
+		{
+			name:  "recognized",
+			token: "secret",
+			expected: OCRResult{
+				Text:  "годен до 24.09.22",
+				Words: []Word{{Text: "24.09.22", Box: image.Rect(10, 20, 40, 60), Confidence: 0.9}},
+			},
+		},
+		{name: "unauthorized", token: "wrong", fails: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewHTTPEngine(server.URL, tc.token)
+			defer engine.Close()
+
+			result, err := engine.Recognize(context.Background(), []byte("image"))
+			if tc.fails {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func Test_FakeEngine(t *testing.T) {
+	result, err := NewFakeEngine("годен до\n24.09.22").Recognize(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []Word{
+		{Text: "годен", Box: image.Rect(0, 0, 50, 20), Confidence: 1},
+		{Text: "до", Box: image.Rect(60, 0, 80, 20), Confidence: 1},
+		{Text: "24.09.22", Box: image.Rect(90, 0, 170, 20), Confidence: 1},
+	}, result.Words)
+}
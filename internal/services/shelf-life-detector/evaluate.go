@@ -0,0 +1,107 @@
+package shelflifedetector
+
+import (
+	errs "errors"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+)
+
+// Result is the dates detected on a sample of the dataset next to the
+// labelled ones. Error is set if the detection failed other than by finding
+// no dates.
+type Result struct {
+	Name                 string     `json:"name"`
+	Manufactured         *time.Time `json:"manufactured,omitempty"`
+	Expiry               *time.Time `json:"expiry,omitempty"`
+	ExpectedManufactured *time.Time `json:"expected_manufactured,omitempty"`
+	ExpectedExpiry       *time.Time `json:"expected_expiry,omitempty"`
+	LatencyMs            int64      `json:"latency_ms"`
+	Error                string     `json:"error,omitempty"`
+}
+
+// Metrics tells how well the dates of a kind are detected. Precision is the
+// share of the detected dates which are right, recall is the share of the
+// labelled dates which are detected right, and accuracy is the share of the
+// samples with the date right, including the ones without the date.
+type Metrics struct {
+	Samples   int     `json:"samples"`
+	Detected  int     `json:"detected"`
+	Labelled  int     `json:"labelled"`
+	Correct   int     `json:"correct"`
+	Exact     int     `json:"exact"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	Accuracy  float64 `json:"accuracy"`
+}
+
+// Report is the evaluation of the detector on the dataset.
+type Report struct {
+	Results      []Result `json:"results"`
+	Manufactured Metrics  `json:"manufactured"`
+	Expiry       Metrics  `json:"expiry"`
+}
+
+// Evaluate detects the dates on each of the samples with detect and compares
+// the most confident ones with the labels.
+func Evaluate(samples []Sample, detect func(Sample) (Detection, error)) Report {
+	report := Report{Results: make([]Result, 0, len(samples))}
+	for _, sample := range samples {
+		result := Result{
+			Name:                 sample.Name,
+			ExpectedManufactured: sample.Label.Manufactured,
+			ExpectedExpiry:       sample.Label.Expiry,
+		}
+		detection, err := detect(sample)
+		if err != nil && !errs.Is(err, errors.ErrNoDatesDetected) {
+			result.Error = err.Error()
+		}
+		result.Manufactured, result.Expiry = Predict(detection.Dates)
+		result.LatencyMs = detection.Latency.Milliseconds()
+		report.Manufactured.add(result.Manufactured, result.ExpectedManufactured)
+		report.Expiry.add(result.Expiry, result.ExpectedExpiry)
+		report.Results = append(report.Results, result)
+	}
+	report.Manufactured.compute()
+	report.Expiry.compute()
+	return report
+}
+
+func (m *Metrics) add(detected, expected *time.Time) {
+	m.Samples++
+	if detected != nil {
+		m.Detected++
+	}
+	if expected != nil {
+		m.Labelled++
+	}
+	switch {
+	case detected == nil && expected == nil:
+		m.Exact++
+	case sameDay(detected, expected):
+		m.Correct++
+		m.Exact++
+	}
+}
+
+// sameDay reports whether both dates are set and fall on the same calendar
+// day in UTC. The detected dates are compared to the labels as days whatever
+// their time.
+func sameDay(a, b *time.Time) bool {
+	return a != nil && b != nil && utils.Day(*a).Equal(utils.Day(*b))
+}
+
+func (m *Metrics) compute() {
+	m.Precision = ratio(m.Correct, m.Detected)
+	m.Recall = ratio(m.Correct, m.Labelled)
+	m.Accuracy = ratio(m.Exact, m.Samples)
+}
+
+// ratio returns 0 if there is nothing to divide by.
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
@@ -0,0 +1,54 @@
+package shelflifedetector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+)
+
+func Test_Evaluate(t *testing.T) {
+	date := func(day int) *time.Time {
+		date := time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	samples := []Sample{
+		{Name: "right", Label: Label{Manufactured: date(1), Expiry: date(24)}},
+		{Name: "wrong expiry", Label: Label{Manufactured: date(1), Expiry: date(24)}},
+		{Name: "no dates", Label: Label{Expiry: date(24)}},
+		{Name: "failed", Label: Label{Expiry: date(24)}},
+	}
+	detections := map[string][]params.DetectedDate{
+		"right": {
+			{Date: *date(1), Kind: DateManufactured, Confidence: 1},
+			{Date: date(24).Add(12 * time.Hour), Kind: DateExpiry, Confidence: 1},
+		},
+		"wrong expiry": {
+			{Date: *date(1), Kind: DateManufactured, Confidence: 1},
+			{Date: *date(25), Kind: DateExpiry, Confidence: 1},
+		},
+	}
+	report := Evaluate(samples, func(sample Sample) (Detection, error) {
+		switch sample.Name {
+		case "no dates":
+			return Detection{}, errors.ErrNoDatesDetected
+		case "failed":
+			return Detection{}, errors.ErrDetectionTimeout
+		}
+		return Detection{Dates: detections[sample.Name]}, nil
+	})
+
+	assert.Equal(t, Metrics{
+		Samples: 4, Detected: 2, Labelled: 2, Correct: 2, Exact: 4,
+		Precision: 1, Recall: 1, Accuracy: 1,
+	}, report.Manufactured)
+	assert.Equal(t, Metrics{
+		Samples: 4, Detected: 2, Labelled: 4, Correct: 1, Exact: 1,
+		Precision: 0.5, Recall: 0.25, Accuracy: 0.25,
+	}, report.Expiry)
+	assert.Empty(t, report.Results[2].Error)
+	assert.Equal(t, errors.ErrDetectionTimeout.Error(), report.Results[3].Error)
+}
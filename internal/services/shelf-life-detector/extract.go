@@ -0,0 +1,411 @@
+package shelflifedetector
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+)
+
+// Kinds of the detected dates.
+const (
+	DateManufactured = "manufactured"
+	DateExpiry       = "expiry"
+	DateUnknown      = "unknown"
+)
+
+const (
+	// labelWindow is how many bytes before a date are searched for keywords.
+	labelWindow = 48
+	// yearRange is how many years away from now a date may be.
+	yearRange = 20
+	// Confidence multipliers for dates without a year or a day and for the
+	// ways the kind of a date is found out.
+	incompleteFactor = 0.75
+	orderFactor      = 0.7
+	unknownFactor    = 0.5
+)
+
+var (
+	ruMonths = `(янв(?:аря|арь)?|фев(?:раля|раль)?|мар(?:та|т)?|апр(?:еля|ель)?|ма[йя]|июн[яь]?|июл[яь]?|` +
+		`авг(?:уста|уст)?|сен(?:тября|тябрь)?|окт(?:ября|ябрь)?|ноя(?:бря|брь)?|дек(?:абря|абрь)?)\.?`
+	enMonths = `(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|` +
+		`sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)\.?`
+	months = `(?:` + ruMonths + `|` + enMonths + `)`
+	// timeSuffix matches the time stamped after a date, e.g. "24.09.22 14:35".
+	timeSuffix = `(?:\s*[t/]?\s*([01]?\d|2[0-3]):([0-5]\d)(?::[0-5]\d)?)?`
+
+	monthNumbers = map[string]time.Month{
+		"янв": time.January, "фев": time.February, "мар": time.March,
+		"апр": time.April, "май": time.May, "мая": time.May,
+		"июн": time.June, "июл": time.July, "авг": time.August,
+		"сен": time.September, "окт": time.October, "ноя": time.November,
+		"дек": time.December,
+		"jan": time.January, "feb": time.February, "mar": time.March,
+		"apr": time.April, "may": time.May, "jun": time.June,
+		"jul": time.July, "aug": time.August, "sep": time.September,
+		"oct": time.October, "nov": time.November, "dec": time.December,
+	}
+
+	reManufactured = keywords(
+		"изготовлено", "изготовлен", "изготовления", "изг", "произведено", "дата производства",
+		"упаковано", "дата упаковки", "manufactured", "mfg", "mfd", "prod", "production date",
+		"packed", "packed on", "made on",
+	)
+	reExpiry = keywords(
+		"годен", "годно", "годность", "употребить", "использовать", "срок годности",
+		"best before", "bb", "bbe", "use by", "use before", "exp", "expiry", "expires",
+		"expiration", "exp date",
+	)
+)
+
+// keywords matches any of the words as a whole.
+func keywords(words ...string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^\p{L}])(?:` + strings.Join(words, "|") + `)(?:[^\p{L}]|$)`)
+}
+
+// format is a way of writing a date. The formats are tried in order and
+// later formats can't use the text matched by earlier ones.
+type format struct {
+	re         *regexp.Regexp
+	confidence float64
+	// needsKeyword formats are too ambiguous to be taken without a keyword.
+	needsKeyword bool
+	// parse returns the date, whether it exists and whether it is complete,
+	// i.e. has a year and a day.
+	parse func(groups []string, now time.Time) (time.Time, bool, bool)
+}
+
+var formats = []format{
+	{
+		// 2024-03-12
+		re:         regexp.MustCompile(`(\d{4})[-./](\d{1,2})[-./](\d{1,2})` + timeSuffix),
+		confidence: 0.95,
+		parse: func(g []string, _ time.Time) (time.Time, bool, bool) {
+			return numericDate(g[1], g[2], g[3], g[4], g[5])
+		},
+	},
+	{
+		// 12.03.2024, 12/03/24
+		re:         regexp.MustCompile(`(\d{1,2})[-./](\d{1,2})[-./](\d{4}|\d{2})` + timeSuffix),
+		confidence: 0.9,
+		parse: func(g []string, now time.Time) (time.Time, bool, bool) {
+			return numericDate(fullYear(g[3], now), g[2], g[1], g[4], g[5])
+		},
+	},
+	{
+		// 12 МАР 2024, 12 марта
+		re:         regexp.MustCompile(`(\d{1,2})\s*[-./]?\s*` + months + `(?:\s*[-./]?\s*(\d{4}|\d{2}))?` + timeSuffix),
+		confidence: 0.9,
+		parse: func(g []string, now time.Time) (time.Time, bool, bool) {
+			return namedDate(g[1], g[2]+g[3], g[4], g[5], g[6], now)
+		},
+	},
+	{
+		// Mar 12, Mar 12, 2024
+		re:         regexp.MustCompile(months + `\s*(\d{1,2})(?:,?\s*(\d{4}))?` + timeSuffix),
+		confidence: 0.85,
+		parse: func(g []string, now time.Time) (time.Time, bool, bool) {
+			return namedDate(g[3], g[1]+g[2], g[4], g[5], g[6], now)
+		},
+	},
+	{
+		// 03/24
+		re:         regexp.MustCompile(`(0?[1-9]|1[0-2])/(\d{4}|\d{2})`),
+		confidence: 0.75,
+		parse:      monthEnd,
+	},
+	{
+		// 03.24, 12.99 is more likely a price than a month
+		re:           regexp.MustCompile(`(0?[1-9]|1[0-2])\.(\d{4}|\d{2})`),
+		confidence:   0.75,
+		needsKeyword: true,
+		parse:        monthEnd,
+	},
+}
+
+// candidate is a date found in the text.
+type candidate struct {
+	params.DetectedDate
+	start, end   int
+	needsKeyword bool
+}
+
+// ExtractDates finds the dates in the text recognized on a package. The kind
+// of a date is told by the keywords before it, e.g. "годен до" or "best
+// before". If there are no keywords at all the earliest of several dates is
+// taken for the manufacturing date and the latest one for the expiry date.
+// If only the shelf life is printed, e.g. "годен 30 суток", the expiry date
+// is derived from the manufacturing date.
+// Dates without a year are taken in the year closest to now and dates
+// without a day at the end of the month. The dates are ordered by date.
+func ExtractDates(text string, now time.Time) []params.DetectedDate {
+	return collect(extract(text, now))
+}
+
+// normalize lowercases the text and separates the words by single spaces.
+func normalize(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// extract finds the dates like ExtractDates with their positions in the
+// normalized text, repeated and unordered.
+func extract(text string, now time.Time) []candidate {
+	text = normalize(text)
+	used := make([]bool, len(text))
+	durations := findDurations(text)
+	// The keywords of the shelf lives tell nothing about the dates after
+	// them, so they are blanked out of the text searched for keywords.
+	labels := []byte(text)
+	for _, d := range durations {
+		for i := d.start; i < d.end; i++ {
+			used[i] = true
+			labels[i] = ' '
+		}
+	}
+	var candidates []candidate
+	for _, f := range formats {
+		for _, loc := range f.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if !isBoundary(text, start, end) || isUsed(used, start, end) {
+				continue
+			}
+			groups := make([]string, len(loc)/2)
+			for i := range groups {
+				if loc[2*i] >= 0 {
+					groups[i] = text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			date, ok, complete := f.parse(groups, now)
+			if !ok || math.Abs(float64(date.Year()-now.Year())) > yearRange {
+				continue
+			}
+			confidence := f.confidence
+			if !complete {
+				confidence *= incompleteFactor
+			}
+			for i := start; i < end; i++ {
+				used[i] = true
+			}
+			candidates = append(candidates, candidate{
+				DetectedDate: params.DetectedDate{
+					Date:       date,
+					Confidence: confidence,
+					Text:       text[start:end],
+				},
+				start:        start,
+				end:          end,
+				needsKeyword: f.needsKeyword,
+			})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].start < candidates[j].start })
+	candidates = labelByKeywords(string(labels), candidates)
+	candidates = deriveExpiry(candidates, durations)
+	labelByOrder(candidates)
+	return candidates
+}
+
+// labelByKeywords sets the kinds of the dates ordered by position and drops
+// the ambiguous dates without keywords.
+func labelByKeywords(text string, candidates []candidate) []candidate {
+	result := candidates[:0]
+	prevEnd := 0
+	for _, c := range candidates {
+		from := c.start - labelWindow
+		if from < prevEnd {
+			from = prevEnd
+		}
+		for from < c.start && !utf8.RuneStart(text[from]) {
+			from++
+		}
+		prevEnd = c.end
+		c.Kind = keywordKind(text[from:c.start])
+		if c.needsKeyword && c.Kind == DateUnknown {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// labelByOrder sets the kinds of several dates if there are no keywords at
+// all and lowers the confidence of the dates of unknown kind.
+func labelByOrder(candidates []candidate) {
+	unknown := 0
+	for _, c := range candidates {
+		if c.Kind == DateUnknown {
+			unknown++
+		}
+	}
+	if unknown < 2 || unknown != len(candidates) {
+		for i := range candidates {
+			if candidates[i].Kind == DateUnknown {
+				candidates[i].Confidence *= unknownFactor
+			}
+		}
+		return
+	}
+	first, last := 0, 0
+	for i := range candidates {
+		if candidates[i].Date.Before(candidates[first].Date) {
+			first = i
+		}
+		if !candidates[i].Date.Before(candidates[last].Date) {
+			last = i
+		}
+	}
+	for i := range candidates {
+		c := &candidates[i]
+		switch {
+		case candidates[first].Date.Equal(candidates[last].Date):
+			c.Confidence *= unknownFactor
+		case i == first:
+			c.Kind = DateManufactured
+			c.Confidence *= orderFactor
+		case i == last:
+			c.Kind = DateExpiry
+			c.Confidence *= orderFactor
+		default:
+			c.Confidence *= unknownFactor
+		}
+	}
+}
+
+// collect drops the repeated dates keeping the most confident ones and
+// orders the rest by date.
+func collect(candidates []candidate) []params.DetectedDate {
+	result := make([]params.DetectedDate, 0, len(candidates))
+	seen := map[time.Time]int{}
+	for _, c := range candidates {
+		c.Confidence = math.Round(c.Confidence*100) / 100
+		if i, ok := seen[c.Date]; ok {
+			if c.Confidence > result[i].Confidence {
+				result[i] = c.DetectedDate
+			}
+			continue
+		}
+		seen[c.Date] = len(result)
+		result = append(result, c.DetectedDate)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result
+}
+
+// keywordKind returns the kind told by the keyword closest to the end of
+// the text.
+func keywordKind(text string) string {
+	manufactured, expiry := lastIndex(reManufactured, text), lastIndex(reExpiry, text)
+	switch {
+	case manufactured < 0 && expiry < 0:
+		return DateUnknown
+	case manufactured > expiry:
+		return DateManufactured
+	default:
+		return DateExpiry
+	}
+}
+
+func lastIndex(re *regexp.Regexp, text string) int {
+	matches := re.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	return matches[len(matches)-1][0]
+}
+
+// numericDate builds the date from the numbers and checks that it exists,
+// e.g. there is no 31.02.
+func numericDate(year, month, day, hour, minute string) (time.Time, bool, bool) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	h, _ := strconv.Atoi(hour)
+	min, _ := strconv.Atoi(minute)
+	date := time.Date(y, time.Month(m), d, h, min, 0, 0, time.UTC)
+	if date.Year() != y || date.Month() != time.Month(m) || date.Day() != d {
+		return time.Time{}, false, false
+	}
+	return date, true, true
+}
+
+// namedDate builds the date with the month written in words. The year
+// closest to now is taken if it is missing.
+func namedDate(day, month, year, hour, minute string, now time.Time) (time.Time, bool, bool) {
+	number, ok := monthNumbers[string([]rune(month)[:3])]
+	if !ok {
+		return time.Time{}, false, false
+	}
+	m := strconv.Itoa(int(number))
+	if year != "" {
+		return numericDate(fullYear(year, now), m, day, hour, minute)
+	}
+	var (
+		best    time.Time
+		found   bool
+		closest time.Duration
+	)
+	for y := now.Year() - 1; y <= now.Year()+1; y++ {
+		date, ok, _ := numericDate(strconv.Itoa(y), m, day, hour, minute)
+		if !ok {
+			continue
+		}
+		distance := date.Sub(now)
+		if distance < 0 {
+			distance = -distance
+		}
+		if !found || distance < closest {
+			best, found, closest = date, true, distance
+		}
+	}
+	return best, found, false
+}
+
+// monthEnd returns the last day of the month as the products are good
+// through the whole month.
+func monthEnd(g []string, now time.Time) (time.Time, bool, bool) {
+	year, _ := strconv.Atoi(fullYear(g[2], now))
+	month, _ := strconv.Atoi(g[1])
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC), true, false
+}
+
+// fullYear adds the century of now to a two digit year.
+func fullYear(year string, now time.Time) string {
+	if len(year) == 2 {
+		return strconv.Itoa(now.Year()/100) + year
+	}
+	return year
+}
+
+// isBoundary reports whether the match is not a part of a longer word or
+// number.
+func isBoundary(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUsed(used []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if used[i] {
+			return true
+		}
+	}
+	return false
+}
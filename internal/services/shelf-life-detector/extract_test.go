@@ -0,0 +1,123 @@
+package shelflifedetector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExtractDates(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	testCases := []struct {
+		name     string
+		text     string
+		expected []params.DetectedDate
+	}{
+		{
+			name: "russian keywords and month name",
+			text: "Изготовлено: 12 МАР 2024\nГоден до: 12.04.2024",
+			expected: []params.DetectedDate{
+				{Date: date(2024, 3, 12, 0, 0), Kind: DateManufactured, Confidence: 0.9, Text: "12 мар 2024"},
+				{Date: date(2024, 4, 12, 0, 0), Kind: DateExpiry, Confidence: 0.9, Text: "12.04.2024"},
+			},
+		},
+		{
+			name: "english month name without year",
+			text: "BEST BEFORE Mar 12 LOT 123",
+			expected: []params.DetectedDate{
+				{Date: date(2024, 3, 12, 0, 0), Kind: DateExpiry, Confidence: 0.64, Text: "mar 12"},
+			},
+		},
+		{
+			name: "iso and time suffix",
+			text: "mfg 01/02/2024 exp 2024-08-01T10:00",
+			expected: []params.DetectedDate{
+				{Date: date(2024, 2, 1, 0, 0), Kind: DateManufactured, Confidence: 0.9, Text: "01/02/2024"},
+				{Date: date(2024, 8, 1, 10, 0), Kind: DateExpiry, Confidence: 0.95, Text: "2024-08-01t10:00"},
+			},
+		},
+		{
+			name: "month and year",
+			text: "exp 03/25",
+			expected: []params.DetectedDate{
+				{Date: date(2025, 3, 31, 0, 0), Kind: DateExpiry, Confidence: 0.56, Text: "03/25"},
+			},
+		},
+		{
+			name: "price is not a date",
+			text: "Цена 12.99 руб годен до 03.25",
+			expected: []params.DetectedDate{
+				{Date: date(2025, 3, 31, 0, 0), Kind: DateExpiry, Confidence: 0.56, Text: "03.25"},
+			},
+		},
+		{
+			name: "order without keywords",
+			text: "24.09.22 08:00 15.09.22 14:35",
+			expected: []params.DetectedDate{
+				{Date: date(2022, 9, 15, 14, 35), Kind: DateManufactured, Confidence: 0.63, Text: "15.09.22 14:35"},
+				{Date: date(2022, 9, 24, 8, 0), Kind: DateExpiry, Confidence: 0.63, Text: "24.09.22 08:00"},
+			},
+		},
+		{
+			name: "single date without keywords",
+			text: "2024-03-12",
+			expected: []params.DetectedDate{
+				{Date: date(2024, 3, 12, 0, 0), Kind: DateUnknown, Confidence: 0.48, Text: "2024-03-12"},
+			},
+		},
+		{
+			name: "shelf life in days",
+			text: "Дата изготовления 15.09.22 Годен 30 суток",
+			expected: []params.DetectedDate{
+				{Date: date(2022, 9, 15, 0, 0), Kind: DateManufactured, Confidence: 0.9, Text: "15.09.22"},
+				{Date: date(2022, 10, 15, 0, 0), Kind: DateExpiry, Confidence: 0.81, Text: "30 суток", Derived: true},
+			},
+		},
+		{
+			name: "shelf life in months before the date",
+			text: "Хранить 6 месяцев. 31.08.2023",
+			expected: []params.DetectedDate{
+				{Date: date(2023, 8, 31, 0, 0), Kind: DateManufactured, Confidence: 0.63, Text: "31.08.2023"},
+				{Date: date(2024, 2, 29, 0, 0), Kind: DateExpiry, Confidence: 0.57, Text: "6 месяцев", Derived: true},
+			},
+		},
+		{
+			name: "shelf life in years",
+			text: "MFG 2023-05-10 shelf life: 2 years",
+			expected: []params.DetectedDate{
+				{Date: date(2023, 5, 10, 0, 0), Kind: DateManufactured, Confidence: 0.95, Text: "2023-05-10"},
+				{Date: date(2025, 5, 10, 0, 0), Kind: DateExpiry, Confidence: 0.86, Text: "2 years", Derived: true},
+			},
+		},
+		{
+			name: "printed expiry date over shelf life",
+			text: "изг 01.03.2024 годен до 10.03.2024 хранить 3 суток после вскрытия",
+			expected: []params.DetectedDate{
+				{Date: date(2024, 3, 1, 0, 0), Kind: DateManufactured, Confidence: 0.9, Text: "01.03.2024"},
+				{Date: date(2024, 3, 10, 0, 0), Kind: DateExpiry, Confidence: 0.9, Text: "10.03.2024"},
+			},
+		},
+		{
+			name: "shelf life after opening",
+			text: "изг 01.03.2024 use within 3 days after opening",
+			expected: []params.DetectedDate{
+				{Date: date(2024, 3, 1, 0, 0), Kind: DateManufactured, Confidence: 0.9, Text: "01.03.2024"},
+			},
+		},
+		{
+			name:     "nonexistent date",
+			text:     "годен до 31.02.2024",
+			expected: []params.DetectedDate{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractDates(tc.text, now))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package shelflifedetector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	errs "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/detection"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// DetectionHistoryServicer records the detections of the users so that the
+// accuracy of the detector can be evaluated on the corrected ones.
+type DetectionHistoryServicer interface {
+	// Detect detects the dates on the image of the user like
+	// DateDetectorServicer and records the detection, including the one
+	// without dates, in which case errors.ErrNoDatesDetected is returned too.
+	// The recognized words are returned but not recorded.
+	Detect(ctx context.Context, userID int, image []byte) (params.FindDetection, error)
+	FindDetections(
+		ctx context.Context,
+		user *params.TokenPayload,
+		filter *params.DetectionFilter,
+	) ([]params.FindDetection, error)
+	CorrectDetection(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+		payload *params.CorrectDetection,
+	) (params.FindDetection, error)
+	// ExportDataset writes the corrected detections to w as the zip archive of
+	// the dataset directory and returns the number of samples.
+	ExportDataset(ctx context.Context, w io.Writer) (int, error)
+}
+
+type detectionHistoryService struct {
+	detector DateDetectorServicer
+	repo     repository.DetectionRepositorer
+	now      func() time.Time
+}
+
+func NewHistoryService(
+	detector DateDetectorServicer,
+	repo repository.DetectionRepositorer,
+) DetectionHistoryServicer {
+	return &detectionHistoryService{
+		detector: detector,
+		repo:     repo,
+		now:      time.Now,
+	}
+}
+
+// Detect implements DetectionHistoryServicer
+func (svc *detectionHistoryService) Detect(
+	ctx context.Context,
+	userID int,
+	image []byte,
+) (params.FindDetection, error) {
+	detection, detectErr := svc.detector.Detect(ctx, image)
+	if detectErr != nil && !errs.Is(detectErr, errors.ErrNoDatesDetected) {
+		return params.FindDetection{}, detectErr
+	}
+	hash := sha256.Sum256(image)
+	model := models.Detection{
+		UserID: userID,
+		Image: models.DetectionImage{
+			Hash:        hex.EncodeToString(hash[:]),
+			Image:       image,
+			ContentType: http.DetectContentType(image),
+		},
+		Text:      detection.Text,
+		Dates:     utils.DetectedDatesToModels(detection.Dates),
+		LatencyMs: detection.Latency.Milliseconds(),
+	}
+	if err := svc.repo.Create(ctx, &model); err != nil {
+		return params.FindDetection{}, fmt.Errorf("error recording detection: %w", err)
+	}
+	result := utils.DetectionModelToFind(&model)
+	result.Words = detection.Words
+	return result, detectErr
+}
+
+// FindDetections implements DetectionHistoryServicer
+//
+// Non-admins only find their own detections.
+func (svc *detectionHistoryService) FindDetections(
+	ctx context.Context,
+	user *params.TokenPayload,
+	filter *params.DetectionFilter,
+) ([]params.FindDetection, error) {
+	model := utils.DetectionFilterToModel(filter)
+	model.UserID = utils.ViewerID(user)
+	detections, err := svc.repo.FindMany(ctx, model)
+	if err != nil {
+		return nil, fmt.Errorf("error finding detections: %w", err)
+	}
+	return utils.DetectionModelsToFinds(detections), nil
+}
+
+// CorrectDetection implements DetectionHistoryServicer
+//
+// The detections of the other users are not found unless the user is an
+// admin.
+func (svc *detectionHistoryService) CorrectDetection(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+	payload *params.CorrectDetection,
+) (params.FindDetection, error) {
+	detection, err := svc.repo.FindByID(ctx, id)
+	if err != nil {
+		return params.FindDetection{}, fmt.Errorf("error finding detection: %w", err)
+	}
+	if detection.UserID != user.UserID && !user.IsAdmin() {
+		return params.FindDetection{}, fmt.Errorf("detection %d: %w", id, errors.ErrDetectionNotFound)
+	}
+	now := svc.now()
+	if err := svc.repo.Correct(ctx, id, payload.Manufactured, payload.Expiry, now); err != nil {
+		return params.FindDetection{}, fmt.Errorf("error correcting detection: %w", err)
+	}
+	detection.Manufactured, detection.Expiry, detection.CorrectedAt = payload.Manufactured, payload.Expiry, &now
+	return utils.DetectionModelToFind(&detection), nil
+}
+
+// ExportDataset implements DetectionHistoryServicer
+//
+// The samples are named after the hashes of the images.
+func (svc *detectionHistoryService) ExportDataset(ctx context.Context, w io.Writer) (int, error) {
+	detections, err := svc.repo.FindCorrected(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error finding corrected detections: %w", err)
+	}
+	samples := make([]Sample, len(detections))
+	for i, detection := range detections {
+		samples[i] = Sample{
+			Name: detection.Image.Hash + imageExtension(detection.Image.ContentType),
+			Label: Label{
+				Text:         detection.Text,
+				Manufactured: detection.Manufactured,
+				Expiry:       detection.Expiry,
+				DetectedAt:   *detection.CreatedAt,
+			},
+			Image: detection.Image.Image,
+		}
+	}
+	if err := WriteDataset(w, samples); err != nil {
+		return 0, fmt.Errorf("error writing dataset: %w", err)
+	}
+	return len(samples), nil
+}
@@ -0,0 +1,95 @@
+package shelflifedetector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+)
+
+// maxResponseSize limits the responses of the OCR service.
+const maxResponseSize = 10 * 1024 * 1024
+
+type httpEngine struct {
+	client *http.Client
+	url    string
+	token  string
+}
+
+// httpRequest is the body posted to the OCR service. The image is encoded
+// in base64.
+type httpRequest struct {
+	Image     []byte   `json:"image"`
+	Languages []string `json:"languages"`
+}
+
+// httpResponse is the body the OCR service responds with.
+type httpResponse struct {
+	Text  string `json:"text"`
+	Words []struct {
+		Text       string  `json:"text"`
+		Confidence float64 `json:"confidence"`
+		Box        struct {
+			X      int `json:"x"`
+			Y      int `json:"y"`
+			Width  int `json:"width"`
+			Height int `json:"height"`
+		} `json:"box"`
+	} `json:"words"`
+}
+
+// NewHTTPEngine returns an engine which posts the images as JSON to the OCR
+// service at the URL, authorized with the bearer token if it is set.
+func NewHTTPEngine(url, token string) OCREngine {
+	return &httpEngine{
+		client: &http.Client{},
+		url:    url,
+		token:  token,
+	}
+}
+
+// Recognize implements OCREngine
+func (e *httpEngine) Recognize(ctx context.Context, data []byte) (OCRResult, error) {
+	body, err := json.Marshal(httpRequest{Image: data, Languages: []string{"eng", "rus"}})
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("failed to marshal ocr request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("failed to create ocr request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("failed to call ocr service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return OCRResult{}, fmt.Errorf("ocr service responded with status %d", resp.StatusCode)
+	}
+	var response httpResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&response); err != nil {
+		return OCRResult{}, fmt.Errorf("failed to decode ocr response: %w", err)
+	}
+	result := OCRResult{Text: response.Text, Words: make([]Word, 0, len(response.Words))}
+	for _, word := range response.Words {
+		result.Words = append(result.Words, Word{
+			Text:       word.Text,
+			Box:        image.Rect(word.Box.X, word.Box.Y, word.Box.X+word.Box.Width, word.Box.Y+word.Box.Height),
+			Confidence: word.Confidence,
+		})
+	}
+	return result, nil
+}
+
+// Close implements OCREngine
+func (e *httpEngine) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,301 @@
+package shelflifedetector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	errs "errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/safehttp"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/detection-job"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+const (
+	// pollInterval is how long an idle worker waits before it looks for
+	// jobs again.
+	pollInterval = time.Second
+	// cleanupInterval is how often the finished jobs past the retention are
+	// deleted.
+	cleanupInterval = time.Hour
+	// maxBackoff limits the delay before a job is retried.
+	maxBackoff = time.Hour
+	// callbackTimeout limits the requests to the callback URLs.
+	callbackTimeout = 10 * time.Second
+)
+
+type DetectionJobServicer interface {
+	CreateJob(
+		ctx context.Context,
+		user *params.TokenPayload,
+		image []byte,
+		payload *params.CreateDetectionJob,
+	) (params.FindDetectionJob, error)
+	FindJob(ctx context.Context, user *params.TokenPayload, id int) (params.FindDetectionJob, error)
+}
+
+type detectionJobService struct {
+	repo repository.DetectionJobRepositorer
+}
+
+func NewJobService(repo repository.DetectionJobRepositorer) DetectionJobServicer {
+	return &detectionJobService{repo: repo}
+}
+
+// CreateJob implements DetectionJobServicer
+//
+// The image is checked before it is queued so that the job only fails if the
+// recognition does. The callback URL must be an https one not pointing to an
+// internal address, errors.ErrUnsafeURL is returned otherwise.
+func (svc *detectionJobService) CreateJob(
+	ctx context.Context,
+	user *params.TokenPayload,
+	image []byte,
+	payload *params.CreateDetectionJob,
+) (params.FindDetectionJob, error) {
+	if err := ValidateImage(image); err != nil {
+		return params.FindDetectionJob{}, err
+	}
+	if payload.CallbackURL != "" {
+		if err := safehttp.ValidateURL(payload.CallbackURL); err != nil {
+			return params.FindDetectionJob{}, err
+		}
+	}
+	job := models.DetectionJob{
+		UserID:      user.UserID,
+		Image:       image,
+		CallbackURL: payload.CallbackURL,
+	}
+	if err := svc.repo.Create(ctx, &job); err != nil {
+		return params.FindDetectionJob{}, fmt.Errorf("error creating detection job: %w", err)
+	}
+	return utils.DetectionJobModelToFind(&job), nil
+}
+
+// FindJob implements DetectionJobServicer
+//
+// The jobs of the other users are not found unless the user is an admin.
+func (svc *detectionJobService) FindJob(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) (params.FindDetectionJob, error) {
+	job, err := svc.repo.FindByID(ctx, id)
+	if err != nil {
+		return params.FindDetectionJob{}, fmt.Errorf("error finding detection job: %w", err)
+	}
+	if job.UserID != user.UserID && !user.IsAdmin() {
+		return params.FindDetectionJob{}, fmt.Errorf("detection job %d: %w", id, errors.ErrJobNotFound)
+	}
+	return utils.DetectionJobModelToFind(&job), nil
+}
+
+// JobWorker runs the detection jobs in background.
+type JobWorker interface {
+	// Process delivers one of the pending callbacks, or runs one of the
+	// pending jobs if there is none, and reports whether there was one.
+	Process(ctx context.Context) (bool, error)
+	// Run processes the jobs with the number of workers and deletes the
+	// finished ones past the retention until ctx is done.
+	Run(ctx context.Context, workers int)
+}
+
+type jobWorker struct {
+	repo        repository.DetectionJobRepositorer
+	detector    DetectionHistoryServicer
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	lease       time.Duration
+	retention   time.Duration
+	log         *zerolog.Logger
+	now         func() time.Time
+}
+
+// NewJobWorker creates the worker which tries each job up to maxAttempts
+// times, doubling the backoff after each failure. A job is taken over by
+// another worker if it is not finished within the lease, e.g. when its
+// worker stops. The callbacks of the finished jobs are retried the same way.
+func NewJobWorker(
+	repo repository.DetectionJobRepositorer,
+	detector DetectionHistoryServicer,
+	maxAttempts int,
+	backoff, lease, retention time.Duration,
+	log *zerolog.Logger,
+) JobWorker {
+	return &jobWorker{
+		repo:        repo,
+		detector:    detector,
+		client:      safehttp.NewClient(callbackTimeout),
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		lease:       lease,
+		retention:   retention,
+		log:         log,
+		now:         time.Now,
+	}
+}
+
+// Process implements JobWorker
+func (w *jobWorker) Process(ctx context.Context) (bool, error) {
+	now := w.now()
+	job, ok, err := w.repo.ClaimCallback(ctx, now, now.Add(w.lease))
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return true, w.deliver(ctx, job)
+	}
+	job, ok, err = w.repo.Claim(ctx, now, now.Add(w.lease))
+	if err != nil || !ok {
+		return false, err
+	}
+	detection, err := w.detector.Detect(ctx, job.UserID, job.Image)
+	job.Image = nil
+	finishedAt := w.now()
+	switch {
+	case err == nil:
+		job.Status, job.Dates = models.JobSucceeded, utils.DetectedDatesToModels(detection.Dates)
+		err = w.repo.Succeed(ctx, job.ID, job.Dates, finishedAt)
+	case errs.Is(err, errors.ErrNoDatesDetected) || errs.Is(err, errors.ErrInvalidImage):
+		job.Status, job.Error = models.JobFailed, err.Error()
+		err = w.repo.Fail(ctx, job.ID, job.Error, finishedAt)
+	case ctx.Err() != nil:
+		// The worker stops, the job is run again from the start.
+		return true, w.repo.Retry(context.WithoutCancel(ctx), job.ID, err.Error(), finishedAt)
+	case job.Attempts >= w.maxAttempts:
+		job.Status, job.Error = models.JobFailed, err.Error()
+		err = w.repo.Fail(ctx, job.ID, job.Error, finishedAt)
+	default:
+		return true, w.repo.Retry(ctx, job.ID, err.Error(), finishedAt.Add(retryDelay(job.Attempts, w.backoff)))
+	}
+	if err != nil {
+		return true, fmt.Errorf("error finishing detection job %d: %w", job.ID, err)
+	}
+	return true, nil
+}
+
+// deliver posts the finished job to its callback URL and records the result.
+// A failed callback is retried after the backoff until the attempts run out.
+func (w *jobWorker) deliver(ctx context.Context, job models.DetectionJob) error {
+	err := w.callback(ctx, job)
+	if err != nil {
+		w.log.Warn().Err(err).Int("job", job.ID).Int("attempt", job.CallbackAttempts).
+			Msg("failed to call back detection job")
+	}
+	now := w.now()
+	switch {
+	case err == nil:
+		err = w.repo.SucceedCallback(ctx, job.ID)
+	case ctx.Err() != nil:
+		// The worker stops, the callback is delivered by the next one.
+		return w.repo.RetryCallback(context.WithoutCancel(ctx), job.ID, err.Error(), now)
+	case job.CallbackAttempts >= w.maxAttempts:
+		err = w.repo.FailCallback(ctx, job.ID, err.Error())
+	default:
+		err = w.repo.RetryCallback(ctx, job.ID, err.Error(), now.Add(retryDelay(job.CallbackAttempts, w.backoff)))
+	}
+	if err != nil {
+		return fmt.Errorf("error finishing detection job %d callback: %w", job.ID, err)
+	}
+	return nil
+}
+
+// Run implements JobWorker
+func (w *jobWorker) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	wg.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		w.cleanup(ctx)
+	}()
+	wg.Wait()
+}
+
+func (w *jobWorker) work(ctx context.Context) {
+	for {
+		processed, err := w.Process(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.log.Error().Err(err).Msg("failed to process detection job")
+		}
+		if processed {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (w *jobWorker) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := w.repo.DeleteFinished(ctx, w.now().Add(-w.retention))
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			w.log.Error().Err(err).Msg("failed to delete detection jobs")
+		} else if deleted > 0 {
+			w.log.Info().Int64("deleted", deleted).Msg("detection jobs deleted")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// callback posts the finished job as JSON to its callback URL.
+func (w *jobWorker) callback(ctx context.Context, job models.DetectionJob) error {
+	body, err := json.Marshal(utils.DetectionJobModelToFind(&job))
+	if err != nil {
+		return fmt.Errorf("failed to marshal detection job: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call callback: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// retryDelay returns the delay before the attempt following the failed one,
+// starting with the backoff and doubling with each attempt up to an hour.
+func retryDelay(attempt int, backoff time.Duration) time.Duration {
+	delay := backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,332 @@
+package shelflifedetector
+
+import (
+	"context"
+	"encoding/json"
+	errs "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/detection-job"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// fakeJobRepository keeps a single job and records how it was finished.
+type fakeJobRepository struct {
+	repository.DetectionJobRepositorer
+	job *models.DetectionJob
+}
+
+func (r *fakeJobRepository) Create(_ context.Context, job *models.DetectionJob) error {
+	job.ID = 1
+	r.job = job
+	return nil
+}
+
+func (r *fakeJobRepository) FindByID(_ context.Context, id int) (models.DetectionJob, error) {
+	if r.job == nil || r.job.ID != id {
+		return models.DetectionJob{}, errors.ErrJobNotFound
+	}
+	return *r.job, nil
+}
+
+func (r *fakeJobRepository) Claim(_ context.Context, now, _ time.Time) (models.DetectionJob, bool, error) {
+	if r.job == nil || r.job.Status != models.JobPending || r.job.RunAt.After(now) {
+		return models.DetectionJob{}, false, nil
+	}
+	r.job.Status = models.JobRunning
+	r.job.Attempts++
+	return *r.job, true, nil
+}
+
+func (r *fakeJobRepository) Succeed(_ context.Context, _ int, dates []models.DetectedDate, now time.Time) error {
+	r.job.Status, r.job.Dates, r.job.FinishedAt = models.JobSucceeded, dates, &now
+	r.scheduleCallback(now)
+	return nil
+}
+
+func (r *fakeJobRepository) Fail(_ context.Context, _ int, message string, now time.Time) error {
+	r.job.Status, r.job.Error, r.job.FinishedAt = models.JobFailed, message, &now
+	r.scheduleCallback(now)
+	return nil
+}
+
+func (r *fakeJobRepository) scheduleCallback(now time.Time) {
+	if r.job.CallbackURL != "" {
+		r.job.CallbackStatus, r.job.CallbackAt = models.CallbackPending, &now
+	}
+}
+
+func (r *fakeJobRepository) ClaimCallback(_ context.Context, now, lockedUntil time.Time) (models.DetectionJob, bool, error) {
+	if r.job == nil || r.job.CallbackStatus != models.CallbackPending || r.job.CallbackAt.After(now) {
+		return models.DetectionJob{}, false, nil
+	}
+	r.job.CallbackAttempts++
+	r.job.CallbackAt = &lockedUntil
+	return *r.job, true, nil
+}
+
+func (r *fakeJobRepository) SucceedCallback(context.Context, int) error {
+	r.job.CallbackStatus, r.job.CallbackError, r.job.CallbackAt = models.CallbackDelivered, "", nil
+	return nil
+}
+
+func (r *fakeJobRepository) FailCallback(_ context.Context, _ int, message string) error {
+	r.job.CallbackStatus, r.job.CallbackError, r.job.CallbackAt = models.CallbackFailed, message, nil
+	return nil
+}
+
+func (r *fakeJobRepository) RetryCallback(_ context.Context, _ int, message string, runAt time.Time) error {
+	r.job.CallbackError, r.job.CallbackAt = message, &runAt
+	return nil
+}
+
+func (r *fakeJobRepository) Retry(_ context.Context, _ int, message string, runAt time.Time) error {
+	r.job.Status, r.job.Error, r.job.RunAt = models.JobPending, message, &runAt
+	return nil
+}
+
+type fakeDetector struct {
+	DetectionHistoryServicer
+	dates []params.DetectedDate
+	err   error
+}
+
+func (d *fakeDetector) Detect(context.Context, int, []byte) (params.FindDetection, error) {
+	return params.FindDetection{Dates: d.dates}, d.err
+}
+
+func Test_Process(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiry := params.DetectedDate{Date: time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC), Kind: DateExpiry}
+	testCases := []struct {
+		name     string
+		attempts int
+		detector *fakeDetector
+		status   string
+		runAt    time.Time
+	}{
+		{
+			name:     "succeeded",
+			detector: &fakeDetector{dates: []params.DetectedDate{expiry}},
+			status:   models.JobSucceeded,
+			runAt:    now,
+		},
+		{
+			name:     "no dates are not retried",
+			detector: &fakeDetector{err: errors.ErrNoDatesDetected},
+			status:   models.JobFailed,
+			runAt:    now,
+		},
+		{
+			name:     "first failure is retried after the backoff",
+			detector: &fakeDetector{err: errors.ErrDetectionTimeout},
+			status:   models.JobPending,
+			runAt:    now.Add(10 * time.Second),
+		},
+		{
+			name:     "second failure doubles the backoff",
+			attempts: 1,
+			detector: &fakeDetector{err: errors.ErrDetectorBusy},
+			status:   models.JobPending,
+			runAt:    now.Add(20 * time.Second),
+		},
+		{
+			name:     "last attempt fails",
+			attempts: 2,
+			detector: &fakeDetector{err: errors.ErrDetectionTimeout},
+			status:   models.JobFailed,
+			runAt:    now,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeJobRepository{job: &models.DetectionJob{
+				ID:       1,
+				Status:   models.JobPending,
+				Attempts: tc.attempts,
+				RunAt:    &now,
+			}}
+			log := zerolog.Nop()
+			worker := NewJobWorker(repo, tc.detector, 3, 10*time.Second, time.Minute, time.Hour, &log).(*jobWorker)
+			worker.now = func() time.Time { return now }
+
+			processed, err := worker.Process(context.Background())
+			assert.Nil(t, err)
+			assert.True(t, processed)
+			assert.Equal(t, tc.status, repo.job.Status)
+			assert.Equal(t, tc.runAt, *repo.job.RunAt)
+			if tc.detector.err != nil {
+				assert.Equal(t, tc.detector.err.Error(), repo.job.Error)
+			} else {
+				assert.Len(t, repo.job.Dates, 1)
+			}
+
+			processed, err = worker.Process(context.Background())
+			assert.Nil(t, err)
+			assert.False(t, processed)
+		})
+	}
+}
+
+// roundTripFunc answers the callbacks without a server, the test servers
+// listen on the loopback refused by the callback client.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_ProcessCallback(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeJobRepository{job: &models.DetectionJob{
+		ID:          1,
+		Status:      models.JobPending,
+		CallbackURL: "https://example.com/jobs",
+		RunAt:       &now,
+	}}
+	log := zerolog.Nop()
+	worker := NewJobWorker(repo, &fakeDetector{err: errors.ErrNoDatesDetected}, 3, 10*time.Second, time.Minute, time.Hour, &log).(*jobWorker)
+	worker.now = func() time.Time { return now }
+	statuses := []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusOK}
+	var received []params.FindDetectionJob
+	worker.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		var job params.FindDetectionJob
+		assert.Nil(t, json.NewDecoder(req.Body).Decode(&job))
+		received = append(received, job)
+		status := statuses[len(received)-1]
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})}
+
+	processed, err := worker.Process(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, models.JobFailed, repo.job.Status)
+	assert.Equal(t, models.CallbackPending, repo.job.CallbackStatus)
+	assert.Empty(t, received)
+
+	// The first failure is retried after the backoff, the second one after
+	// twice the backoff.
+	for i, delay := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		processed, err = worker.Process(context.Background())
+		assert.Nil(t, err)
+		assert.True(t, processed)
+		assert.Len(t, received, i+1)
+		assert.Equal(t, models.CallbackPending, repo.job.CallbackStatus)
+		assert.Equal(t, now.Add(delay), *repo.job.CallbackAt)
+
+		processed, err = worker.Process(context.Background())
+		assert.Nil(t, err)
+		assert.False(t, processed)
+		now = now.Add(delay)
+	}
+
+	processed, err = worker.Process(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, models.CallbackDelivered, repo.job.CallbackStatus)
+	assert.Len(t, received, 3)
+	assert.Equal(t, models.JobFailed, received[2].Status)
+	assert.Equal(t, errors.ErrNoDatesDetected.Error(), received[2].Error)
+
+	processed, err = worker.Process(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, processed)
+}
+
+func Test_ProcessCallbackFailed(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeJobRepository{job: &models.DetectionJob{
+		ID:               1,
+		Status:           models.JobSucceeded,
+		CallbackURL:      "https://example.com/jobs",
+		CallbackStatus:   models.CallbackPending,
+		CallbackAttempts: 2,
+		CallbackAt:       &now,
+	}}
+	log := zerolog.Nop()
+	worker := NewJobWorker(repo, &fakeDetector{}, 3, 10*time.Second, time.Minute, time.Hour, &log).(*jobWorker)
+	worker.now = func() time.Time { return now }
+	worker.client = &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, fmt.Errorf("connection refused")
+	})}
+
+	processed, err := worker.Process(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, models.CallbackFailed, repo.job.CallbackStatus)
+	assert.NotEmpty(t, repo.job.CallbackError)
+
+	processed, err = worker.Process(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, processed)
+}
+
+func Test_FindJob(t *testing.T) {
+	repo := &fakeJobRepository{job: &models.DetectionJob{ID: 1, UserID: 1, Status: models.JobPending}}
+	svc := NewJobService(repo)
+	testCases := []struct {
+		user *params.TokenPayload
+		id   int
+		err  error
+	}{
+		{user: &params.TokenPayload{UserID: 1}, id: 1},
+		{user: &params.TokenPayload{UserID: 2}, id: 1, err: errors.ErrJobNotFound},
+		{user: &params.TokenPayload{UserID: 2, Roles: []string{"admin"}}, id: 1},
+		{user: &params.TokenPayload{UserID: 1}, id: 2, err: errors.ErrJobNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("user %d job %d", tc.user.UserID, tc.id), func(t *testing.T) {
+			job, err := svc.FindJob(context.Background(), tc.user, tc.id)
+			if tc.err != nil {
+				assert.True(t, errs.Is(err, tc.err), err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.id, job.ID)
+		})
+	}
+}
+
+func Test_CreateJobCallback(t *testing.T) {
+	testCases := []struct {
+		url string
+		err error
+	}{
+		{url: ""},
+		{url: "https://example.com/jobs"},
+		{url: "http://example.com/jobs", err: errors.ErrUnsafeURL},
+		{url: "https://169.254.169.254/latest/meta-data", err: errors.ErrUnsafeURL},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			repo := &fakeJobRepository{}
+			payload := &params.CreateDetectionJob{CallbackURL: tc.url}
+			_, err := NewJobService(repo).CreateJob(context.Background(), &params.TokenPayload{UserID: 1}, encodeTestPNG(t, 1, 1), payload)
+			if tc.err != nil {
+				assert.True(t, errs.Is(err, tc.err), err)
+				assert.Nil(t, repo.job)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.url, repo.job.CallbackURL)
+		})
+	}
+}
+
+func Test_RetryDelay(t *testing.T) {
+	backoff := 10 * time.Second
+	assert.Equal(t, 10*time.Second, retryDelay(1, backoff))
+	assert.Equal(t, 20*time.Second, retryDelay(2, backoff))
+	assert.Equal(t, 40*time.Second, retryDelay(3, backoff))
+	assert.Equal(t, maxBackoff, retryDelay(100, backoff))
+}
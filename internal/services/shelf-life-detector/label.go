@@ -0,0 +1,305 @@
+package shelflifedetector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+)
+
+// similarProduct is the least similarity of the name of a product to the
+// text for the product to be suggested.
+const similarProduct = 0.8
+
+var (
+	reNetWeight  = regexp.MustCompile(`(\d+(?:[.,]\d+)?) ?(кг|гр|г|мл|л|шт|kg|g|ml|l)\.?`)
+	reNetKeyword = keywords("нетто", "масса", "вес", "объем", "объём", "net", "net wt")
+	// rePerAmount matches the amount the nutrition facts are given per,
+	// e.g. "в 100 г".
+	rePerAmount = regexp.MustCompile(`(?:^|[^\p{L}])(?:в|на|per) $`)
+	// reEAN matches the digits printed under the barcodes, grouped as they
+	// are printed or not.
+	reEAN = regexp.MustCompile(`\d{13}|\d \d{6} \d{6}|\d{8}|\d{4} \d{4}`)
+
+	// netUnits are the measures written on the labels by their names.
+	netUnits = map[string]models.Measure{
+		"кг": {Name: "кг", Dimension: models.DimensionMass, Factor: 1000},
+		"kg": {Name: "кг", Dimension: models.DimensionMass, Factor: 1000},
+		"г":  {Name: "г", Dimension: models.DimensionMass, Factor: 1},
+		"гр": {Name: "г", Dimension: models.DimensionMass, Factor: 1},
+		"g":  {Name: "г", Dimension: models.DimensionMass, Factor: 1},
+		"мл": {Name: "мл", Dimension: models.DimensionVolume, Factor: 1},
+		"ml": {Name: "мл", Dimension: models.DimensionVolume, Factor: 1},
+		"л":  {Name: "л", Dimension: models.DimensionVolume, Factor: 1000},
+		"l":  {Name: "л", Dimension: models.DimensionVolume, Factor: 1000},
+		"шт": {Name: "шт", Dimension: models.DimensionCount, Factor: 1},
+	}
+)
+
+// NetWeight is the quantity of the product in the package written on the
+// label, e.g. "500 г". Unit is one of the names of the common measures.
+type NetWeight struct {
+	Quantity float32
+	Unit     string
+}
+
+// ExtractNetWeight finds the net weight or volume in the text recognized on
+// a label. The quantity after a keyword, e.g. "масса нетто", is taken first,
+// otherwise the largest one, taking millilitres for grams, but the amounts
+// the nutrition facts are given per, e.g. "в 100 г". Years, e.g. "2022 г.",
+// are not taken for grams.
+func ExtractNetWeight(text string) (NetWeight, bool) {
+	text = normalize(text)
+	var (
+		best     NetWeight
+		bestBase float32
+		found    bool
+	)
+	for _, loc := range reNetWeight.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		if !isBoundary(text, start, end) || isDatePart(text, start) {
+			continue
+		}
+		number := text[loc[2]:loc[3]]
+		quantity, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 32)
+		unit := netUnits[text[loc[4]:loc[5]]]
+		if err != nil || quantity <= 0 || unit.Name == "г" && isYear(number) {
+			continue
+		}
+		weight := NetWeight{Quantity: float32(quantity), Unit: unit.Name}
+		from := max(0, start-labelWindow)
+		for from < start && !utf8.RuneStart(text[from]) {
+			from++
+		}
+		if reNetKeyword.MatchString(text[from:start]) {
+			return weight, true
+		}
+		if rePerAmount.MatchString(text[from:start]) {
+			continue
+		}
+		base := weight.Quantity * unit.Factor
+		if unit.Dimension == models.DimensionCount {
+			base = 0
+		}
+		if !found || base > bestBase {
+			best, bestBase, found = weight, base, true
+		}
+	}
+	return best, found
+}
+
+// isDatePart reports whether the number at the start is a part of a date or
+// a time, e.g. "2022" of "15.09.2022".
+func isDatePart(text string, start int) bool {
+	if start == 0 {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:start])
+	return strings.ContainsRune("./-:", r)
+}
+
+func isYear(number string) bool {
+	year, err := strconv.Atoi(number)
+	return err == nil && year >= 1900 && year < 2100
+}
+
+// ExtractBarcodes finds the EAN-13 and EAN-8 codes in the text recognized on
+// a label, skipping the digits whose check digit is wrong.
+func ExtractBarcodes(text string) []string {
+	text = normalize(text)
+	var codes []string
+	for _, loc := range reEAN.FindAllStringIndex(text, -1) {
+		if !isBoundary(text, loc[0], loc[1]) {
+			continue
+		}
+		code := strings.ReplaceAll(text[loc[0]:loc[1]], " ", "")
+		if ValidEAN(code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// ValidEAN reports whether the code is an EAN-13 or EAN-8 code with the
+// right check digit. The digits are weighted 3 and 1 alternately from the
+// one before the check digit.
+func ValidEAN(code string) bool {
+	if len(code) != 13 && len(code) != 8 {
+		return false
+	}
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := int(code[len(code)-1] - '0')
+	return check == (10-sum%10)%10
+}
+
+// MatchProduct returns the product whose name is the most similar to the
+// text recognized on a label and the similarity between 0 and 1. Each word
+// of the name is compared with the most similar word of the text, so that
+// misrecognized letters and other word forms still match. Of the products
+// equally similar the one with the longer name is taken.
+func MatchProduct(text string, products []models.Product) (models.Product, float64, bool) {
+	words := tokenize(text)
+	var (
+		best      models.Product
+		bestScore float64
+		bestWords int
+	)
+	for _, product := range products {
+		name := tokenize(product.Name)
+		if len(name) == 0 {
+			continue
+		}
+		var score float64
+		for _, part := range name {
+			closest := 0.0
+			for _, word := range words {
+				closest = max(closest, similarity(part, word))
+			}
+			score += closest
+		}
+		score /= float64(len(name))
+		if score > bestScore || score == bestScore && len(name) > bestWords {
+			best, bestScore, bestWords = product, score, len(name)
+		}
+	}
+	if bestScore < similarProduct {
+		return models.Product{}, 0, false
+	}
+	return best, bestScore, true
+}
+
+// tokenize splits the text into the lowercase words of two letters or
+// digits or more.
+func tokenize(text string) [][]rune {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := make([][]rune, 0, len(fields))
+	for _, field := range fields {
+		if word := []rune(field); len(word) > 1 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// similarity is one minus the edit distance between the words relative to
+// the longer one.
+func similarity(a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between the words.
+func editDistance(a, b []rune) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			diagonal, row[j] = row[j], min(row[j]+1, row[j-1]+1, diagonal+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+type LabelServicer interface {
+	// Suggest reads the product, its quantity and the barcodes from the text
+	// recognized on a label to prefill the shelf life.
+	Suggest(ctx context.Context, text string) (params.LabelSuggestion, error)
+}
+
+type labelService struct {
+	products product.ProductRepositorer
+	measures measure.MeasureRepositorer
+}
+
+func NewLabelService(products product.ProductRepositorer, measures measure.MeasureRepositorer) LabelServicer {
+	return &labelService{
+		products: products,
+		measures: measures,
+	}
+}
+
+// Suggest implements LabelServicer
+//
+// The net weight is given in the measure of the catalog it reads best in,
+// e.g. 1500 г in kilograms, and is left out if there is no measure of its
+// dimension.
+func (svc *labelService) Suggest(ctx context.Context, text string) (params.LabelSuggestion, error) {
+	suggestion := params.LabelSuggestion{Barcodes: ExtractBarcodes(text)}
+	products, err := svc.products.FindAll(ctx)
+	if err != nil {
+		return params.LabelSuggestion{}, fmt.Errorf("error finding products: %w", err)
+	}
+	if product, score, ok := MatchProduct(text, products); ok {
+		suggestion.ProductID, suggestion.Product = product.ID, product.Name
+		suggestion.Similarity = math.Round(score*100) / 100
+	}
+	weight, ok := ExtractNetWeight(text)
+	if !ok {
+		return suggestion, nil
+	}
+	measures, err := svc.measures.FindAll(ctx)
+	if err != nil {
+		return params.LabelSuggestion{}, fmt.Errorf("error finding measures: %w", err)
+	}
+	var (
+		converter = conversion.NewConverter(measures, nil)
+		unit      = netUnits[weight.Unit]
+		found     *models.Measure
+		quantity  float32
+	)
+	for i := range measures {
+		converted, ok := converter.Convert(0, weight.Quantity, unit, measures[i])
+		if !ok {
+			continue
+		}
+		// The metric measures are preferred to the rest of the dimension,
+		// e.g. a cup, and the one read best is chosen of them.
+		exp := math.Log10(float64(converted / weight.Quantity))
+		metric := math.Abs(exp-math.Round(exp)) < 1e-4
+		if found == nil || metric {
+			found, quantity = &measures[i], converted
+		}
+		if metric {
+			break
+		}
+	}
+	if found != nil {
+		quantity, measure := converter.Readable(quantity, *found)
+		suggestion.MeasureID, suggestion.Quantity = measure.ID, quantity
+	}
+	return suggestion, nil
+}
@@ -0,0 +1,165 @@
+package shelflifedetector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/measure"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/product"
+)
+
+func Test_ExtractNetWeight(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected NetWeight
+		ok       bool
+	}{
+		{
+			name:     "keyword",
+			text:     "Пищевая ценность в 100 г: белки 3,0 г, жиры 3,2 г\nМасса нетто: 500 г",
+			expected: NetWeight{Quantity: 500, Unit: "г"},
+			ok:       true,
+		},
+		{
+			name:     "largest",
+			text:     "МОЛОКО 3,2% белки 2,9 г 0,9 л",
+			expected: NetWeight{Quantity: 0.9, Unit: "л"},
+			ok:       true,
+		},
+		{
+			name:     "english unit",
+			text:     "Net wt 1.5kg",
+			expected: NetWeight{Quantity: 1.5, Unit: "кг"},
+			ok:       true,
+		},
+		{
+			name: "per amount only",
+			text: "Пищевая ценность на 100 г продукта",
+			ok:   false,
+		},
+		{
+			name: "dates and years",
+			text: "Изготовлено 15.09.2022 г. годен до 24.09.22 г",
+			ok:   false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			weight, ok := ExtractNetWeight(tc.text)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, weight)
+		})
+	}
+}
+
+func Test_ExtractBarcodes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{name: "ean-13", text: "4006381333931", expected: []string{"4006381333931"}},
+		{name: "ean-13 grouped", text: "штрихкод 4 006381 333931", expected: []string{"4006381333931"}},
+		{name: "ean-8 grouped", text: "9638 5074 годен до 24.09.22", expected: []string{"96385074"}},
+		{name: "wrong check digit", text: "4006381333932"},
+		{name: "longer number", text: "40063813339310"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExtractBarcodes(tc.text))
+		})
+	}
+}
+
+func Test_MatchProduct(t *testing.T) {
+	products := []models.Product{
+		{ID: 1, Name: "Молоко"},
+		{ID: 2, Name: "Молоко топлёное"},
+		{ID: 3, Name: "Сыр Российский"},
+	}
+	testCases := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{name: "exact", text: "МОЛОКО питьевое пастеризованное", expected: 1},
+		{name: "longer name", text: "Молоко топленое 4%", expected: 2},
+		{name: "misrecognized", text: "Сыр Росснйский 50%", expected: 3},
+		{name: "no match", text: "Кефир 2,5%"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, _, ok := MatchProduct(tc.text, products)
+			assert.Equal(t, tc.expected != 0, ok)
+			assert.Equal(t, tc.expected, result.ID)
+		})
+	}
+}
+
+type fakeProductRepository struct {
+	product.ProductRepositorer
+	products []models.Product
+}
+
+func (r *fakeProductRepository) FindAll(context.Context) ([]models.Product, error) {
+	return r.products, nil
+}
+
+type fakeMeasureRepository struct {
+	measure.MeasureRepositorer
+	measures []models.Measure
+}
+
+func (r *fakeMeasureRepository) FindAll(context.Context) ([]models.Measure, error) {
+	return r.measures, nil
+}
+
+func Test_Suggest(t *testing.T) {
+	products := &fakeProductRepository{products: []models.Product{{ID: 1, Name: "Сыр Российский"}}}
+	measures := &fakeMeasureRepository{measures: []models.Measure{
+		{ID: 1, Name: "стакан", Dimension: models.DimensionVolume, Factor: 250},
+		{ID: 2, Name: "г"},
+		{ID: 3, Name: "кг"},
+		{ID: 4, Name: "мл"},
+	}}
+	testCases := []struct {
+		name     string
+		text     string
+		expected params.LabelSuggestion
+	}{
+		{
+			name: "product, weight and barcode",
+			text: "Сыр Российский 50% масса нетто 1500 г 4006381333931",
+			expected: params.LabelSuggestion{
+				ProductID:  1,
+				Product:    "Сыр Российский",
+				Similarity: 1,
+				MeasureID:  3,
+				Quantity:   1.5,
+				Barcodes:   []string{"4006381333931"},
+			},
+		},
+		{
+			name:     "metric measure",
+			text:     "Вода питьевая 0,5 л",
+			expected: params.LabelSuggestion{MeasureID: 4, Quantity: 500},
+		},
+		{
+			name:     "no measure of dimension",
+			text:     "Яйца 10 шт",
+			expected: params.LabelSuggestion{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			suggestion, err := NewLabelService(products, measures).Suggest(context.Background(), tc.text)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, suggestion)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package shelflifedetector
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+)
+
+// ocrClient is the part of the Tesseract client used by the engine. The
+// clients aren't safe for concurrent use.
+type ocrClient interface {
+	SetImageFromBytes(data []byte) error
+	Text() (string, error)
+	// Words returns the words recognized in the image.
+	Words() ([]ocrBox, error)
+	Close() error
+}
+
+// ocrBox is a word recognized by a client with its position in pixels and
+// the confidence between 0 and 100.
+type ocrBox struct {
+	Word       string
+	Box        image.Rectangle
+	Confidence float64
+}
+
+// closeTimeout limits the time the engine waits for the clients to be
+// released when it is closed. Tesseract can't be interrupted, so a client
+// stuck on an image is left open.
+const closeTimeout = 30 * time.Second
+
+// tesseractEngine recognizes the images with a pool of clients, made by
+// NewTesseractEngine when built with the tesseract tag.
+type tesseractEngine struct {
+	pool *pool
+	// wait limits the time a request waits for a free client.
+	wait time.Duration
+}
+
+// Recognize implements OCREngine
+func (e *tesseractEngine) Recognize(ctx context.Context, image []byte) (OCRResult, error) {
+	client, err := e.pool.acquire(ctx, e.wait)
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("failed to acquire client: %w", err)
+	}
+	type result struct {
+		result OCRResult
+		err    error
+	}
+	done := make(chan result, 1)
+	// Tesseract can't be interrupted, so the client returns to the pool once
+	// it is done even if nobody waits for the text anymore.
+	go func() {
+		defer e.pool.release(client)
+		r, err := recognizeWith(client, image)
+		done <- result{result: r, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.result, r.err
+	case <-ctx.Done():
+		return OCRResult{}, ctx.Err()
+	}
+}
+
+// Close implements OCREngine
+//
+// It waits for the clients busy with the requests for up to closeTimeout.
+func (e *tesseractEngine) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	return e.pool.Close(ctx)
+}
+
+func recognizeWith(client ocrClient, image []byte) (OCRResult, error) {
+	if err := client.SetImageFromBytes(image); err != nil {
+		return OCRResult{}, fmt.Errorf("failed to set image: %w", err)
+	}
+	text, err := client.Text()
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("failed to recognize text: %w", err)
+	}
+	boxes, err := client.Words()
+	if err != nil {
+		return OCRResult{}, fmt.Errorf("failed to get bounding boxes: %w", err)
+	}
+	words := make([]Word, 0, len(boxes))
+	for _, box := range boxes {
+		words = append(words, Word{
+			Text:       box.Word,
+			Box:        box.Box,
+			Confidence: box.Confidence / 100,
+		})
+	}
+	return OCRResult{Text: text, Words: words}, nil
+}
+
+// pool lends each of a fixed number of clients to one request at a time.
+type pool struct {
+	clients chan ocrClient
+}
+
+func newPool(size int, newClient func() ocrClient) *pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &pool{clients: make(chan ocrClient, size)}
+	for i := 0; i < size; i++ {
+		p.clients <- newClient()
+	}
+	return p
+}
+
+// acquire waits for a free client until the wait or the context is over.
+func (p *pool) acquire(ctx context.Context, wait time.Duration) (ocrClient, error) {
+	select {
+	case client := <-p.clients:
+		return client, nil
+	default:
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case client := <-p.clients:
+		return client, nil
+	case <-timer.C:
+		return nil, errors.ErrDetectorBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *pool) release(client ocrClient) {
+	p.clients <- client
+}
+
+// Close closes the clients once they are released and returns the errors of
+// all of them. The clients not released until ctx is done are left open.
+func (p *pool) Close(ctx context.Context) error {
+	var failures []error
+	for i := 0; i < cap(p.clients); i++ {
+		select {
+		case client := <-p.clients:
+			if err := client.Close(); err != nil {
+				failures = append(failures, err)
+			}
+		case <-ctx.Done():
+			failures = append(failures, fmt.Errorf("%d clients not released: %w", cap(p.clients)-i, ctx.Err()))
+			return errs.Join(failures...)
+		}
+	}
+	return errs.Join(failures...)
+}
@@ -0,0 +1,358 @@
+package shelflifedetector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// maxSide is the longest side of the images passed to Tesseract. Larger
+	// photos only slow the recognition down.
+	maxSide = 2000
+	// maxPixels limits the size of the decoded images.
+	maxPixels = 50_000_000
+	// thresholdWindow is the longest side of the image divided by the side
+	// of the neighbourhood each pixel is compared with.
+	thresholdWindow = 16
+	// thresholdPercent is how much darker than its neighbourhood a pixel
+	// has to be to become black.
+	thresholdPercent = 15
+)
+
+// EXIF orientations, the transformations making the image upright.
+const (
+	orientationNormal     = 1
+	orientationFlipH      = 2
+	orientationRotate180  = 3
+	orientationFlipV      = 4
+	orientationTranspose  = 5
+	orientationRotate90   = 6
+	orientationTransverse = 7
+	orientationRotate270  = 8
+)
+
+// rotations are tried in order when the text is recognized, as the photos
+// are mostly upright and the upside down ones are more common than the
+// sideways ones.
+var rotations = []int{
+	orientationNormal,
+	orientationRotate180,
+	orientationRotate90,
+	orientationRotate270,
+}
+
+// Preprocess decodes the JPEG, PNG or WebP image and prepares it for the
+// recognition: turns it upright according to its EXIF orientation, converts
+// it to grayscale, downscales it and binarizes it with an adaptive
+// threshold.
+func Preprocess(data []byte) (*image.Gray, error) {
+	img, _, err := preprocess(data)
+	return img, err
+}
+
+// frame tells how the preprocessed image is made from the original one, so
+// that the positions on it can be mapped back.
+type frame struct {
+	// original and scaled are the sizes of the decoded and the downscaled
+	// image.
+	original, scaled image.Point
+	orientation      int
+}
+
+// preprocess prepares the image like Preprocess and returns the frame of the
+// result.
+func preprocess(data []byte) (*image.Gray, frame, error) {
+	if err := ValidateImage(data); err != nil {
+		return nil, frame{}, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, frame{}, fmt.Errorf("%w: %v", errors.ErrInvalidImage, err)
+	}
+	gray := grayscale(img, maxSide)
+	f := frame{
+		original:    img.Bounds().Size(),
+		scaled:      gray.Bounds().Size(),
+		orientation: exifOrientation(data),
+	}
+	return threshold(orient(gray, f.orientation)), f, nil
+}
+
+// toOriginal maps the rectangle on the preprocessed image turned by the
+// rotation back to the original image: turns it back, undoes the EXIF
+// orientation and scales it up.
+func (f frame) toOriginal(r image.Rectangle, rotation int) image.Rectangle {
+	upright := orientSize(f.scaled, f.orientation)
+	r = orientRect(r, inverseOrientation(rotation), orientSize(upright, rotation))
+	r = orientRect(r, inverseOrientation(f.orientation), upright)
+	r = image.Rect(
+		r.Min.X*f.original.X/f.scaled.X,
+		r.Min.Y*f.original.Y/f.scaled.Y,
+		// Rounded up so that the scaled rectangle still covers the text.
+		(r.Max.X*f.original.X+f.scaled.X-1)/f.scaled.X,
+		(r.Max.Y*f.original.Y+f.scaled.Y-1)/f.scaled.Y,
+	)
+	return r.Intersect(image.Rectangle{Max: f.original})
+}
+
+// ValidateImage checks that the image is a JPEG, PNG or WebP image of an
+// acceptable size without decoding it.
+func ValidateImage(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrInvalidImage, err)
+	}
+	if config.Width*config.Height > maxPixels {
+		return fmt.Errorf("%w: image is too large: %dx%d", errors.ErrInvalidImage, config.Width, config.Height)
+	}
+	return nil
+}
+
+// encodePNG encodes the image for Tesseract.
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// grayscale converts the image to grayscale, downscaling it so that its
+// longest side is at most maxSide.
+func grayscale(img image.Image, maxSide int) *image.Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > maxSide {
+		width = max(1, width*maxSide/longest)
+		height = max(1, height*maxSide/longest)
+	}
+	gray := image.NewGray(image.Rect(0, 0, width, height))
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(gray, gray.Bounds(), img, bounds, draw.Src, nil)
+	}
+	return gray
+}
+
+// threshold makes the pixels darker than their neighbourhood black and the
+// rest white. Unlike a global threshold it copes with shadows and uneven
+// lighting. The means of the neighbourhoods come from a summed-area table.
+func threshold(img *image.Gray) *image.Gray {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	stride := width + 1
+	sums := make([]uint64, stride*(height+1))
+	for y := 0; y < height; y++ {
+		var row uint64
+		for x := 0; x < width; x++ {
+			row += uint64(img.Pix[y*img.Stride+x])
+			sums[(y+1)*stride+x+1] = sums[y*stride+x+1] + row
+		}
+	}
+	radius := max(1, max(width, height)/thresholdWindow/2)
+	result := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := max(0, y-radius), min(height, y+radius+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := max(0, x-radius), min(width, x+radius+1)
+			sum := sums[y1*stride+x1] - sums[y0*stride+x1] - sums[y1*stride+x0] + sums[y0*stride+x0]
+			count := uint64((x1 - x0) * (y1 - y0))
+			if uint64(img.Pix[y*img.Stride+x])*count*100 > sum*(100-thresholdPercent) {
+				result.Pix[y*result.Stride+x] = 0xff
+			}
+		}
+	}
+	return result
+}
+
+// orient applies the transformation of the EXIF orientation to the image.
+func orient(img *image.Gray, orientation int) *image.Gray {
+	switch orientation {
+	case orientationFlipH:
+		return remap(img, false, func(x, y, w, h int) (int, int) { return w - 1 - x, y })
+	case orientationRotate180:
+		return remap(img, false, func(x, y, w, h int) (int, int) { return w - 1 - x, h - 1 - y })
+	case orientationFlipV:
+		return remap(img, false, func(x, y, w, h int) (int, int) { return x, h - 1 - y })
+	case orientationTranspose:
+		return remap(img, true, func(x, y, w, h int) (int, int) { return y, x })
+	case orientationRotate90:
+		return remap(img, true, func(x, y, w, h int) (int, int) { return h - 1 - y, x })
+	case orientationTransverse:
+		return remap(img, true, func(x, y, w, h int) (int, int) { return h - 1 - y, w - 1 - x })
+	case orientationRotate270:
+		return remap(img, true, func(x, y, w, h int) (int, int) { return y, w - 1 - x })
+	default:
+		return img
+	}
+}
+
+// orientRect applies the transformation of the EXIF orientation to the
+// rectangle on an image of the size. Unlike orient it maps the edges of the
+// pixels rather than the pixels themselves.
+func orientRect(r image.Rectangle, orientation int, size image.Point) image.Rectangle {
+	w, h := size.X, size.Y
+	to := func(p image.Point) image.Point {
+		switch orientation {
+		case orientationFlipH:
+			return image.Pt(w-p.X, p.Y)
+		case orientationRotate180:
+			return image.Pt(w-p.X, h-p.Y)
+		case orientationFlipV:
+			return image.Pt(p.X, h-p.Y)
+		case orientationTranspose:
+			return image.Pt(p.Y, p.X)
+		case orientationRotate90:
+			return image.Pt(h-p.Y, p.X)
+		case orientationTransverse:
+			return image.Pt(h-p.Y, w-p.X)
+		case orientationRotate270:
+			return image.Pt(p.Y, w-p.X)
+		default:
+			return p
+		}
+	}
+	return image.Rectangle{Min: to(r.Min), Max: to(r.Max)}.Canon()
+}
+
+// orientSize returns the size of the image of the size after the
+// transformation of the EXIF orientation.
+func orientSize(size image.Point, orientation int) image.Point {
+	if orientation >= orientationTranspose {
+		return image.Pt(size.Y, size.X)
+	}
+	return size
+}
+
+// inverseOrientation returns the orientation undoing the transformation of
+// the other one. The rest of the transformations undo themselves.
+func inverseOrientation(orientation int) int {
+	switch orientation {
+	case orientationRotate90:
+		return orientationRotate270
+	case orientationRotate270:
+		return orientationRotate90
+	default:
+		return orientation
+	}
+}
+
+// remap moves each pixel of the image to the position returned by to,
+// swapping the width and the height if the image is turned sideways.
+func remap(img *image.Gray, swap bool, to func(x, y, w, h int) (int, int)) *image.Gray {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	size := image.Rect(0, 0, w, h)
+	if swap {
+		size = image.Rect(0, 0, h, w)
+	}
+	result := image.NewGray(size)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			nx, ny := to(x, y, w, h)
+			result.Pix[ny*result.Stride+nx] = img.Pix[y*img.Stride+x]
+		}
+	}
+	return result
+}
+
+// exifOrientation returns the orientation stored in the EXIF metadata of
+// the JPEG or WebP image, or the normal one if there is none.
+func exifOrientation(data []byte) int {
+	exif := jpegExif(data)
+	if exif == nil {
+		exif = webpExif(data)
+	}
+	return tiffOrientation(exif)
+}
+
+// jpegExif returns the TIFF structure of the APP1 segment.
+func jpegExif(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return nil
+		}
+		marker := data[i+1]
+		// The image data starts with the start of scan segment.
+		if marker == 0xda {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return nil
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xe1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + size
+	}
+	return nil
+}
+
+// webpExif returns the TIFF structure of the EXIF chunk.
+func webpExif(data []byte) []byte {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil
+	}
+	for i := 12; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+size > len(data) {
+			return nil
+		}
+		if string(data[i:i+4]) == "EXIF" {
+			return bytes.TrimPrefix(data[i+8:i+8+size], []byte("Exif\x00\x00"))
+		}
+		// The chunks are padded to an even size.
+		i += 8 + size + size%2
+	}
+	return nil
+}
+
+// tiffOrientation reads the orientation tag of the first image file
+// directory of the TIFF structure.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return orientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return orientationNormal
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return orientationNormal
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + 12*i
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < orientationNormal || orientation > orientationRotate270 {
+			break
+		}
+		return orientation
+	}
+	return orientationNormal
+}
@@ -0,0 +1,146 @@
+package shelflifedetector
+
+import (
+	"bytes"
+	errs "errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Orient(t *testing.T) {
+	src := &image.Gray{
+		Pix:    []uint8{1, 2, 3, 4, 5, 6},
+		Stride: 3,
+		Rect:   image.Rect(0, 0, 3, 2),
+	}
+	testCases := []struct {
+		orientation int
+		width       int
+		pix         []uint8
+	}{
+		{orientation: orientationNormal, width: 3, pix: []uint8{1, 2, 3, 4, 5, 6}},
+		{orientation: orientationFlipH, width: 3, pix: []uint8{3, 2, 1, 6, 5, 4}},
+		{orientation: orientationRotate180, width: 3, pix: []uint8{6, 5, 4, 3, 2, 1}},
+		{orientation: orientationFlipV, width: 3, pix: []uint8{4, 5, 6, 1, 2, 3}},
+		{orientation: orientationTranspose, width: 2, pix: []uint8{1, 4, 2, 5, 3, 6}},
+		{orientation: orientationRotate90, width: 2, pix: []uint8{4, 1, 5, 2, 6, 3}},
+		{orientation: orientationTransverse, width: 2, pix: []uint8{6, 3, 5, 2, 4, 1}},
+		{orientation: orientationRotate270, width: 2, pix: []uint8{3, 6, 2, 5, 1, 4}},
+	}
+	for _, tc := range testCases {
+		result := orient(src, tc.orientation)
+		assert.Equal(t, tc.width, result.Bounds().Dx(), tc.orientation)
+		assert.Equal(t, tc.pix, result.Pix, tc.orientation)
+	}
+}
+
+func Test_Threshold(t *testing.T) {
+	// The background darkens from left to right, so no global threshold
+	// separates it from the line across it.
+	img := image.NewGray(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			value := 220 - 2*x
+			if y == 32 {
+				value -= 60
+			}
+			img.Pix[y*img.Stride+x] = uint8(value)
+		}
+	}
+	result := threshold(img)
+	for x := 0; x < 64; x++ {
+		assert.Equal(t, uint8(0), result.Pix[32*result.Stride+x], x)
+		assert.Equal(t, uint8(0xff), result.Pix[16*result.Stride+x], x)
+		assert.Equal(t, uint8(0xff), result.Pix[48*result.Stride+x], x)
+	}
+}
+
+func Test_Preprocess(t *testing.T) {
+	webp, _ := os.ReadFile("test_data.webp")
+	testCases := []struct {
+		name   string
+		data   []byte
+		width  int
+		height int
+		err    error
+	}{
+		{name: "large png", data: encodeTestPNG(t, 4000, 1000), width: 2000, height: 500},
+		{name: "rotated jpeg", data: encodeTestJPEG(t, 400, 100, orientationRotate90), width: 100, height: 400},
+		{name: "jpeg", data: encodeTestJPEG(t, 400, 100, orientationNormal), width: 400, height: 100},
+		{name: "webp", data: webp, width: -1},
+		{name: "not an image", data: []byte("годен до 01.03.2024"), err: errors.ErrInvalidImage},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			img, err := Preprocess(tc.data)
+			if tc.err != nil {
+				assert.True(t, errs.Is(err, tc.err), err)
+				return
+			}
+			assert.Nil(t, err)
+			if tc.width >= 0 {
+				assert.Equal(t, image.Rect(0, 0, tc.width, tc.height), img.Bounds())
+			}
+			assert.LessOrEqual(t, max(img.Bounds().Dx(), img.Bounds().Dy()), maxSide)
+			for _, value := range img.Pix {
+				if value != 0 && value != 0xff {
+					t.Fatalf("pixel is not binary: %d", value)
+				}
+			}
+		})
+	}
+}
+
+func Test_ToOriginal(t *testing.T) {
+	text := image.Rect(1, 1, 4, 3)
+	src := image.NewGray(image.Rect(0, 0, 8, 5))
+	for y := text.Min.Y; y < text.Max.Y; y++ {
+		for x := text.Min.X; x < text.Max.X; x++ {
+			src.SetGray(x, y, color.Gray{Y: 0xff})
+		}
+	}
+	for orientation := orientationNormal; orientation <= orientationRotate270; orientation++ {
+		f := frame{original: image.Pt(16, 10), scaled: image.Pt(8, 5), orientation: orientation}
+		for _, rotation := range rotations {
+			img := orient(orient(src, orientation), rotation)
+			var box image.Rectangle
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				for x := 0; x < img.Bounds().Dx(); x++ {
+					if img.GrayAt(x, y).Y != 0 {
+						box = box.Union(image.Rect(x, y, x+1, y+1))
+					}
+				}
+			}
+			assert.Equal(t, image.Rect(2, 2, 8, 6), f.toOriginal(box, rotation), orientation, rotation)
+		}
+	}
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+// encodeTestJPEG encodes a JPEG image with the EXIF orientation.
+func encodeTestJPEG(t *testing.T, width, height, orientation int) []byte {
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil))
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, the directory follows
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0, // orientation
+		0, 0, 0, 0, // no next directory
+	}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := append([]byte{0xff, 0xe1, 0, byte(len(segment) + 2)}, segment...)
+	data := buf.Bytes()
+	return append(append(data[:2:2], app1...), data[2:]...)
+}
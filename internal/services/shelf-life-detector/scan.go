@@ -0,0 +1,134 @@
+package shelflifedetector
+
+import (
+	"context"
+	errs "errors"
+	"net/http"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	shelflifesvc "github.com/romankravchuk/muerta/internal/services/shelf-life"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+)
+
+// Reasons why a scanned shelf life is drafted instead of created.
+const (
+	ReasonNoExpiry          = "no expiry date"
+	ReasonUncertainExpiry   = "expiry date is uncertain"
+	ReasonConflictingExpiry = "conflicting expiry dates"
+	ReasonManufacturedLater = "expiry date precedes manufacturing date"
+	ReasonExpired           = "expiry date precedes purchase date"
+)
+
+// conflictMargin is how much less confident another expiry date can be to
+// still conflict with the most confident one.
+const conflictMargin = 0.2
+
+type ScanServicer interface {
+	// ScanShelfLife creates the shelf life from the dates detected on the
+	// image and links the image to it. If the dates are ambiguous nothing is
+	// created and the draft to confirm is returned instead. The end date
+	// confirmed in the payload is taken as it is, even if it has passed.
+	ScanShelfLife(
+		ctx context.Context,
+		user *params.TokenPayload,
+		image []byte,
+		payload *params.ScanShelfLife,
+	) (params.FindShelfLife, *params.ShelfLifeDraft, error)
+}
+
+type scanService struct {
+	detector   DetectionHistoryServicer
+	shelfLives shelflifesvc.ShelfLifeServicer
+	now        func() time.Time
+}
+
+func NewScanService(detector DetectionHistoryServicer, shelfLives shelflifesvc.ShelfLifeServicer) ScanServicer {
+	return &scanService{
+		detector:   detector,
+		shelfLives: shelfLives,
+		now:        time.Now,
+	}
+}
+
+// ScanShelfLife implements ScanServicer
+func (svc *scanService) ScanShelfLife(
+	ctx context.Context,
+	user *params.TokenPayload,
+	image []byte,
+	payload *params.ScanShelfLife,
+) (params.FindShelfLife, *params.ShelfLifeDraft, error) {
+	if err := ValidateImage(image); err != nil {
+		return params.FindShelfLife{}, nil, err
+	}
+	create := &params.CreateShelfLife{
+		ProductID:    payload.ProductID,
+		UserID:       user.UserID,
+		StorageID:    payload.StorageID,
+		MeasureID:    payload.MeasureID,
+		Quantity:     payload.Quantity,
+		PurchaseDate: payload.PurchaseDate,
+		EndDate:      payload.EndDate,
+	}
+	if create.PurchaseDate == nil {
+		today := utils.Day(svc.now())
+		create.PurchaseDate = &today
+	}
+	dates := []params.DetectedDate{}
+	if create.EndDate == nil {
+		detection, err := svc.detector.Detect(ctx, user.UserID, image)
+		if err != nil && !errs.Is(err, errors.ErrNoDatesDetected) {
+			return params.FindShelfLife{}, nil, err
+		}
+		dates = append(dates, detection.Dates...)
+		expiry, reason := pickExpiry(dates)
+		if expiry != nil {
+			create.EndDate = &expiry.Date
+		}
+		if reason == "" && create.EndDate.Before(*create.PurchaseDate) {
+			reason = ReasonExpired
+		}
+		if reason != "" {
+			return params.FindShelfLife{}, &params.ShelfLifeDraft{ShelfLife: *create, Dates: dates, Reason: reason}, nil
+		}
+	}
+	result, err := svc.shelfLives.CreateScannedShelfLife(ctx, user, create, &params.ShelfLifeScan{
+		Image:       image,
+		ContentType: http.DetectContentType(image),
+		Dates:       dates,
+	})
+	if err != nil {
+		return params.FindShelfLife{}, nil, err
+	}
+	return result, nil, nil
+}
+
+// pickExpiry returns the most confident expiry date and the reason why it is
+// ambiguous, if it is. It is ambiguous unless it is confident, is not
+// contradicted by another expiry date nearly as confident and follows the
+// manufacturing dates.
+func pickExpiry(dates []params.DetectedDate) (*params.DetectedDate, string) {
+	var expiry *params.DetectedDate
+	for i := range dates {
+		if dates[i].Kind == DateExpiry && (expiry == nil || dates[i].Confidence > expiry.Confidence) {
+			expiry = &dates[i]
+		}
+	}
+	if expiry == nil {
+		return nil, ReasonNoExpiry
+	}
+	if expiry.Confidence < confidentExpiry {
+		return expiry, ReasonUncertainExpiry
+	}
+	for _, date := range dates {
+		switch {
+		case date.Kind == DateExpiry && !date.Date.Equal(expiry.Date) &&
+			date.Confidence >= expiry.Confidence-conflictMargin:
+			return expiry, ReasonConflictingExpiry
+		case date.Kind == DateManufactured && !expiry.Date.After(date.Date):
+			return expiry, ReasonManufacturedLater
+		}
+	}
+	return expiry, ""
+}
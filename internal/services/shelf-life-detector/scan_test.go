@@ -0,0 +1,170 @@
+package shelflifedetector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	shelflifesvc "github.com/romankravchuk/muerta/internal/services/shelf-life"
+)
+
+func Test_PickExpiry(t *testing.T) {
+	manufactured := params.DetectedDate{Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Kind: DateManufactured, Confidence: 1}
+	expiry := params.DetectedDate{Date: time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC), Kind: DateExpiry, Confidence: 1}
+	testCases := []struct {
+		name   string
+		dates  []params.DetectedDate
+		reason string
+	}{
+		{
+			name:  "manufactured and expiry",
+			dates: []params.DetectedDate{manufactured, expiry},
+		},
+		{
+			name:   "no expiry",
+			dates:  []params.DetectedDate{manufactured},
+			reason: ReasonNoExpiry,
+		},
+		{
+			name: "uncertain expiry",
+			dates: []params.DetectedDate{
+				{Date: expiry.Date, Kind: DateExpiry, Confidence: 0.7},
+			},
+			reason: ReasonUncertainExpiry,
+		},
+		{
+			name: "conflicting expiry",
+			dates: []params.DetectedDate{
+				expiry,
+				{Date: expiry.Date.AddDate(0, 0, 1), Kind: DateExpiry, Confidence: 0.9},
+			},
+			reason: ReasonConflictingExpiry,
+		},
+		{
+			name: "less confident expiry does not conflict",
+			dates: []params.DetectedDate{
+				expiry,
+				{Date: expiry.Date.AddDate(0, 0, 1), Kind: DateExpiry, Confidence: 0.5},
+			},
+		},
+		{
+			name: "manufactured later",
+			dates: []params.DetectedDate{
+				{Date: expiry.Date.AddDate(0, 0, 1), Kind: DateManufactured, Confidence: 1},
+				expiry,
+			},
+			reason: ReasonManufacturedLater,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			picked, reason := pickExpiry(tc.dates)
+			assert.Equal(t, tc.reason, reason)
+			if tc.reason != ReasonNoExpiry {
+				assert.Equal(t, expiry.Date, picked.Date)
+			}
+		})
+	}
+}
+
+// fakeShelfLifeService records the shelf life created with the scan.
+type fakeShelfLifeService struct {
+	shelflifesvc.ShelfLifeServicer
+	created *params.CreateShelfLife
+	scan    *params.ShelfLifeScan
+}
+
+func (s *fakeShelfLifeService) CreateScannedShelfLife(
+	_ context.Context,
+	_ *params.TokenPayload,
+	payload *params.CreateShelfLife,
+	scan *params.ShelfLifeScan,
+) (params.FindShelfLife, error) {
+	s.created, s.scan = payload, scan
+	return params.FindShelfLife{ID: 1, PurchaseDate: payload.PurchaseDate, EndDate: payload.EndDate}, nil
+}
+
+func Test_ScanShelfLife(t *testing.T) {
+	now := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	yesterday := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expiry := time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)
+	image := encodeTestPNG(t, 1, 1)
+	testCases := []struct {
+		name     string
+		detector *fakeDetector
+		endDate  *time.Time
+		reason   string
+	}{
+		{
+			name: "created",
+			detector: &fakeDetector{dates: []params.DetectedDate{
+				{Date: expiry, Kind: DateExpiry, Confidence: 1},
+			}},
+		},
+		{
+			name:     "no dates are drafted",
+			detector: &fakeDetector{err: errors.ErrNoDatesDetected},
+			reason:   ReasonNoExpiry,
+		},
+		{
+			name: "expired is drafted",
+			detector: &fakeDetector{dates: []params.DetectedDate{
+				{Date: today.AddDate(0, 0, -1), Kind: DateExpiry, Confidence: 1},
+			}},
+			reason: ReasonExpired,
+		},
+		{
+			name: "expiring today is created",
+			detector: &fakeDetector{dates: []params.DetectedDate{
+				{Date: today, Kind: DateExpiry, Confidence: 1},
+			}},
+		},
+		{
+			name:     "confirmed is not detected",
+			detector: &fakeDetector{err: errors.ErrDetectorBusy},
+			endDate:  &expiry,
+		},
+		{
+			name:     "confirmed expired is created",
+			detector: &fakeDetector{err: errors.ErrDetectorBusy},
+			endDate:  &yesterday,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shelfLives := &fakeShelfLifeService{}
+			svc := NewScanService(tc.detector, shelfLives).(*scanService)
+			svc.now = func() time.Time { return now }
+
+			result, draft, err := svc.ScanShelfLife(
+				context.Background(),
+				&params.TokenPayload{UserID: 1},
+				image,
+				&params.ScanShelfLife{ProductID: 1, StorageID: 1, MeasureID: 1, Quantity: 1, EndDate: tc.endDate},
+			)
+			assert.Nil(t, err)
+			if tc.reason != "" {
+				if assert.NotNil(t, draft) {
+					assert.Equal(t, tc.reason, draft.Reason)
+					assert.Equal(t, today, *draft.ShelfLife.PurchaseDate)
+				}
+				assert.Nil(t, shelfLives.created)
+				return
+			}
+			assert.Nil(t, draft)
+			assert.Equal(t, 1, result.ID)
+			if tc.endDate != nil {
+				assert.Equal(t, *tc.endDate, *shelfLives.created.EndDate)
+			} else if len(tc.detector.dates) > 0 {
+				assert.Equal(t, tc.detector.dates[0].Date, *shelfLives.created.EndDate)
+			}
+			assert.Equal(t, today, *shelfLives.created.PurchaseDate)
+			assert.Equal(t, "image/png", shelfLives.scan.ContentType)
+		})
+	}
+}
@@ -1,75 +1,127 @@
 package shelflifedetector
 
 import (
+	"context"
+	errs "errors"
 	"fmt"
-	"regexp"
-	"sort"
-	"strconv"
-	"strings"
+	"image"
 	"time"
 
-	"github.com/otiai10/gosseract/v2"
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 )
 
+// confidentExpiry is the confidence of an expiry date that stops trying the
+// other rotations of the image.
+const confidentExpiry = 0.8
+
 type DateDetectorServicer interface {
-	Detect(image []byte) ([]time.Time, error)
+	Detect(ctx context.Context, image []byte) (Detection, error)
+}
+
+// Detection is the result of Detect with the text the dates are extracted
+// from, the words it is made of and how long it took.
+type Detection struct {
+	Text    string
+	Dates   []params.DetectedDate
+	Words   []params.RecognizedWord
+	Latency time.Duration
 }
 
 type DateDetectorService struct {
-	client *gosseract.Client
+	engine OCREngine
+	// timeout limits the recognition of an image.
+	timeout time.Duration
 }
 
-var reDate = regexp.MustCompile(`\b(?:0[1-9]|[1-2][0-9]|3[01])[\.\/\-](?:0[1-9]|1[0-2])[\.\/\-](?:\d{4}|\d{2})\b`)
+// New creates the detector recognizing the text with the engine.
+func New(engine OCREngine, timeout time.Duration) *DateDetectorService {
+	return &DateDetectorService{
+		engine:  engine,
+		timeout: timeout,
+	}
+}
 
-func New(cl chan struct{}) *DateDetectorService {
-	client := gosseract.NewClient()
-	client.SetLanguage("eng", "rus")
-	go func() {
-		<-cl
-		client.Close()
-	}()
-	return &DateDetectorService{client: client}
+// Detect preprocesses the image and recognizes the text on it, trying
+// several rotations and keeping the dates of the best one. The boxes of the
+// dates and the words are in the coordinates of the original image. It
+// returns ErrDetectionTimeout if the recognition takes too long and
+// ErrNoDatesDetected along with the recognized text if there are no dates.
+func (s *DateDetectorService) Detect(ctx context.Context, image []byte) (Detection, error) {
+	return s.DetectAt(ctx, image, time.Now())
 }
 
-func (s *DateDetectorService) Detect(image []byte) ([]time.Time, error) {
-	if err := s.client.SetImageFromBytes(image); err != nil {
-		return nil, fmt.Errorf("failed to set image: %w", err)
+// DetectAt detects the dates like Detect as if it was the time now, which
+// the dates without a year are taken relative to.
+func (s *DateDetectorService) DetectAt(ctx context.Context, image []byte, now time.Time) (Detection, error) {
+	start := time.Now()
+	img, f, err := preprocess(image)
+	if err != nil {
+		return Detection{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	detection, err := recognize(ctx, s.engine, img, f, now)
+	if errs.Is(err, context.DeadlineExceeded) {
+		return Detection{}, fmt.Errorf("%w: %v", errors.ErrDetectionTimeout, err)
 	}
-	text, err := s.client.Text()
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect date: %w", err)
+		return Detection{}, err
 	}
-	cleanText := strings.Replace(text, "\n", " ", -1)
-	matches := reDate.FindAllString(cleanText, -1)
-	if len(matches) == 0 {
-		return nil, fmt.Errorf("no matches found")
+	detection.Latency = time.Since(start)
+	if len(detection.Dates) == 0 {
+		return detection, errors.ErrNoDatesDetected
 	}
-	dates := make([]time.Time, 2)
-	replacer := strings.NewReplacer("/", "-", ".", "-")
-	for i, match := range matches {
-		fixedMatch := replacer.Replace(match)
-		pieces := strings.Split(fixedMatch, "-")
-		if len(pieces) != 3 {
-			continue
+	return detection, nil
+}
+
+// recognize extracts the dates from the text on the image in each of the
+// rotations until an expiry date is found with enough confidence, and
+// returns the detection of the rotation with the highest total confidence.
+func recognize(
+	ctx context.Context,
+	engine OCREngine,
+	img *image.Gray,
+	f frame,
+	now time.Time,
+) (Detection, error) {
+	var (
+		best         OCRResult
+		bestRotation int
+		bestDates    []params.DetectedDate
+		bestScore    float64
+	)
+	for i, rotation := range rotations {
+		data, err := encodePNG(orient(img, rotation))
+		if err != nil {
+			return Detection{}, fmt.Errorf("failed to encode image: %w", err)
+		}
+		result, err := engine.Recognize(ctx, data)
+		if err != nil {
+			return Detection{}, fmt.Errorf("failed to detect date: %w", err)
+		}
+		candidates := extract(result.Text, now)
+		locate(candidates, result, f, rotation)
+		dates := collect(candidates)
+		score, confident := 0.0, false
+		for _, date := range dates {
+			score += date.Confidence
+			confident = confident || date.Kind == DateExpiry && date.Confidence >= confidentExpiry
 		}
-		if len(pieces[2]) == 2 {
-			pieces[2] = strconv.Itoa(time.Now().Year())[:2] + pieces[2]
+		if i == 0 || score > bestScore {
+			best, bestRotation, bestDates, bestScore = result, rotation, dates, score
 		}
-		converted := make([]int, len(pieces))
-		var err error
-		for i, p := range pieces {
-			converted[i], err = strconv.Atoi(p)
-			if err != nil {
-				return nil, err
-			}
+		if confident {
+			break
 		}
-		date := time.Date(converted[2], time.Month(converted[1]), converted[0], 0, 0, 0, 0, time.UTC)
-		dates[i] = date
 	}
-	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
-	return dates, nil
+	return Detection{
+		Text:  best.Text,
+		Dates: bestDates,
+		Words: recognizedWords(best.Words, f, bestRotation),
+	}, nil
 }
 
 func (s *DateDetectorService) Close() error {
-	return s.client.Close()
+	return s.engine.Close()
 }
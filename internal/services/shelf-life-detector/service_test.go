@@ -1,38 +1,202 @@
 package shelflifedetector
 
 import (
+	"bytes"
+	"context"
+	errs "errors"
+	"fmt"
+	"image/png"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_Detect(t *testing.T) {
+	fixture, _ := os.ReadFile("test_data.webp")
 	testCases := []struct {
 		name     string
-		path     string
-		expected []string
+		image    []byte
+		text     string
+		expected map[string]time.Time
+		err      error
 	}{
 		{
-			name: "date start and date end",
-			path: `test_data.webp`,
-			expected: []string{
-				"15.09.22",
-				"24.09.22",
+			name:  "date start and date end",
+			image: fixture,
+			text:  "Дата изготовления: 15.09.22\nГоден до: 24.09.22",
+			expected: map[string]time.Time{
+				DateManufactured: time.Date(2022, 9, 15, 0, 0, 0, 0, time.UTC),
+				DateExpiry:       time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC),
 			},
 		},
+		{
+			name:  "no dates",
+			image: fixture,
+			text:  "Молоко 3,2%",
+			err:   errors.ErrNoDatesDetected,
+		},
+		{
+			name:  "not an image",
+			image: []byte("Годен до: 24.09.22"),
+			err:   errors.ErrInvalidImage,
+		},
 	}
-	cl := make(chan struct{})
-	detector := New(cl)
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			data, _ := os.ReadFile(tc.path)
-			dates, err := detector.Detect(data)
+			detector := New(NewFakeEngine(tc.text), 10*time.Second)
+			defer detector.Close()
+
+			detection, err := detector.Detect(context.Background(), tc.image)
+			if tc.err != nil {
+				assert.True(t, errs.Is(err, tc.err), err)
+				return
+			}
 			assert.Nil(t, err)
-			assert.NotNil(t, dates)
-			assert.NotEmpty(t, dates)
-			assert.Equal(t, tc.expected, dates)
+			assert.Equal(t, tc.text, detection.Text)
+			kinds := make(map[string]time.Time, len(detection.Dates))
+			for _, date := range detection.Dates {
+				kinds[date.Kind] = date.Date
+			}
+			assert.Equal(t, tc.expected, kinds)
 		})
 	}
-	cl <- struct{}{}
+}
+
+func Test_DetectBoxes(t *testing.T) {
+	testCases := []struct {
+		name  string
+		image []byte
+		box   params.Box
+	}{
+		{
+			// Downscaled twice, the fake engine puts the date at 300-380.
+			name:  "downscaled",
+			image: encodeTestPNG(t, 4000, 200),
+			box:   params.Box{X: 600, Y: 0, Width: 160, Height: 40},
+		},
+		{
+			name:  "rotated by exif",
+			image: encodeTestJPEG(t, 200, 4000, orientationRotate90),
+			box:   params.Box{X: 0, Y: 3240, Width: 40, Height: 160},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detector := New(NewFakeEngine("Изготовлено 15.09.22 годен до 24.09.22"), 10*time.Second)
+			defer detector.Close()
+
+			detection, err := detector.Detect(context.Background(), tc.image)
+			assert.Nil(t, err)
+			assert.Len(t, detection.Dates, 2)
+			assert.Equal(t, DateExpiry, detection.Dates[1].Kind)
+			assert.Equal(t, &tc.box, detection.Dates[1].Box)
+			assert.Len(t, detection.Words, 5)
+			assert.Equal(t, "24.09.22", detection.Words[4].Text)
+			assert.Equal(t, tc.box, detection.Words[4].Box)
+		})
+	}
+}
+
+// fakeClient recognizes an expiry date in March with the day equal to the
+// width of the image, optionally waiting to be unblocked. Sharing it between
+// requests is caught by the race detector.
+type fakeClient struct {
+	image    []byte
+	unblock  chan struct{}
+	closeErr error
+}
+
+func (c *fakeClient) SetImageFromBytes(data []byte) error {
+	c.image = data
+	return nil
+}
+
+func (c *fakeClient) Text() (string, error) {
+	if c.unblock != nil {
+		<-c.unblock
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(c.image))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("годен до %02d.03.2024", config.Width), nil
+}
+
+func (c *fakeClient) Words() ([]ocrBox, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) Close() error { return c.closeErr }
+
+func Test_PoolClose(t *testing.T) {
+	first, second := fmt.Errorf("first"), fmt.Errorf("second")
+	clients := []*fakeClient{{closeErr: first}, {}, {closeErr: second}}
+	i := 0
+	p := newPool(len(clients), func() ocrClient {
+		i++
+		return clients[i-1]
+	})
+	err := p.Close(context.Background())
+	assert.True(t, errs.Is(err, first), err)
+	assert.True(t, errs.Is(err, second), err)
+
+	// A client stuck on an image doesn't block the close.
+	p = newPool(2, func() ocrClient { return &fakeClient{} })
+	client, err := p.acquire(context.Background(), time.Second)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = p.Close(ctx)
+	assert.True(t, errs.Is(err, context.DeadlineExceeded), err)
+	p.release(client)
+}
+
+func Test_DetectParallel(t *testing.T) {
+	detector := &DateDetectorService{
+		engine: &tesseractEngine{
+			pool: newPool(2, func() ocrClient { return &fakeClient{} }),
+			wait: 10 * time.Second,
+		},
+		timeout: 10 * time.Second,
+	}
+	var wg sync.WaitGroup
+	for day := 1; day <= 16; day++ {
+		wg.Add(1)
+		go func(day int) {
+			defer wg.Done()
+			detection, err := detector.Detect(context.Background(), encodeTestPNG(t, day, 1))
+			assert.Nil(t, err)
+			if assert.Len(t, detection.Dates, 1) {
+				assert.Equal(t, time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC), detection.Dates[0].Date)
+			}
+		}(day)
+	}
+	wg.Wait()
+	assert.Nil(t, detector.Close())
+}
+
+func Test_DetectSaturated(t *testing.T) {
+	unblock := make(chan struct{})
+	engine := &tesseractEngine{
+		pool: newPool(1, func() ocrClient { return &fakeClient{unblock: unblock} }),
+		wait: 10 * time.Millisecond,
+	}
+	detector := &DateDetectorService{engine: engine, timeout: 50 * time.Millisecond}
+	img := encodeTestPNG(t, 1, 1)
+
+	_, err := detector.Detect(context.Background(), img)
+	assert.True(t, errs.Is(err, errors.ErrDetectionTimeout), err)
+	_, err = detector.Detect(context.Background(), img)
+	assert.True(t, errs.Is(err, errors.ErrDetectorBusy), err)
+
+	close(unblock)
+	engine.wait = time.Second
+	detection, err := detector.Detect(context.Background(), img)
+	assert.Nil(t, err)
+	assert.Len(t, detection.Dates, 1)
 }
@@ -0,0 +1,47 @@
+//go:build tesseract
+
+package shelflifedetector
+
+import (
+	"time"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// tesseractClient adapts the Tesseract client to ocrClient.
+type tesseractClient struct {
+	*gosseract.Client
+}
+
+func newTesseractClient() ocrClient {
+	client := gosseract.NewClient()
+	client.SetLanguage("eng", "rus")
+	return tesseractClient{Client: client}
+}
+
+// Words implements ocrClient
+func (c tesseractClient) Words() ([]ocrBox, error) {
+	boxes, err := c.GetBoundingBoxes(gosseract.RIL_WORD)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]ocrBox, 0, len(boxes))
+	for _, box := range boxes {
+		words = append(words, ocrBox{
+			Word:       box.Word,
+			Box:        box.Box,
+			Confidence: box.Confidence,
+		})
+	}
+	return words, nil
+}
+
+// NewTesseractEngine returns an engine recognizing the images with a pool of
+// size Tesseract clients. It returns ErrDetectorBusy if no client is freed
+// within the wait.
+func NewTesseractEngine(size int, wait time.Duration) (OCREngine, error) {
+	return &tesseractEngine{
+		pool: newPool(size, newTesseractClient),
+		wait: wait,
+	}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !tesseract
+
+package shelflifedetector
+
+import (
+	"fmt"
+	"time"
+)
+
+// NewTesseractEngine returns an error, the binary is built without Tesseract.
+// Build it with -tags tesseract to use the engine.
+func NewTesseractEngine(int, time.Duration) (OCREngine, error) {
+	return nil, fmt.Errorf("%s engine: built without the tesseract tag", EngineTesseract)
+}
@@ -0,0 +1,56 @@
+//go:build tesseract
+
+package shelflifedetector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_DetectTesseract needs Tesseract with the English and Russian data:
+//
+//	go test -tags tesseract ./internal/services/shelf-life-detector/
+func Test_DetectTesseract(t *testing.T) {
+	engine, err := NewTesseractEngine(1, time.Second)
+	assert.Nil(t, err)
+	detector := New(engine, time.Minute)
+	defer detector.Close()
+	data, err := os.ReadFile("test_data.webp")
+	assert.Nil(t, err)
+
+	detection, err := detector.Detect(context.Background(), data)
+	assert.Nil(t, err)
+	kinds := make(map[string]time.Time, len(detection.Dates))
+	for _, date := range detection.Dates {
+		kinds[date.Kind] = date.Date.Truncate(24 * time.Hour)
+	}
+	assert.Equal(t, map[string]time.Time{
+		DateManufactured: time.Date(2022, 9, 15, 0, 0, 0, 0, time.UTC),
+		DateExpiry:       time.Date(2022, 9, 24, 0, 0, 0, 0, time.UTC),
+	}, kinds)
+}
+
+// Test_DatasetTesseract detects the dates on the images of the dataset like
+// Test_Dataset does on the recognized text.
+func Test_DatasetTesseract(t *testing.T) {
+	engine, err := NewTesseractEngine(1, time.Second)
+	assert.Nil(t, err)
+	detector := New(engine, time.Minute)
+	defer detector.Close()
+	samples, err := LoadDataset(filepath.Join("testdata", "dataset"))
+	assert.Nil(t, err)
+	for _, sample := range samples {
+		t.Run(sample.Name, func(t *testing.T) {
+			detection, err := detector.DetectAt(context.Background(), sample.Image, sample.Label.DetectedAt)
+			assert.Nil(t, err)
+			manufactured, expiry := Predict(detection.Dates)
+			assertSameDay(t, sample.Label.Manufactured, manufactured, "manufactured")
+			assertSameDay(t, sample.Label.Expiry, expiry, "expiry")
+		})
+	}
+}
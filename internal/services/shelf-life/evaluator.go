@@ -0,0 +1,168 @@
+package shelflife
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+	statusrepo "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life-status"
+)
+
+// Names of the statuses managed by the evaluator.
+const (
+	StatusFresh        = "Свежий"
+	StatusExpiringSoon = "Скоро истекает"
+	StatusExpired      = "Просрочен"
+)
+
+// ExpiryState is the computed freshness of a shelf life.
+type ExpiryState int
+
+const (
+	StateFresh ExpiryState = iota
+	StateExpiringSoon
+	StateExpired
+)
+
+// StatusName returns the name of the status matching the state.
+func (s ExpiryState) StatusName() string {
+	switch s {
+	case StateExpired:
+		return StatusExpired
+	case StateExpiringSoon:
+		return StatusExpiringSoon
+	default:
+		return StatusFresh
+	}
+}
+
+// EvaluateExpiry computes the expiry state of an item ending at endDate.
+// An item is expired once now reaches its end date and expiring soon when
+// the end date is within threshold from now.
+func EvaluateExpiry(endDate, now time.Time, threshold time.Duration) ExpiryState {
+	if !now.Before(endDate) {
+		return StateExpired
+	}
+	if endDate.Sub(now) <= threshold {
+		return StateExpiringSoon
+	}
+	return StateFresh
+}
+
+type StatusEvaluator interface {
+	Evaluate(ctx context.Context) (params.StatusEvaluation, error)
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type statusEvaluator struct {
+	repo      repository.ShelfLifeRepositorer
+	statuses  statusrepo.ShelfLifeStatusRepositorer
+	threshold time.Duration
+	log       *zerolog.Logger
+}
+
+// Evaluate implements StatusEvaluator
+func (e *statusEvaluator) Evaluate(ctx context.Context) (params.StatusEvaluation, error) {
+	states := []ExpiryState{StateFresh, StateExpiringSoon, StateExpired}
+	statusIDs := make(map[ExpiryState]int, len(states))
+	managedIDs := make([]int, 0, len(states))
+	for _, state := range states {
+		id, err := e.resolveStatus(ctx, state.StatusName())
+		if err != nil {
+			return params.StatusEvaluation{}, err
+		}
+		statusIDs[state] = id
+		managedIDs = append(managedIDs, id)
+	}
+	shelfLives, err := e.repo.FindExpirable(ctx)
+	if err != nil {
+		return params.StatusEvaluation{}, fmt.Errorf("error finding shelf lives: %w", err)
+	}
+	now := time.Now().UTC()
+	result := params.StatusEvaluation{Processed: len(shelfLives)}
+	for _, shelfLife := range shelfLives {
+		state := EvaluateExpiry(*shelfLife.EndDate, now, e.threshold)
+		changed, err := e.repo.ReplaceStatus(ctx, shelfLife.ID, statusIDs[state], managedIDs)
+		if err != nil {
+			return result, fmt.Errorf("error replacing status of shelf life %d: %w", shelfLife.ID, err)
+		}
+		if changed {
+			result.Changed++
+		}
+		switch state {
+		case StateFresh:
+			result.Fresh++
+		case StateExpiringSoon:
+			result.ExpiringSoon++
+		case StateExpired:
+			result.Expired++
+		}
+	}
+	return result, nil
+}
+
+// Run implements StatusEvaluator
+//
+// It evaluates statuses immediately and then once per interval until ctx is done.
+func (e *statusEvaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := e.Evaluate(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			e.log.Error().Err(err).Msg("failed to evaluate shelf life statuses")
+		} else {
+			e.log.Info().
+				Int("processed", result.Processed).
+				Int("changed", result.Changed).
+				Msg("shelf life statuses evaluated")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *statusEvaluator) resolveStatus(ctx context.Context, name string) (int, error) {
+	status, err := e.statuses.FindByName(ctx, name)
+	if err == nil {
+		return status.ID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("error finding status %q: %w", name, err)
+	}
+	if err := e.statuses.Create(ctx, models.ShelfLifeStatus{Name: name}); err != nil {
+		return 0, fmt.Errorf("error creating status %q: %w", name, err)
+	}
+	status, err = e.statuses.FindByName(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("error finding status %q: %w", name, err)
+	}
+	return status.ID, nil
+}
+
+func NewStatusEvaluator(
+	repo repository.ShelfLifeRepositorer,
+	statuses statusrepo.ShelfLifeStatusRepositorer,
+	threshold time.Duration,
+	log *zerolog.Logger,
+) StatusEvaluator {
+	return &statusEvaluator{
+		repo:      repo,
+		statuses:  statuses,
+		threshold: threshold,
+		log:       log,
+	}
+}
@@ -0,0 +1,56 @@
+package shelflife
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EvaluateExpiry(t *testing.T) {
+	now := time.Date(2023, time.September, 15, 12, 0, 0, 0, time.UTC)
+	threshold := time.Hour * 72
+	testCases := []struct {
+		name     string
+		endDate  time.Time
+		expected ExpiryState
+	}{
+		{
+			name:     "far from end date",
+			endDate:  now.Add(time.Hour * 24 * 10),
+			expected: StateFresh,
+		},
+		{
+			name:     "just outside threshold",
+			endDate:  now.Add(threshold + time.Minute),
+			expected: StateFresh,
+		},
+		{
+			name:     "on threshold",
+			endDate:  now.Add(threshold),
+			expected: StateExpiringSoon,
+		},
+		{
+			name:     "within threshold",
+			endDate:  now.Add(time.Hour),
+			expected: StateExpiringSoon,
+		},
+		{
+			name:     "on end date",
+			endDate:  now,
+			expected: StateExpired,
+		},
+		{
+			name:     "past end date",
+			endDate:  now.Add(-time.Hour * 24),
+			expected: StateExpired,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := EvaluateExpiry(tc.endDate, now, threshold)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.expected.StatusName(), actual.StatusName())
+		})
+	}
+}
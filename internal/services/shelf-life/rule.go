@@ -0,0 +1,74 @@
+package shelflife
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+)
+
+// MatchRule returns the most specific rule or nil if there are none. Rules
+// of the product are preferred to the ones of its categories and rules for
+// the storage type to the ones for any storage. The shortest duration wins
+// among equally specific rules, e.g. of several categories.
+func MatchRule(rules []models.ShelfLifeRule) *models.ShelfLifeRule {
+	var match *models.ShelfLifeRule
+	for i := range rules {
+		if match == nil || isMoreSpecific(&rules[i], match) {
+			match = &rules[i]
+		}
+	}
+	return match
+}
+
+func isMoreSpecific(a, b *models.ShelfLifeRule) bool {
+	if ra, rb := specificity(a), specificity(b); ra != rb {
+		return ra > rb
+	}
+	if a.Days != b.Days {
+		return a.Days < b.Days
+	}
+	if a.Source != b.Source {
+		return a.Source < b.Source
+	}
+	return a.SourceID < b.SourceID
+}
+
+func specificity(rule *models.ShelfLifeRule) int {
+	result := 0
+	if rule.Source == models.ShelfLifeRuleProduct {
+		result += 2
+	}
+	if rule.StorageType.ID != 0 {
+		result++
+	}
+	return result
+}
+
+// DefaultEndDate sets the end date of the payload from the purchase date and
+// the most specific rule if the end date is missing. It returns the applied
+// rule or errors.ErrNoDefaultDuration if there is no rule to apply.
+func DefaultEndDate(
+	ctx context.Context,
+	repo repository.ShelfLifeRepositorer,
+	payload *params.CreateShelfLife,
+) (*params.ShelfLifeRule, error) {
+	if payload.EndDate != nil {
+		return nil, nil
+	}
+	rules, err := repo.FindRules(ctx, payload.ProductID, payload.StorageID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding default durations: %w", err)
+	}
+	rule := MatchRule(rules)
+	if rule == nil {
+		return nil, fmt.Errorf("product %d: %w", payload.ProductID, errors.ErrNoDefaultDuration)
+	}
+	endDate := payload.PurchaseDate.AddDate(0, 0, rule.Days)
+	payload.EndDate = &endDate
+	return utils.ShelfLifeRuleModelToFind(rule), nil
+}
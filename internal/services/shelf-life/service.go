@@ -5,30 +5,146 @@ import (
 	"fmt"
 
 	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/services/utils"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
 )
 
+// ShelfLifeServicer manages shelf lives on behalf of the user from the
+// token payload. Non-admins are limited to their own shelf lives and to the
+// ones kept in storages shared with them, otherwise errors.ErrNotOwner is
+// returned.
 type ShelfLifeServicer interface {
-	FindShelfLifeByID(ctx context.Context, id int) (params.FindShelfLife, error)
-	FindShelfLifes(ctx context.Context, filter *params.ShelfLifeFilter) ([]params.FindShelfLife, error)
-	CreateShelfLife(ctx context.Context, payload *params.CreateShelfLife) error
-	UpdateShelfLife(ctx context.Context, id int, payload *params.UpdateShelfLife) error
-	DeleteShelfLife(ctx context.Context, id int) error
-	RestoreShelfLife(ctx context.Context, id int) error
-	FindShelfLifeStatuses(ctx context.Context, id int) ([]params.FindShelfLifeStatus, error)
-	CreateShelfLifeStatus(ctx context.Context, id, status int) (params.FindShelfLifeStatus, error)
-	DeleteShelfLifeStatus(ctx context.Context, id, status int) error
-	Count(ctx context.Context, filter params.ShelfLifeFilter) (int, error)
+	FindShelfLifeByID(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+	) (params.FindShelfLife, error)
+	FindShelfLifes(
+		ctx context.Context,
+		user *params.TokenPayload,
+		filter *params.ShelfLifeFilter,
+	) ([]params.FindShelfLife, error)
+	CreateShelfLife(
+		ctx context.Context,
+		user *params.TokenPayload,
+		payload *params.CreateShelfLife,
+	) (params.FindShelfLife, error)
+	// CreateScannedShelfLife creates the shelf life like CreateShelfLife and
+	// links the scan it was created from to it.
+	CreateScannedShelfLife(
+		ctx context.Context,
+		user *params.TokenPayload,
+		payload *params.CreateShelfLife,
+		scan *params.ShelfLifeScan,
+	) (params.FindShelfLife, error)
+	// FindShelfLifeScan returns the scan the shelf life was created from or
+	// errors.ErrScanNotFound if it was created otherwise.
+	FindShelfLifeScan(ctx context.Context, user *params.TokenPayload, id int) (params.ShelfLifeScan, error)
+	UpdateShelfLife(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+		payload *params.UpdateShelfLife,
+	) error
+	DeleteShelfLife(ctx context.Context, user *params.TokenPayload, id int) error
+	RestoreShelfLife(ctx context.Context, user *params.TokenPayload, id int) error
+	FindShelfLifeStatuses(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+	) ([]params.FindShelfLifeStatus, error)
+	CreateShelfLifeStatus(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id, status int,
+	) (params.FindShelfLifeStatus, error)
+	DeleteShelfLifeStatus(ctx context.Context, user *params.TokenPayload, id, status int) error
+	Count(ctx context.Context, user *params.TokenPayload, filter params.ShelfLifeFilter) (int, error)
+	FindShelfLifeEvents(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+	) ([]params.FindShelfLifeEvent, error)
+	CreateShelfLifeEvent(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+		payload *params.CreateShelfLifeEvent,
+	) (params.ShelfLifeEventResult, error)
+	// FindShelfLifeTips returns the tips of the product, its categories, the
+	// storage and its type applicable to the shelf life ordered by relevance.
+	FindShelfLifeTips(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+	) ([]params.FindShelfLifeTip, error)
 }
 
 type shelfLifeSerivce struct {
 	repo repository.ShelfLifeRepositorer
 }
 
-func (s *shelfLifeSerivce) Count(ctx context.Context, filter params.ShelfLifeFilter) (int, error) {
-	count, err := s.repo.Count(ctx, models.ShelfLifeFilter{})
+// authorize returns errors.ErrNotOwner if the user can not access the shelf life.
+func (s *shelfLifeSerivce) authorize(ctx context.Context, user *params.TokenPayload, id int) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	ok, err := s.repo.IsAccessible(ctx, id, user.UserID)
+	if err != nil {
+		return fmt.Errorf("error checking shelf life access: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("shelf life %d: %w", id, errors.ErrNotOwner)
+	}
+	return nil
+}
+
+// authorizeWrite returns errors.ErrNotOwner if the user can not change the
+// shelf life. Household viewers can only read it.
+func (s *shelfLifeSerivce) authorizeWrite(ctx context.Context, user *params.TokenPayload, id int) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	ok, err := s.repo.IsWritable(ctx, id, user.UserID)
+	if err != nil {
+		return fmt.Errorf("error checking shelf life access: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("shelf life %d: %w", id, errors.ErrNotOwner)
+	}
+	return nil
+}
+
+// authorizeStorage returns errors.ErrNotOwner if the user can not put shelf
+// lives into the storage.
+func (s *shelfLifeSerivce) authorizeStorage(
+	ctx context.Context,
+	user *params.TokenPayload,
+	storageID int,
+) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	ok, err := s.repo.IsStorageWritable(ctx, storageID, user.UserID)
+	if err != nil {
+		return fmt.Errorf("error checking storage access: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("storage %d: %w", storageID, errors.ErrNotOwner)
+	}
+	return nil
+}
+
+func (s *shelfLifeSerivce) Count(
+	ctx context.Context,
+	user *params.TokenPayload,
+	filter params.ShelfLifeFilter,
+) (int, error) {
+	model := utils.ShelfLifeFilterToModel(&filter)
+	model.ViewerID = utils.ViewerID(user)
+	count, err := s.repo.Count(ctx, model)
 	if err != nil {
 		return 0, fmt.Errorf("error counting shelf lives: %w", err)
 	}
@@ -36,7 +152,14 @@ func (s *shelfLifeSerivce) Count(ctx context.Context, filter params.ShelfLifeFil
 }
 
 // CreateShelfLifeStatus implements ShelfLifeServicer
-func (s *shelfLifeSerivce) CreateShelfLifeStatus(ctx context.Context, id, status int) (params.FindShelfLifeStatus, error) {
+func (s *shelfLifeSerivce) CreateShelfLifeStatus(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id, status int,
+) (params.FindShelfLifeStatus, error) {
+	if err := s.authorizeWrite(ctx, user, id); err != nil {
+		return params.FindShelfLifeStatus{}, err
+	}
 	model, err := s.repo.CreateStatus(ctx, id, status)
 	if err != nil {
 		return params.FindShelfLifeStatus{}, err
@@ -45,7 +168,14 @@ func (s *shelfLifeSerivce) CreateShelfLifeStatus(ctx context.Context, id, status
 }
 
 // DeleteShelfLifeStatus implements ShelfLifeServicer
-func (s *shelfLifeSerivce) DeleteShelfLifeStatus(ctx context.Context, id, status int) error {
+func (s *shelfLifeSerivce) DeleteShelfLifeStatus(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id, status int,
+) error {
+	if err := s.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	if err := s.repo.DeleteStatus(ctx, id, status); err != nil {
 		return err
 	}
@@ -53,7 +183,14 @@ func (s *shelfLifeSerivce) DeleteShelfLifeStatus(ctx context.Context, id, status
 }
 
 // FindShelfLifeStatuses implements ShelfLifeServicer
-func (s *shelfLifeSerivce) FindShelfLifeStatuses(ctx context.Context, id int) ([]params.FindShelfLifeStatus, error) {
+func (s *shelfLifeSerivce) FindShelfLifeStatuses(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) ([]params.FindShelfLifeStatus, error) {
+	if err := s.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
 	models, err := s.repo.FindStatuses(ctx, id)
 	if err != nil {
 		return nil, err
@@ -62,16 +199,83 @@ func (s *shelfLifeSerivce) FindShelfLifeStatuses(ctx context.Context, id int) ([
 }
 
 // CreateShelfLife implements ShelfLifeServicer
-func (svc *shelfLifeSerivce) CreateShelfLife(ctx context.Context, payload *params.CreateShelfLife) error {
+//
+// Non-admins always create shelf lives for themselves. The missing end date
+// is derived from the default durations of the product.
+func (svc *shelfLifeSerivce) CreateShelfLife(
+	ctx context.Context,
+	user *params.TokenPayload,
+	payload *params.CreateShelfLife,
+) (params.FindShelfLife, error) {
+	return svc.create(ctx, user, payload, nil)
+}
+
+// CreateScannedShelfLife implements ShelfLifeServicer
+func (svc *shelfLifeSerivce) CreateScannedShelfLife(
+	ctx context.Context,
+	user *params.TokenPayload,
+	payload *params.CreateShelfLife,
+	scan *params.ShelfLifeScan,
+) (params.FindShelfLife, error) {
+	return svc.create(ctx, user, payload, scan)
+}
+
+func (svc *shelfLifeSerivce) create(
+	ctx context.Context,
+	user *params.TokenPayload,
+	payload *params.CreateShelfLife,
+	scan *params.ShelfLifeScan,
+) (params.FindShelfLife, error) {
+	if !user.IsAdmin() {
+		payload.UserID = user.UserID
+	}
+	if err := svc.authorizeStorage(ctx, user, payload.StorageID); err != nil {
+		return params.FindShelfLife{}, err
+	}
+	rule, err := DefaultEndDate(ctx, svc.repo, payload)
+	if err != nil {
+		return params.FindShelfLife{}, err
+	}
 	model := utils.CreateShelfLifeToModel(payload)
-	if err := svc.repo.Create(ctx, model); err != nil {
-		return err
+	if scan == nil {
+		err = svc.repo.Create(ctx, &model)
+	} else {
+		scanModel := utils.ShelfLifeScanToModel(scan)
+		err = svc.repo.CreateScanned(ctx, &model, &scanModel)
 	}
-	return nil
+	if err != nil {
+		return params.FindShelfLife{}, err
+	}
+	model, err = svc.repo.FindByID(ctx, model.ID)
+	if err != nil {
+		return params.FindShelfLife{}, fmt.Errorf("error finding created shelf life: %w", err)
+	}
+	result := utils.ShelfLifeModelToFind(&model)
+	result.EndDateRule = rule
+	return result, nil
+}
+
+// FindShelfLifeScan implements ShelfLifeServicer
+func (svc *shelfLifeSerivce) FindShelfLifeScan(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) (params.ShelfLifeScan, error) {
+	if err := svc.authorize(ctx, user, id); err != nil {
+		return params.ShelfLifeScan{}, err
+	}
+	scan, err := svc.repo.FindScan(ctx, id)
+	if err != nil {
+		return params.ShelfLifeScan{}, fmt.Errorf("error finding shelf life scan: %w", err)
+	}
+	return utils.ShelfLifeScanModelToParams(&scan), nil
 }
 
 // DeleteShelfLife implements ShelfLifeServicer
-func (svc *shelfLifeSerivce) DeleteShelfLife(ctx context.Context, id int) error {
+func (svc *shelfLifeSerivce) DeleteShelfLife(ctx context.Context, user *params.TokenPayload, id int) error {
+	if err := svc.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	if err := svc.repo.Delete(ctx, id); err != nil {
 		return err
 	}
@@ -79,32 +283,106 @@ func (svc *shelfLifeSerivce) DeleteShelfLife(ctx context.Context, id int) error
 }
 
 // FindShelfLifeByID implements ShelfLifeServicer
-func (svc *shelfLifeSerivce) FindShelfLifeByID(ctx context.Context, id int) (params.FindShelfLife, error) {
+func (svc *shelfLifeSerivce) FindShelfLifeByID(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) (params.FindShelfLife, error) {
+	if err := svc.authorize(ctx, user, id); err != nil {
+		return params.FindShelfLife{}, err
+	}
 	model, err := svc.repo.FindByID(ctx, id)
-	result := utils.ShelfLifeModelToFind(&model)
 	if err != nil {
 		return params.FindShelfLife{}, err
 	}
+	events, err := svc.repo.FindEvents(ctx, id)
+	if err != nil {
+		return params.FindShelfLife{}, fmt.Errorf("error finding shelf life events: %w", err)
+	}
+	result := utils.ShelfLifeModelToFind(&model)
+	result.Events = utils.ShelfLifeEventModelsToFinds(events)
 	return result, nil
 }
 
+// FindShelfLifeEvents implements ShelfLifeServicer
+func (svc *shelfLifeSerivce) FindShelfLifeEvents(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) ([]params.FindShelfLifeEvent, error) {
+	if err := svc.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
+	events, err := svc.repo.FindEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shelf life events: %w", err)
+	}
+	return utils.ShelfLifeEventModelsToFinds(events), nil
+}
+
+// CreateShelfLifeEvent implements ShelfLifeServicer
+func (svc *shelfLifeSerivce) CreateShelfLifeEvent(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+	payload *params.CreateShelfLifeEvent,
+) (params.ShelfLifeEventResult, error) {
+	if err := svc.authorizeWrite(ctx, user, id); err != nil {
+		return params.ShelfLifeEventResult{}, err
+	}
+	event := utils.CreateShelfLifeEventToModel(id, payload)
+	remaining, closed, err := svc.repo.CreateEvent(ctx, &event, consume)
+	if err != nil {
+		return params.ShelfLifeEventResult{}, fmt.Errorf("error creating shelf life event: %w", err)
+	}
+	return params.ShelfLifeEventResult{
+		Event:     utils.ShelfLifeEventModelToFind(&event),
+		Remaining: remaining,
+		Closed:    closed,
+	}, nil
+}
+
+// quantityEpsilon absorbs float rounding when an item is used up completely.
+const quantityEpsilon = 1e-6
+
+// consume is the repository.ConsumeFunc decrementing the quantity of the
+// shelf life by the event. The shelf life is closed once nothing remains.
+// Using more than remains is errors.ErrNotEnoughQuantity and the closed
+// shelf lives are errors.ErrShelfLifeClosed.
+func consume(shelfLife models.ShelfLife, event models.ShelfLifeEvent) (float32, bool, error) {
+	if shelfLife.ClosedAt != nil {
+		return 0, false, fmt.Errorf("shelf life %d: %w", shelfLife.ID, errors.ErrShelfLifeClosed)
+	}
+	remaining := shelfLife.Quantity - event.Quantity
+	if remaining < -quantityEpsilon {
+		return 0, false, errors.ErrNotEnoughQuantity
+	}
+	if remaining < quantityEpsilon {
+		return 0, true, nil
+	}
+	return remaining, false, nil
+}
+
 // FindShelfLifes implements ShelfLifeServicer
-func (svc *shelfLifeSerivce) FindShelfLifes(ctx context.Context, filter *params.ShelfLifeFilter) ([]params.FindShelfLife, error) {
-	models, err := svc.repo.FindMany(ctx, models.ShelfLifeFilter{
-		PageFilter: models.PageFilter{
-			Limit:  filter.Limit,
-			Offset: filter.Offset,
-		},
-	})
-	dtos := utils.ShelfLifeModelsToFinds(models)
+func (svc *shelfLifeSerivce) FindShelfLifes(
+	ctx context.Context,
+	user *params.TokenPayload,
+	filter *params.ShelfLifeFilter,
+) ([]params.FindShelfLife, error) {
+	model := utils.ShelfLifeFilterToModel(filter)
+	model.ViewerID = utils.ViewerID(user)
+	models, err := svc.repo.FindMany(ctx, model)
 	if err != nil {
 		return nil, err
 	}
-	return dtos, nil
+	return utils.ShelfLifeModelsToFinds(models), nil
 }
 
 // RestoreShelfLife implements ShelfLifeServicer
-func (svc *shelfLifeSerivce) RestoreShelfLife(ctx context.Context, id int) error {
+func (svc *shelfLifeSerivce) RestoreShelfLife(ctx context.Context, user *params.TokenPayload, id int) error {
+	if err := svc.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	if err := svc.repo.Restore(ctx, id); err != nil {
 		return err
 	}
@@ -114,9 +392,13 @@ func (svc *shelfLifeSerivce) RestoreShelfLife(ctx context.Context, id int) error
 // UpdateShelfLife implements ShelfLifeServicer
 func (svc *shelfLifeSerivce) UpdateShelfLife(
 	ctx context.Context,
+	user *params.TokenPayload,
 	id int,
 	payload *params.UpdateShelfLife,
 ) error {
+	if err := svc.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	model, err := svc.repo.FindByID(ctx, id)
 	if err != nil {
 		return err
@@ -133,7 +415,10 @@ func (svc *shelfLifeSerivce) UpdateShelfLife(
 	if payload.MeasureID != 0 {
 		model.Measure.ID = payload.MeasureID
 	}
-	if payload.StorageID != 0 {
+	if payload.StorageID != 0 && payload.StorageID != model.Storage.ID {
+		if err := svc.authorizeStorage(ctx, user, payload.StorageID); err != nil {
+			return err
+		}
 		model.Storage.ID = payload.StorageID
 	}
 	if payload.ProductID != 0 {
@@ -0,0 +1,370 @@
+package shelflife
+
+import (
+	"context"
+	errs "errors"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps shelf lives in memory. Shelf lives are accessible to
+// their owner and to the users their storage is shared with. Viewers can only
+// read the shelf lives in the storage.
+type fakeRepository struct {
+	repository.ShelfLifeRepositorer
+	shelfLives map[int]models.ShelfLife
+	shared     map[int][]int
+	viewers    map[int][]int
+	rules      []models.ShelfLifeRule
+	filter     models.ShelfLifeFilter
+	created    models.ShelfLife
+	updated    bool
+	deleted    bool
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		shelfLives: map[int]models.ShelfLife{
+			1: {ID: 1, User: models.User{ID: 1}, Storage: models.Vault{ID: 10}},
+			2: {ID: 2, User: models.User{ID: 2}, Storage: models.Vault{ID: 20}},
+		},
+		shared: map[int][]int{
+			10: {1, 3},
+			20: {2},
+		},
+		viewers: map[int][]int{
+			10: {5},
+		},
+	}
+}
+
+func (r *fakeRepository) IsStorageAccessible(ctx context.Context, storageID, userID int) (bool, error) {
+	for _, id := range r.viewers[storageID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return r.IsStorageWritable(ctx, storageID, userID)
+}
+
+func (r *fakeRepository) IsStorageWritable(_ context.Context, storageID, userID int) (bool, error) {
+	for _, id := range r.shared[storageID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepository) IsAccessible(ctx context.Context, id, userID int) (bool, error) {
+	shelfLife, ok := r.shelfLives[id]
+	if !ok {
+		return false, nil
+	}
+	if shelfLife.User.ID == userID {
+		return true, nil
+	}
+	return r.IsStorageAccessible(ctx, shelfLife.Storage.ID, userID)
+}
+
+func (r *fakeRepository) IsWritable(ctx context.Context, id, userID int) (bool, error) {
+	shelfLife, ok := r.shelfLives[id]
+	if !ok {
+		return false, nil
+	}
+	if shelfLife.User.ID == userID {
+		return true, nil
+	}
+	return r.IsStorageWritable(ctx, shelfLife.Storage.ID, userID)
+}
+
+func (r *fakeRepository) FindByID(_ context.Context, id int) (models.ShelfLife, error) {
+	return r.shelfLives[id], nil
+}
+
+func (r *fakeRepository) FindEvents(_ context.Context, _ int) ([]models.ShelfLifeEvent, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) FindMany(
+	_ context.Context,
+	filter models.ShelfLifeFilter,
+) ([]models.ShelfLife, error) {
+	r.filter = filter
+	return nil, nil
+}
+
+func (r *fakeRepository) Update(_ context.Context, _ models.ShelfLife) error {
+	r.updated = true
+	return nil
+}
+
+func (r *fakeRepository) Delete(_ context.Context, _ int) error {
+	r.deleted = true
+	return nil
+}
+
+func (r *fakeRepository) Create(_ context.Context, model *models.ShelfLife) error {
+	r.created = *model
+	return nil
+}
+
+// CreateEvent consumes the shelf life kept in memory like the repository
+// does in its transaction.
+func (r *fakeRepository) CreateEvent(
+	_ context.Context,
+	event *models.ShelfLifeEvent,
+	consume repository.ConsumeFunc,
+) (float32, bool, error) {
+	shelfLife := r.shelfLives[event.ShelfLifeID]
+	remaining, closed, err := consume(shelfLife, *event)
+	if err != nil {
+		return 0, false, err
+	}
+	shelfLife.Quantity = remaining
+	if closed {
+		now := time.Now()
+		shelfLife.ClosedAt = &now
+	}
+	r.shelfLives[event.ShelfLifeID] = shelfLife
+	return remaining, closed, nil
+}
+
+func (r *fakeRepository) FindRules(_ context.Context, _, _ int) ([]models.ShelfLifeRule, error) {
+	return r.rules, nil
+}
+
+var (
+	owner  = &params.TokenPayload{UserID: 1, Roles: []string{"user"}}
+	other  = &params.TokenPayload{UserID: 2, Roles: []string{"user"}}
+	shared = &params.TokenPayload{UserID: 3, Roles: []string{"user"}}
+	admin  = &params.TokenPayload{UserID: 4, Roles: []string{"user", "admin"}}
+	viewer = &params.TokenPayload{UserID: 5, Roles: []string{"user"}}
+)
+
+func Test_ShelfLifeAccess(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     *params.TokenPayload
+		denied   bool
+		readOnly bool
+	}{
+		{name: "owner", user: owner},
+		{name: "shared storage", user: shared},
+		{name: "admin", user: admin},
+		{name: "household viewer", user: viewer, readOnly: true},
+		{name: "other user", user: other, denied: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			svc := New(repo)
+			ctx := context.Background()
+
+			_, err := svc.FindShelfLifeByID(ctx, tc.user, 1)
+			assert.Equal(t, tc.denied, errs.Is(err, errors.ErrNotOwner))
+			err = svc.UpdateShelfLife(ctx, tc.user, 1, &params.UpdateShelfLife{Quantity: 2})
+			assert.Equal(t, tc.denied || tc.readOnly, errs.Is(err, errors.ErrNotOwner))
+			assert.Equal(t, !tc.denied && !tc.readOnly, repo.updated)
+			err = svc.DeleteShelfLife(ctx, tc.user, 1)
+			assert.Equal(t, tc.denied || tc.readOnly, errs.Is(err, errors.ErrNotOwner))
+			assert.Equal(t, !tc.denied && !tc.readOnly, repo.deleted)
+		})
+	}
+}
+
+func Test_CreateShelfLifeInForeignStorage(t *testing.T) {
+	svc := New(newFakeRepository())
+	endDate := time.Now()
+	payload := &params.CreateShelfLife{UserID: 2, StorageID: 20, EndDate: &endDate}
+
+	_, err := svc.CreateShelfLife(context.Background(), owner, payload)
+	assert.True(t, errs.Is(err, errors.ErrNotOwner))
+
+	_, err = svc.CreateShelfLife(context.Background(), other, payload)
+	assert.Nil(t, err)
+}
+
+func Test_MoveShelfLifeToForeignStorage(t *testing.T) {
+	repo := newFakeRepository()
+	svc := New(repo)
+
+	err := svc.UpdateShelfLife(context.Background(), owner, 1, &params.UpdateShelfLife{StorageID: 20})
+	assert.True(t, errs.Is(err, errors.ErrNotOwner))
+	assert.False(t, repo.updated)
+}
+
+func Test_FindShelfLifesScope(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     *params.TokenPayload
+		expected int
+	}{
+		{name: "user sees own and shared", user: owner, expected: 1},
+		{name: "admin sees everything", user: admin, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			svc := New(repo)
+			_, err := svc.FindShelfLifes(context.Background(), tc.user, &params.ShelfLifeFilter{})
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, repo.filter.ViewerID)
+		})
+	}
+}
+
+func Test_CreateShelfLifeEvent(t *testing.T) {
+	testCases := []struct {
+		name      string
+		quantity  float32
+		used      []float32
+		remaining float32
+		closed    bool
+		err       error
+	}{
+		{name: "decrements the quantity", quantity: 3, used: []float32{1}, remaining: 2},
+		{name: "closes once used up", quantity: 3, used: []float32{1, 2}, closed: true},
+		{name: "absorbs rounding", quantity: 0.3, used: []float32{0.1, 0.2}, closed: true},
+		{name: "refuses more than remains", quantity: 3, used: []float32{1, 2.5}, remaining: 2, err: errors.ErrNotEnoughQuantity},
+		{name: "refuses closed", quantity: 1, used: []float32{1, 0.5}, closed: true, err: errors.ErrShelfLifeClosed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			shelfLife := repo.shelfLives[1]
+			shelfLife.Quantity = tc.quantity
+			repo.shelfLives[1] = shelfLife
+			svc := New(repo)
+			var err error
+			for _, used := range tc.used {
+				_, err = svc.CreateShelfLifeEvent(context.Background(), owner, 1, &params.CreateShelfLifeEvent{
+					Type:     models.ShelfLifeEventConsumed,
+					Quantity: used,
+				})
+			}
+			if tc.err != nil {
+				assert.True(t, errs.Is(err, tc.err), err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.InDelta(t, tc.remaining, repo.shelfLives[1].Quantity, 1e-6)
+			assert.Equal(t, tc.closed, repo.shelfLives[1].ClosedAt != nil)
+		})
+	}
+}
+
+func rule(source string, id, storageTypeID, days int) models.ShelfLifeRule {
+	return models.ShelfLifeRule{
+		Source:   source,
+		SourceID: id,
+		DefaultDuration: models.DefaultDuration{
+			StorageType: models.StorageType{ID: storageTypeID},
+			Days:        days,
+		},
+	}
+}
+
+func Test_MatchRule(t *testing.T) {
+	var (
+		product       = rule("product", 1, 0, 7)
+		productFridge = rule("product", 1, 1, 10)
+		dairy         = rule("category", 1, 0, 5)
+		dairyFridge   = rule("category", 1, 1, 14)
+		drinks        = rule("category", 2, 0, 30)
+	)
+	testCases := []struct {
+		name     string
+		rules    []models.ShelfLifeRule
+		expected *models.ShelfLifeRule
+	}{
+		{name: "no rules"},
+		{name: "product for storage type", rules: []models.ShelfLifeRule{dairyFridge, product, productFridge}, expected: &productFridge},
+		{name: "product", rules: []models.ShelfLifeRule{dairyFridge, product, dairy}, expected: &product},
+		{name: "category for storage type", rules: []models.ShelfLifeRule{dairy, dairyFridge}, expected: &dairyFridge},
+		{name: "shortest category", rules: []models.ShelfLifeRule{drinks, dairy}, expected: &dairy},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MatchRule(tc.rules))
+		})
+	}
+}
+
+func Test_CreateShelfLifeWithDefaultEndDate(t *testing.T) {
+	purchaseDate := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	repo := newFakeRepository()
+	svc := New(repo)
+	payload := &params.CreateShelfLife{ProductID: 1, StorageID: 10, PurchaseDate: &purchaseDate}
+
+	_, err := svc.CreateShelfLife(context.Background(), owner, payload)
+	assert.True(t, errs.Is(err, errors.ErrNoDefaultDuration))
+
+	repo.rules = []models.ShelfLifeRule{{
+		Source:          "category",
+		SourceID:        3,
+		Name:            "Молочные продукты",
+		DefaultDuration: models.DefaultDuration{Days: 5},
+	}}
+	result, err := svc.CreateShelfLife(context.Background(), owner, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 5, 6, 0, 0, 0, 0, time.UTC), *repo.created.EndDate)
+	assert.Equal(t, &params.ShelfLifeRule{Source: "category", ID: 3, Name: "Молочные продукты", Days: 5}, result.EndDateRule)
+}
+
+func sourcedTip(source string, id int, rule models.TipRule) models.SourcedTip {
+	return models.SourcedTip{Tip: models.Tip{ID: id, Rule: rule}, Source: source}
+}
+
+func Test_SelectTips(t *testing.T) {
+	var (
+		now     = time.Date(2023, 5, 10, 18, 0, 0, 0, time.UTC)
+		twoDays = 2
+		warm    = float32(8)
+		cold    = float32(0)
+		tips    = []models.SourcedTip{
+			sourcedTip(models.TipSourceStorageType, 1, models.TipRule{}),
+			sourcedTip(models.TipSourceStorage, 2, models.TipRule{}),
+			sourcedTip(models.TipSourceProduct, 1, models.TipRule{}),
+			sourcedTip(models.TipSourceCategory, 3, models.TipRule{}),
+			sourcedTip(models.TipSourceStorageType, 4, models.TipRule{ExpiresWithin: &twoDays}),
+			sourcedTip(models.TipSourceStorage, 5, models.TipRule{TemperatureAbove: &warm}),
+			sourcedTip(models.TipSourceProduct, 6, models.TipRule{TemperatureBelow: &cold}),
+		}
+	)
+	date := func(days int) *time.Time {
+		d := time.Date(2023, 5, 10+days, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	testCases := []struct {
+		name        string
+		endDate     *time.Time
+		temperature float32
+		expected    []int
+	}{
+		{name: "unconditional tips by source", endDate: date(10), temperature: 4, expected: []int{1, 3, 2}},
+		{name: "expires within two days", endDate: date(2), temperature: 4, expected: []int{4, 1, 3, 2}},
+		{name: "expired", endDate: date(-1), temperature: 4, expected: []int{4, 1, 3, 2}},
+		{name: "no end date", temperature: 4, expected: []int{1, 3, 2}},
+		{name: "warm storage", endDate: date(10), temperature: 12, expected: []int{5, 1, 3, 2}},
+		{name: "freezer", endDate: date(1), temperature: -18, expected: []int{6, 4, 1, 3, 2}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			shelfLife := models.ShelfLife{EndDate: tc.endDate, Storage: models.Vault{Temperature: tc.temperature}}
+			result := SelectTips(tips, shelfLife, now)
+			ids := make([]int, len(result))
+			for i, tip := range result {
+				ids[i] = tip.ID
+			}
+			assert.Equal(t, tc.expected, ids)
+			assert.Equal(t, []string{models.TipSourceProduct, models.TipSourceStorageType}, result[len(result)-3].Sources)
+		})
+	}
+}
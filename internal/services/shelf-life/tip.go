@@ -0,0 +1,133 @@
+package shelflife
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// tipSources orders the sources of the tips from the most to the least
+// specific.
+var tipSources = map[string]int{
+	models.TipSourceProduct:     4,
+	models.TipSourceCategory:    3,
+	models.TipSourceStorage:     2,
+	models.TipSourceStorageType: 1,
+}
+
+// FindShelfLifeTips implements ShelfLifeServicer
+func (svc *shelfLifeSerivce) FindShelfLifeTips(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) ([]params.FindShelfLifeTip, error) {
+	if err := svc.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
+	shelfLife, err := svc.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shelf life: %w", err)
+	}
+	tips, err := svc.repo.FindTips(ctx, shelfLife.Product.ID, shelfLife.Storage.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding tips: %w", err)
+	}
+	return SelectTips(tips, shelfLife, time.Now()), nil
+}
+
+// SelectTips returns the tips whose rules hold for the shelf life, each tip
+// once with all of its sources. Conditional tips come first as they concern
+// the current state of the item, then the tips of the most specific source,
+// e.g. the product before the storage type, and the ones attached to more
+// sources.
+func SelectTips(
+	tips []models.SourcedTip,
+	shelfLife models.ShelfLife,
+	now time.Time,
+) []params.FindShelfLifeTip {
+	type selected struct {
+		tip         params.FindShelfLifeTip
+		conditional bool
+		specificity int
+	}
+	var (
+		result = make([]*selected, 0, len(tips))
+		byID   = map[int]*selected{}
+	)
+	for _, tip := range tips {
+		if !matchTip(tip.Rule, shelfLife, now) {
+			continue
+		}
+		s, ok := byID[tip.ID]
+		if !ok {
+			s = &selected{
+				tip: params.FindShelfLifeTip{
+					FindTip: utils.TipModelToFind(&tip.Tip),
+					Sources: []string{},
+				},
+				conditional: !tip.Rule.IsZero(),
+			}
+			byID[tip.ID] = s
+			result = append(result, s)
+		}
+		if !contains(s.tip.Sources, tip.Source) {
+			s.tip.Sources = append(s.tip.Sources, tip.Source)
+		}
+		if specificity := tipSources[tip.Source]; specificity > s.specificity {
+			s.specificity = specificity
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.conditional != b.conditional {
+			return a.conditional
+		}
+		if a.specificity != b.specificity {
+			return a.specificity > b.specificity
+		}
+		if len(a.tip.Sources) != len(b.tip.Sources) {
+			return len(a.tip.Sources) > len(b.tip.Sources)
+		}
+		return a.tip.ID < b.tip.ID
+	})
+	dtos := make([]params.FindShelfLifeTip, len(result))
+	for i, s := range result {
+		sort.Slice(s.tip.Sources, func(i, j int) bool {
+			return tipSources[s.tip.Sources[i]] > tipSources[s.tip.Sources[j]]
+		})
+		dtos[i] = s.tip
+	}
+	return dtos
+}
+
+// matchTip reports whether all of the conditions of the rule hold for the
+// shelf life. Items without an end date never expire within any amount of
+// days, expired ones always do.
+func matchTip(rule models.TipRule, shelfLife models.ShelfLife, now time.Time) bool {
+	if rule.ExpiresWithin != nil {
+		if shelfLife.EndDate == nil || utils.DaysLeft(*shelfLife.EndDate, now) > *rule.ExpiresWithin {
+			return false
+		}
+	}
+	if rule.TemperatureAbove != nil && shelfLife.Storage.Temperature <= *rule.TemperatureAbove {
+		return false
+	}
+	if rule.TemperatureBelow != nil && shelfLife.Storage.Temperature >= *rule.TemperatureBelow {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
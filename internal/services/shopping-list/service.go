@@ -0,0 +1,285 @@
+package shoppinglist
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	shelflifesvc "github.com/romankravchuk/muerta/internal/services/shelf-life"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+// quantityEpsilon absorbs float errors when quantities are compared.
+const quantityEpsilon = 1e-4
+
+// ShoppingListServicer manages the shopping lists of the user. Lists of
+// other users are reported as errors.ErrShoppingListNotFound.
+type ShoppingListServicer interface {
+	FindShoppingLists(ctx context.Context, userID int) ([]params.FindShoppingList, error)
+	FindShoppingList(ctx context.Context, userID, id int) (params.FindShoppingList, error)
+	CreateShoppingList(
+		ctx context.Context,
+		userID int,
+		payload *params.CreateShoppingList,
+	) (params.FindShoppingList, error)
+	DeleteShoppingList(ctx context.Context, userID, id int) error
+	CreateItem(
+		ctx context.Context,
+		userID, id int,
+		payload *params.CreateShoppingListItem,
+	) (params.FindShoppingListItem, error)
+	DeleteItem(ctx context.Context, userID, id, itemID int) error
+	// GenerateItems adds the ingredients of the recipes the user neither
+	// holds in shelf lives nor has on the list yet.
+	GenerateItems(
+		ctx context.Context,
+		userID, id int,
+		payload *params.GenerateShoppingList,
+	) (params.FindShoppingList, error)
+	CheckItem(
+		ctx context.Context,
+		userID, id, itemID int,
+		payload *params.CheckShoppingListItem,
+	) (params.CheckedShoppingListItem, error)
+	UncheckItem(ctx context.Context, userID, id, itemID int) (params.FindShoppingListItem, error)
+}
+
+type shoppingListService struct {
+	repo        repository.ShoppingListRepositorer
+	recipes     recipes.RecipesRepositorer
+	users       user.UserStorage
+	shelfLives  shelflife.ShelfLifeRepositorer
+	conversions conversion.ConversionServicer
+	now         func() time.Time
+}
+
+// FindShoppingLists implements ShoppingListServicer
+func (s *shoppingListService) FindShoppingLists(
+	ctx context.Context,
+	userID int,
+) ([]params.FindShoppingList, error) {
+	lists, err := s.repo.FindMany(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shopping lists: %w", err)
+	}
+	return utils.ShoppingListModelsToFinds(lists), nil
+}
+
+// FindShoppingList implements ShoppingListServicer
+func (s *shoppingListService) FindShoppingList(
+	ctx context.Context,
+	userID, id int,
+) (params.FindShoppingList, error) {
+	list, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding shopping list: %w", err)
+	}
+	list.Items, err = s.repo.FindItems(ctx, userID, id)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding shopping list items: %w", err)
+	}
+	return utils.ShoppingListModelToFind(&list), nil
+}
+
+// CreateShoppingList implements ShoppingListServicer
+func (s *shoppingListService) CreateShoppingList(
+	ctx context.Context,
+	userID int,
+	payload *params.CreateShoppingList,
+) (params.FindShoppingList, error) {
+	list := models.ShoppingList{User: models.User{ID: userID}, Name: payload.Name}
+	if err := s.repo.Create(ctx, &list); err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error creating shopping list: %w", err)
+	}
+	return utils.ShoppingListModelToFind(&list), nil
+}
+
+// DeleteShoppingList implements ShoppingListServicer
+func (s *shoppingListService) DeleteShoppingList(ctx context.Context, userID, id int) error {
+	if err := s.repo.Delete(ctx, userID, id); err != nil {
+		return fmt.Errorf("error deleting shopping list: %w", err)
+	}
+	return nil
+}
+
+// CreateItem implements ShoppingListServicer
+func (s *shoppingListService) CreateItem(
+	ctx context.Context,
+	userID, id int,
+	payload *params.CreateShoppingListItem,
+) (params.FindShoppingListItem, error) {
+	item := utils.CreateShoppingListItemToModel(id, payload)
+	if err := s.repo.CreateItem(ctx, userID, &item); err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error creating shopping list item: %w", err)
+	}
+	return s.findItem(ctx, userID, id, item.ID)
+}
+
+// DeleteItem implements ShoppingListServicer
+func (s *shoppingListService) DeleteItem(ctx context.Context, userID, id, itemID int) error {
+	if err := s.repo.DeleteItem(ctx, userID, id, itemID); err != nil {
+		return fmt.Errorf("error deleting shopping list item: %w", err)
+	}
+	return nil
+}
+
+// GenerateItems implements ShoppingListServicer
+func (s *shoppingListService) GenerateItems(
+	ctx context.Context,
+	userID, id int,
+	payload *params.GenerateShoppingList,
+) (params.FindShoppingList, error) {
+	items, err := s.repo.FindItems(ctx, userID, id)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding shopping list items: %w", err)
+	}
+	var ingredients []models.RecipeIngredient
+	for _, recipeID := range payload.RecipeIDs {
+		result, err := s.recipes.FindIngredients(ctx, recipeID)
+		if err != nil {
+			return params.FindShoppingList{}, fmt.Errorf("error finding ingredients: %w", err)
+		}
+		ingredients = append(ingredients, result...)
+	}
+	shelfLives, err := s.users.FindShelfLives(ctx, userID)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding shelf lives: %w", err)
+	}
+	productIDs := make([]int, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		productIDs = append(productIDs, ingredient.Product.ID)
+	}
+	converter, err := s.conversions.Converter(ctx, productIDs)
+	if err != nil {
+		return params.FindShoppingList{}, fmt.Errorf("error finding conversions: %w", err)
+	}
+	holdings := append(stockHoldings(shelfLives, s.now()), listHoldings(items)...)
+	missing := shortages(ingredients, holdings, converter)
+	if len(missing) > 0 {
+		if err := s.repo.MergeItems(ctx, userID, id, missing); err != nil {
+			return params.FindShoppingList{}, fmt.Errorf("error merging shopping list items: %w", err)
+		}
+	}
+	return s.FindShoppingList(ctx, userID, id)
+}
+
+// CheckItem implements ShoppingListServicer
+//
+// The item becomes a shelf life if the storage is set. The storage must be
+// shared with the user.
+func (s *shoppingListService) CheckItem(
+	ctx context.Context,
+	userID, id, itemID int,
+	payload *params.CheckShoppingListItem,
+) (params.CheckedShoppingListItem, error) {
+	if payload.StorageID == 0 {
+		if err := s.repo.CheckItem(ctx, userID, id, itemID, true); err != nil {
+			return params.CheckedShoppingListItem{}, fmt.Errorf("error checking shopping list item: %w", err)
+		}
+		item, err := s.findItem(ctx, userID, id, itemID)
+		return params.CheckedShoppingListItem{Item: item}, err
+	}
+	item, err := s.repo.FindItem(ctx, userID, id, itemID)
+	if err != nil {
+		return params.CheckedShoppingListItem{}, fmt.Errorf("error finding shopping list item: %w", err)
+	}
+	if item.ShelfLifeID != 0 {
+		return params.CheckedShoppingListItem{}, fmt.Errorf(
+			"item %d: %w",
+			itemID,
+			errors.ErrItemAlreadyStocked,
+		)
+	}
+	ok, err := s.shelfLives.IsStorageWritable(ctx, payload.StorageID, userID)
+	if err != nil {
+		return params.CheckedShoppingListItem{}, fmt.Errorf("error checking storage access: %w", err)
+	}
+	if !ok {
+		return params.CheckedShoppingListItem{}, fmt.Errorf(
+			"storage %d: %w",
+			payload.StorageID,
+			errors.ErrNotOwner,
+		)
+	}
+	create := &params.CreateShelfLife{
+		ProductID:    item.Product.ID,
+		UserID:       userID,
+		StorageID:    payload.StorageID,
+		MeasureID:    item.Measure.ID,
+		Quantity:     item.Quantity,
+		PurchaseDate: payload.PurchaseDate,
+		EndDate:      payload.EndDate,
+	}
+	if create.PurchaseDate == nil {
+		today := utils.Day(s.now())
+		create.PurchaseDate = &today
+	}
+	rule, err := shelflifesvc.DefaultEndDate(ctx, s.shelfLives, create)
+	if err != nil {
+		return params.CheckedShoppingListItem{}, err
+	}
+	shelfLife := utils.CreateShelfLifeToModel(create)
+	if err := s.repo.StockItem(ctx, userID, id, itemID, &shelfLife); err != nil {
+		return params.CheckedShoppingListItem{}, fmt.Errorf("error stocking shopping list item: %w", err)
+	}
+	shelfLife, err = s.shelfLives.FindByID(ctx, shelfLife.ID)
+	if err != nil {
+		return params.CheckedShoppingListItem{}, fmt.Errorf("error finding shelf life: %w", err)
+	}
+	result := params.CheckedShoppingListItem{}
+	result.Item, err = s.findItem(ctx, userID, id, itemID)
+	if err != nil {
+		return params.CheckedShoppingListItem{}, err
+	}
+	created := utils.ShelfLifeModelToFind(&shelfLife)
+	created.EndDateRule = rule
+	result.ShelfLife = &created
+	return result, nil
+}
+
+// UncheckItem implements ShoppingListServicer
+func (s *shoppingListService) UncheckItem(
+	ctx context.Context,
+	userID, id, itemID int,
+) (params.FindShoppingListItem, error) {
+	if err := s.repo.CheckItem(ctx, userID, id, itemID, false); err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error unchecking shopping list item: %w", err)
+	}
+	return s.findItem(ctx, userID, id, itemID)
+}
+
+func (s *shoppingListService) findItem(
+	ctx context.Context,
+	userID, id, itemID int,
+) (params.FindShoppingListItem, error) {
+	item, err := s.repo.FindItem(ctx, userID, id, itemID)
+	if err != nil {
+		return params.FindShoppingListItem{}, fmt.Errorf("error finding shopping list item: %w", err)
+	}
+	return utils.ShoppingListItemModelToFind(&item), nil
+}
+
+func New(
+	repo repository.ShoppingListRepositorer,
+	recipes recipes.RecipesRepositorer,
+	users user.UserStorage,
+	shelfLives shelflife.ShelfLifeRepositorer,
+	conversions conversion.ConversionServicer,
+) ShoppingListServicer {
+	return &shoppingListService{
+		repo:        repo,
+		recipes:     recipes,
+		users:       users,
+		shelfLives:  shelfLives,
+		conversions: conversions,
+		now:         time.Now,
+	}
+}
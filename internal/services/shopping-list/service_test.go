@@ -0,0 +1,174 @@
+package shoppinglist
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/shopping-list"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	repository.ShoppingListRepositorer
+	items  []models.ShoppingListItem
+	merged []models.ShoppingListItem
+}
+
+func (r *fakeRepository) FindItems(_ context.Context, _, _ int) ([]models.ShoppingListItem, error) {
+	return r.items, nil
+}
+
+func (r *fakeRepository) MergeItems(_ context.Context, _, _ int, items []models.ShoppingListItem) error {
+	r.merged = append(r.merged, items...)
+	return nil
+}
+
+func (r *fakeRepository) FindByID(_ context.Context, userID, id int) (models.ShoppingList, error) {
+	return models.ShoppingList{ID: id, User: models.User{ID: userID}}, nil
+}
+
+type fakeRecipes struct {
+	recipes.RecipesRepositorer
+	ingredients map[int][]models.RecipeIngredient
+}
+
+func (r *fakeRecipes) FindIngredients(_ context.Context, id int) ([]models.RecipeIngredient, error) {
+	return r.ingredients[id], nil
+}
+
+type fakeUsers struct {
+	user.UserStorage
+	shelfLives []models.ShelfLife
+}
+
+func (u *fakeUsers) FindShelfLives(_ context.Context, _ int) ([]models.ShelfLife, error) {
+	return u.shelfLives, nil
+}
+
+type fakeConversions struct {
+	conversion.ConversionServicer
+}
+
+func (fakeConversions) Converter(_ context.Context, _ []int) (conversion.Converter, error) {
+	return conversion.NewConverter(nil, nil), nil
+}
+
+var (
+	now   = time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+	gram  = models.Measure{ID: 1, Name: "г"}
+	kilo  = models.Measure{ID: 2, Name: "кг"}
+	piece = models.Measure{ID: 3, Name: "шт"}
+
+	flour = models.Product{ID: 1, Name: "мука"}
+	eggs  = models.Product{ID: 2, Name: "яйца"}
+	milk  = models.Product{ID: 3, Name: "молоко"}
+)
+
+func ingredient(product models.Product, measure models.Measure, quantity float32) models.RecipeIngredient {
+	return models.RecipeIngredient{Product: product, Measure: measure, Quantity: quantity}
+}
+
+func item(product models.Product, measure models.Measure, quantity float32) models.ShoppingListItem {
+	return models.ShoppingListItem{Product: product, Measure: measure, Quantity: quantity}
+}
+
+func Test_Shortages(t *testing.T) {
+	testCases := []struct {
+		name        string
+		ingredients []models.RecipeIngredient
+		holdings    []holding
+		expected    []models.ShoppingListItem
+	}{
+		{
+			name:        "nothing held",
+			ingredients: []models.RecipeIngredient{ingredient(flour, gram, 300), ingredient(eggs, piece, 2)},
+			expected:    []models.ShoppingListItem{item(flour, gram, 300), item(eggs, piece, 2)},
+		},
+		{
+			name:        "same product summed up in the first measure",
+			ingredients: []models.RecipeIngredient{ingredient(flour, gram, 300), ingredient(flour, kilo, 1)},
+			expected:    []models.ShoppingListItem{item(flour, gram, 1300)},
+		},
+		{
+			name:        "held in another measure",
+			ingredients: []models.RecipeIngredient{ingredient(flour, gram, 1300)},
+			holdings:    []holding{{productID: flour.ID, measure: kilo, quantity: 1}},
+			expected:    []models.ShoppingListItem{item(flour, gram, 300)},
+		},
+		{
+			name:        "held enough",
+			ingredients: []models.RecipeIngredient{ingredient(eggs, piece, 2)},
+			holdings:    []holding{{productID: eggs.ID, measure: piece, quantity: 6}},
+			expected:    []models.ShoppingListItem{},
+		},
+		{
+			name:        "inconvertible measures",
+			ingredients: []models.RecipeIngredient{ingredient(eggs, piece, 2), ingredient(eggs, gram, 100)},
+			holdings:    []holding{{productID: eggs.ID, measure: gram, quantity: 50}},
+			expected:    []models.ShoppingListItem{item(eggs, piece, 2), item(eggs, gram, 50)},
+		},
+		{
+			name: "holding used once",
+			ingredients: []models.RecipeIngredient{
+				ingredient(eggs, piece, 2),
+				ingredient(eggs, gram, 100),
+				ingredient(milk, gram, 100),
+			},
+			holdings: []holding{
+				{productID: eggs.ID, measure: piece, quantity: 1},
+				{productID: milk.ID, measure: kilo, quantity: 0.05},
+			},
+			expected: []models.ShoppingListItem{item(eggs, piece, 1), item(eggs, gram, 100), item(milk, gram, 50)},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := shortages(tc.ingredients, tc.holdings, conversion.NewConverter(nil, nil))
+			assert.Len(t, result, len(tc.expected))
+			for i := range tc.expected {
+				assert.Equal(t, tc.expected[i].Product, result[i].Product)
+				assert.Equal(t, tc.expected[i].Measure, result[i].Measure)
+				assert.InDelta(t, tc.expected[i].Quantity, result[i].Quantity, quantityEpsilon)
+			}
+		})
+	}
+}
+
+func Test_GenerateItems(t *testing.T) {
+	yesterday := now.AddDate(0, 0, -1)
+	checked := now.Add(-time.Hour)
+	repo := &fakeRepository{items: []models.ShoppingListItem{
+		item(flour, gram, 200),
+		{Product: eggs, Measure: piece, Quantity: 10, CheckedAt: &checked},
+	}}
+	svc := &shoppingListService{
+		repo: repo,
+		recipes: &fakeRecipes{ingredients: map[int][]models.RecipeIngredient{
+			1: {ingredient(flour, gram, 500), ingredient(eggs, piece, 3)},
+			2: {ingredient(milk, gram, 200), ingredient(eggs, piece, 1)},
+		}},
+		users: &fakeUsers{shelfLives: []models.ShelfLife{
+			{Product: eggs, Measure: piece, Quantity: 2},
+			{Product: milk, Measure: gram, Quantity: 1000, EndDate: &yesterday},
+		}},
+		conversions: fakeConversions{},
+		now:         func() time.Time { return now },
+	}
+	_, err := svc.GenerateItems(context.Background(), 1, 1, &params.GenerateShoppingList{RecipeIDs: []int{1, 2}})
+	assert.NoError(t, err)
+	// flour is partly on the list, two eggs are held, the checked off eggs
+	// and the expired milk do not count.
+	assert.Len(t, repo.merged, 3)
+	assert.Equal(t, flour, repo.merged[0].Product)
+	assert.InDelta(t, 300, repo.merged[0].Quantity, quantityEpsilon)
+	assert.Equal(t, eggs, repo.merged[1].Product)
+	assert.InDelta(t, 2, repo.merged[1].Quantity, quantityEpsilon)
+	assert.Equal(t, milk, repo.merged[2].Product)
+	assert.InDelta(t, 200, repo.merged[2].Quantity, quantityEpsilon)
+}
@@ -0,0 +1,114 @@
+package shoppinglist
+
+import (
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// holding is a quantity of the product the user already has or is going to
+// buy.
+type holding struct {
+	productID int
+	measure   models.Measure
+	quantity  float32
+}
+
+// stockHoldings returns the shelf lives which have not expired yet.
+func stockHoldings(shelfLives []models.ShelfLife, now time.Time) []holding {
+	today := utils.Day(now)
+	result := make([]holding, 0, len(shelfLives))
+	for _, shelfLife := range shelfLives {
+		if shelfLife.EndDate != nil && shelfLife.EndDate.Before(today) {
+			continue
+		}
+		result = append(result, holding{
+			productID: shelfLife.Product.ID,
+			measure:   shelfLife.Measure,
+			quantity:  shelfLife.Quantity,
+		})
+	}
+	return result
+}
+
+// listHoldings returns the items of the list which are not checked off yet.
+// Checked off items are either bought and held in shelf lives or skipped.
+func listHoldings(items []models.ShoppingListItem) []holding {
+	result := make([]holding, 0, len(items))
+	for _, item := range items {
+		if item.CheckedAt != nil {
+			continue
+		}
+		result = append(result, holding{
+			productID: item.Product.ID,
+			measure:   item.Measure,
+			quantity:  item.Quantity,
+		})
+	}
+	return result
+}
+
+// shortages sums up the ingredients per product and subtracts the holdings.
+// Ingredients of the same product are summed up in the measure seen first
+// unless the measures are not convertible. Every holding is used up once,
+// so it is not subtracted from several ingredients.
+func shortages(
+	ingredients []models.RecipeIngredient,
+	holdings []holding,
+	converter conversion.Converter,
+) []models.ShoppingListItem {
+	var needs []models.ShoppingListItem
+	for _, ingredient := range ingredients {
+		quantity := ingredient.Quantity
+		merged := false
+		for i := range needs {
+			if needs[i].Product.ID != ingredient.Product.ID {
+				continue
+			}
+			converted, ok := converter.Convert(ingredient.Product.ID, quantity, ingredient.Measure, needs[i].Measure)
+			if ok {
+				needs[i].Quantity += converted
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			needs = append(needs, models.ShoppingListItem{
+				Product:  ingredient.Product,
+				Measure:  ingredient.Measure,
+				Quantity: quantity,
+			})
+		}
+	}
+	remaining := make([]float32, len(holdings))
+	for i, h := range holdings {
+		remaining[i] = h.quantity
+	}
+	result := make([]models.ShoppingListItem, 0, len(needs))
+	for _, need := range needs {
+		for i, h := range holdings {
+			if h.productID != need.Product.ID || remaining[i] <= quantityEpsilon {
+				continue
+			}
+			have, ok := converter.Convert(h.productID, remaining[i], h.measure, need.Measure)
+			if !ok || have <= 0 {
+				continue
+			}
+			used := have
+			if need.Quantity < used {
+				used = need.Quantity
+			}
+			need.Quantity -= used
+			remaining[i] -= remaining[i] * used / have
+			if need.Quantity <= quantityEpsilon {
+				break
+			}
+		}
+		if need.Quantity > quantityEpsilon {
+			result = append(result, need)
+		}
+	}
+	return result
+}
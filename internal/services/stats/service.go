@@ -0,0 +1,194 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	repository "github.com/romankravchuk/muerta/internal/storage/postgres/stats"
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+
+	GroupByProduct  = "product"
+	GroupByCategory = "category"
+	GroupByStorage  = "storage"
+)
+
+// StatsServicer reports what happened to the food of the users.
+type StatsServicer interface {
+	// Waste reports the outcomes of shelf lives of the user. Zero user ID
+	// reports on all users.
+	Waste(ctx context.Context, userID int, filter *params.WasteFilter) (params.WasteStats, error)
+}
+
+type statsService struct {
+	repo        repository.StatsRepositorer
+	conversions conversion.ConversionServicer
+	now         func() time.Time
+}
+
+// Waste implements StatsServicer
+func (s *statsService) Waste(
+	ctx context.Context,
+	userID int,
+	filter *params.WasteFilter,
+) (params.WasteStats, error) {
+	model := s.wasteFilterToModel(filter)
+	model.UserID = userID
+	records, err := s.repo.FindWaste(ctx, model)
+	if err != nil {
+		return params.WasteStats{}, fmt.Errorf("error finding waste: %w", err)
+	}
+	converter, err := s.conversions.Converter(ctx, nil)
+	if err != nil {
+		return params.WasteStats{}, fmt.Errorf("error finding conversions: %w", err)
+	}
+	buckets, totals := AggregateWaste(ToBaseUnits(records, converter))
+	return params.WasteStats{
+		From:    model.From,
+		To:      model.To,
+		Period:  model.Period,
+		GroupBy: model.GroupBy,
+		Buckets: buckets,
+		Totals:  totals,
+	}, nil
+}
+
+// wasteFilterToModel fills the defaults: monthly buckets grouped by product.
+// The range ends with the current day and covers 30 days, 12 weeks or 12
+// months depending on the period. The end date is inclusive.
+func (s *statsService) wasteFilterToModel(filter *params.WasteFilter) models.WasteFilter {
+	model := models.WasteFilter{
+		Period:  filter.Period,
+		GroupBy: filter.GroupBy,
+	}
+	if model.Period == "" {
+		model.Period = PeriodMonth
+	}
+	if model.GroupBy == "" {
+		model.GroupBy = GroupByProduct
+	}
+	model.To = utils.Day(s.now())
+	if to, err := time.Parse(time.DateOnly, filter.To); err == nil {
+		model.To = to
+	}
+	model.To = model.To.AddDate(0, 0, 1)
+	switch model.Period {
+	case PeriodDay:
+		model.From = model.To.AddDate(0, 0, -30)
+	case PeriodWeek:
+		model.From = model.To.AddDate(0, 0, -12*7)
+	default:
+		model.From = model.To.AddDate(0, -12, 0)
+	}
+	if from, err := time.Parse(time.DateOnly, filter.From); err == nil {
+		model.From = from
+	}
+	return model
+}
+
+// ToBaseUnits converts the quantities of the records to the base units of
+// their measures, so kilograms and grams are summed up as grams. The records
+// in the measures without a base unit are kept as they are.
+func ToBaseUnits(records []models.WasteRecord, converter conversion.Converter) []models.WasteRecord {
+	for i, record := range records {
+		base, ok := converter.Base(record.Measure)
+		if !ok {
+			continue
+		}
+		quantity, ok := converter.Convert(0, record.Quantity, record.Measure, base)
+		if !ok {
+			continue
+		}
+		records[i].Measure, records[i].Quantity = base, quantity
+	}
+	return records
+}
+
+// AggregateWaste folds the records ordered by bucket and group into buckets
+// and sums up the totals per measure.
+func AggregateWaste(records []models.WasteRecord) ([]params.WasteBucket, []params.WasteMeasure) {
+	var (
+		buckets = []params.WasteBucket{}
+		totals  = []params.WasteMeasure{}
+	)
+	for _, record := range records {
+		if n := len(buckets); n == 0 || !buckets[n-1].Start.Equal(record.Bucket) {
+			buckets = append(buckets, params.WasteBucket{Start: record.Bucket})
+		}
+		bucket := &buckets[len(buckets)-1]
+		if n := len(bucket.Groups); n == 0 || bucket.Groups[n-1].ID != record.GroupID {
+			bucket.Groups = append(bucket.Groups, params.WasteGroup{
+				ID:   record.GroupID,
+				Name: record.GroupName,
+			})
+		}
+		group := &bucket.Groups[len(bucket.Groups)-1]
+		group.Measures = addOutcome(group.Measures, record)
+		totals = addOutcome(totals, record)
+	}
+	for i := range buckets {
+		for j := range buckets[i].Groups {
+			setWasteRatios(buckets[i].Groups[j].Measures)
+		}
+	}
+	setWasteRatios(totals)
+	return buckets, totals
+}
+
+func addOutcome(measures []params.WasteMeasure, record models.WasteRecord) []params.WasteMeasure {
+	i := 0
+	for i < len(measures) && measures[i].Measure.ID != record.Measure.ID {
+		i++
+	}
+	if i == len(measures) {
+		measures = append(measures, params.WasteMeasure{
+			Measure: utils.MeasureModelToFind(&record.Measure),
+		})
+	}
+	var amount *params.WasteAmount
+	switch record.Outcome {
+	case models.ShelfLifeEventConsumed:
+		amount = &measures[i].Consumed
+	case models.ShelfLifeOutcomeConsumedExpired:
+		amount = &measures[i].ConsumedExpired
+	case models.ShelfLifeEventDiscarded:
+		amount = &measures[i].Discarded
+	case models.ShelfLifeEventGivenAway:
+		amount = &measures[i].GivenAway
+	case models.ShelfLifeOutcomeExpired:
+		amount = &measures[i].Expired
+	default:
+		return measures
+	}
+	amount.Count += record.Count
+	amount.Quantity += record.Quantity
+	return measures
+}
+
+func setWasteRatios(measures []params.WasteMeasure) {
+	for i := range measures {
+		m := &measures[i]
+		wasted := m.Discarded.Quantity + m.Expired.Quantity
+		total := wasted + m.Consumed.Quantity + m.ConsumedExpired.Quantity + m.GivenAway.Quantity
+		if total > 0 {
+			m.WasteRatio = wasted / total
+		}
+	}
+}
+
+func New(repo repository.StatsRepositorer, conversions conversion.ConversionServicer) StatsServicer {
+	return &statsService{
+		repo:        repo,
+		conversions: conversions,
+		now:         time.Now,
+	}
+}
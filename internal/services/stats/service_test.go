@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRepository struct {
+	filter  models.WasteFilter
+	records []models.WasteRecord
+}
+
+func (r *fakeRepository) FindWaste(
+	_ context.Context,
+	filter models.WasteFilter,
+) ([]models.WasteRecord, error) {
+	r.filter = filter
+	return r.records, nil
+}
+
+// fakeConversions converts the common units known by their names.
+type fakeConversions struct {
+	conversion.ConversionServicer
+	measures []models.Measure
+}
+
+func (c fakeConversions) Converter(context.Context, []int) (conversion.Converter, error) {
+	return conversion.NewConverter(c.measures, nil), nil
+}
+
+func Test_WasteFilter(t *testing.T) {
+	now := time.Date(2023, 5, 17, 13, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		filter   params.WasteFilter
+		expected models.WasteFilter
+	}{
+		{
+			name:   "defaults",
+			filter: params.WasteFilter{},
+			expected: models.WasteFilter{
+				UserID:  1,
+				From:    time.Date(2022, 5, 18, 0, 0, 0, 0, time.UTC),
+				To:      time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC),
+				Period:  PeriodMonth,
+				GroupBy: GroupByProduct,
+			},
+		},
+		{
+			name:   "daily",
+			filter: params.WasteFilter{Period: PeriodDay, GroupBy: GroupByStorage},
+			expected: models.WasteFilter{
+				UserID:  1,
+				From:    time.Date(2023, 4, 18, 0, 0, 0, 0, time.UTC),
+				To:      time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC),
+				Period:  PeriodDay,
+				GroupBy: GroupByStorage,
+			},
+		},
+		{
+			name:   "explicit range",
+			filter: params.WasteFilter{From: "2023-01-01", To: "2023-01-31", Period: PeriodWeek},
+			expected: models.WasteFilter{
+				UserID:  1,
+				From:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:      time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+				Period:  PeriodWeek,
+				GroupBy: GroupByProduct,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			svc := &statsService{repo: repo, conversions: fakeConversions{}, now: func() time.Time { return now }}
+			_, err := svc.Waste(context.Background(), 1, &tc.filter)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, repo.filter)
+		})
+	}
+}
+
+func Test_AggregateWaste(t *testing.T) {
+	jan := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	kg := models.Measure{ID: 1, Name: "кг"}
+	pcs := models.Measure{ID: 2, Name: "шт"}
+	records := []models.WasteRecord{
+		{Bucket: jan, GroupID: 1, GroupName: "молоко", Measure: kg, Outcome: "consumed", Count: 2, Quantity: 3},
+		{Bucket: jan, GroupID: 1, GroupName: "молоко", Measure: kg, Outcome: "expired", Count: 1, Quantity: 1},
+		{Bucket: jan, GroupID: 2, GroupName: "яйца", Measure: pcs, Outcome: "discarded", Count: 1, Quantity: 4},
+		{Bucket: feb, GroupID: 1, GroupName: "молоко", Measure: kg, Outcome: "given_away", Count: 1, Quantity: 2},
+	}
+
+	buckets, totals := AggregateWaste(records)
+
+	assert.Len(t, buckets, 2)
+	assert.Len(t, buckets[0].Groups, 2)
+	milk := buckets[0].Groups[0].Measures[0]
+	assert.Equal(t, params.WasteAmount{Count: 2, Quantity: 3}, milk.Consumed)
+	assert.Equal(t, params.WasteAmount{Count: 1, Quantity: 1}, milk.Expired)
+	assert.InDelta(t, 0.25, milk.WasteRatio, 1e-6)
+	assert.Equal(t, feb, buckets[1].Start)
+	assert.Len(t, totals, 2)
+	assert.Equal(t, float32(2), totals[0].GivenAway.Quantity)
+	assert.InDelta(t, 1.0/6, totals[0].WasteRatio, 1e-6)
+	assert.InDelta(t, 1.0, totals[1].WasteRatio, 1e-6)
+}
+
+func Test_WasteInBaseUnits(t *testing.T) {
+	var (
+		jan   = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		gram  = models.Measure{ID: 1, Name: "г"}
+		kilo  = models.Measure{ID: 2, Name: "кг"}
+		bunch = models.Measure{ID: 3, Name: "пучок"}
+		repo  = &fakeRepository{records: []models.WasteRecord{
+			{Bucket: jan, GroupID: 1, GroupName: "сыр", Measure: kilo, Outcome: "consumed", Count: 1, Quantity: 0.5},
+			{Bucket: jan, GroupID: 1, GroupName: "сыр", Measure: gram, Outcome: "consumed", Count: 1, Quantity: 200},
+			{Bucket: jan, GroupID: 1, GroupName: "сыр", Measure: kilo, Outcome: "consumed_expired", Count: 1, Quantity: 0.1},
+			{Bucket: jan, GroupID: 1, GroupName: "сыр", Measure: gram, Outcome: "expired", Count: 1, Quantity: 300},
+			{Bucket: jan, GroupID: 2, GroupName: "укроп", Measure: bunch, Outcome: "discarded", Count: 1, Quantity: 2},
+		}}
+		svc = &statsService{
+			repo:        repo,
+			conversions: fakeConversions{measures: []models.Measure{gram, kilo, bunch}},
+			now:         func() time.Time { return jan },
+		}
+	)
+
+	result, err := svc.Waste(context.Background(), 1, &params.WasteFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, result.Totals, 2)
+	cheese := result.Totals[0]
+	assert.Equal(t, "г", cheese.Measure.Name)
+	assert.Equal(t, 2, cheese.Consumed.Count)
+	assert.InDelta(t, 700, cheese.Consumed.Quantity, 1e-3)
+	assert.InDelta(t, 100, cheese.ConsumedExpired.Quantity, 1e-3)
+	assert.InDelta(t, 300, cheese.Expired.Quantity, 1e-3)
+	assert.InDelta(t, 0.2727, cheese.WasteRatio, 1e-3)
+	assert.Equal(t, "пучок", result.Totals[1].Measure.Name)
+	assert.Equal(t, float32(2), result.Totals[1].Discarded.Quantity)
+}
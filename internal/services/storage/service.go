@@ -5,33 +5,85 @@ import (
 	"fmt"
 
 	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/services/utils"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/storage"
 )
 
+// StorageServicer manages storages on behalf of the user from the token
+// payload. Non-admins are limited to the storages shared with them,
+// otherwise errors.ErrNotOwner is returned.
 type StorageServicer interface {
-	FindStorageByID(ctx context.Context, id int) (params.FindStorage, error)
-	FindStorages(ctx context.Context, filter *params.StorageFilter) ([]params.FindStorage, error)
-	CreateStorage(ctx context.Context, payload *params.CreateStorage) error
-	UpdateStorage(ctx context.Context, id int, payload *params.UpdateStorage) error
-	DeleteStorage(ctx context.Context, id int) error
-	RestoreStorage(ctx context.Context, id int) error
-	FindTips(ctx context.Context, id int) ([]params.FindTip, error)
+	FindStorageByID(ctx context.Context, user *params.TokenPayload, id int) (params.FindStorage, error)
+	FindStorages(
+		ctx context.Context,
+		user *params.TokenPayload,
+		filter *params.StorageFilter,
+	) ([]params.FindStorage, error)
+	CreateStorage(ctx context.Context, user *params.TokenPayload, payload *params.CreateStorage) error
+	UpdateStorage(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+		payload *params.UpdateStorage,
+	) error
+	DeleteStorage(ctx context.Context, user *params.TokenPayload, id int) error
+	RestoreStorage(ctx context.Context, user *params.TokenPayload, id int) error
+	FindTips(ctx context.Context, user *params.TokenPayload, id int) ([]params.FindTip, error)
 	CreateTip(ctx context.Context, id, tipID int) (params.FindTip, error)
 	DeleteTip(ctx context.Context, id, tipID int) error
-	FindShelfLives(ctx context.Context, id int) ([]params.FindShelfLife, error)
-	Count(ctx context.Context, filter params.StorageFilter) (int, error)
+	FindShelfLives(
+		ctx context.Context,
+		user *params.TokenPayload,
+		id int,
+	) ([]params.FindShelfLife, error)
+	Count(ctx context.Context, user *params.TokenPayload, filter params.StorageFilter) (int, error)
 }
 
 type storageService struct {
 	repo storage.StorageRepositorer
 }
 
+// authorize returns errors.ErrNotOwner if the storage is not shared with the user.
+func (s *storageService) authorize(ctx context.Context, user *params.TokenPayload, id int) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	ok, err := s.repo.IsAccessible(ctx, id, user.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check storage access: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("storage %d: %w", id, errors.ErrNotOwner)
+	}
+	return nil
+}
+
+// authorizeWrite returns errors.ErrNotOwner if the user can not change the
+// storage. Household viewers can only read it.
+func (s *storageService) authorizeWrite(ctx context.Context, user *params.TokenPayload, id int) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	ok, err := s.repo.IsWritable(ctx, id, user.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to check storage access: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("storage %d: %w", id, errors.ErrNotOwner)
+	}
+	return nil
+}
+
 func (s *storageService) FindShelfLives(
 	ctx context.Context,
+	user *params.TokenPayload,
 	id int,
 ) ([]params.FindShelfLife, error) {
+	if err := s.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
 	result, err := s.repo.FindShelfLives(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find shelf lives: %w", err)
@@ -39,8 +91,15 @@ func (s *storageService) FindShelfLives(
 	return utils.ShelfLifeModelsToFinds(result), nil
 }
 
-func (s *storageService) Count(ctx context.Context, filter params.StorageFilter) (int, error) {
-	count, err := s.repo.Count(ctx, models.StorageFilter{Name: filter.Name})
+func (s *storageService) Count(
+	ctx context.Context,
+	user *params.TokenPayload,
+	filter params.StorageFilter,
+) (int, error) {
+	count, err := s.repo.Count(ctx, models.StorageFilter{
+		Name:     filter.Name,
+		ViewerID: utils.ViewerID(user),
+	})
 	if err != nil {
 		return 0, fmt.Errorf("error counting storages: %w", err)
 	}
@@ -65,7 +124,14 @@ func (s *storageService) DeleteTip(ctx context.Context, id int, tipID int) error
 }
 
 // FindTips implements StorageServicer
-func (s *storageService) FindTips(ctx context.Context, id int) ([]params.FindTip, error) {
+func (s *storageService) FindTips(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) ([]params.FindTip, error) {
+	if err := s.authorize(ctx, user, id); err != nil {
+		return nil, err
+	}
 	result, err := s.repo.FindTips(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find tips: %w", err)
@@ -79,7 +145,14 @@ func New(repo storage.StorageRepositorer) StorageServicer {
 	}
 }
 
-func (s *storageService) FindStorageByID(ctx context.Context, id int) (params.FindStorage, error) {
+func (s *storageService) FindStorageByID(
+	ctx context.Context,
+	user *params.TokenPayload,
+	id int,
+) (params.FindStorage, error) {
+	if err := s.authorize(ctx, user, id); err != nil {
+		return params.FindStorage{}, err
+	}
 	model, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return params.FindStorage{}, fmt.Errorf("failed to find storage: %w", err)
@@ -90,11 +163,13 @@ func (s *storageService) FindStorageByID(ctx context.Context, id int) (params.Fi
 
 func (s *storageService) FindStorages(
 	ctx context.Context,
+	user *params.TokenPayload,
 	filter *params.StorageFilter,
 ) ([]params.FindStorage, error) {
 	models, err := s.repo.FindMany(ctx, models.StorageFilter{
 		PageFilter: models.PageFilter{Limit: filter.Limit, Offset: filter.Offset},
 		Name:       filter.Name,
+		ViewerID:   utils.ViewerID(user),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to find storages: %w", err)
@@ -103,9 +178,14 @@ func (s *storageService) FindStorages(
 	return dtos, nil
 }
 
-func (s *storageService) CreateStorage(ctx context.Context, payload *params.CreateStorage) error {
+// CreateStorage shares the storage created by a non-admin with its creator.
+func (s *storageService) CreateStorage(
+	ctx context.Context,
+	user *params.TokenPayload,
+	payload *params.CreateStorage,
+) error {
 	model := utils.CreateStorageToModel(payload)
-	if err := s.repo.Create(ctx, &model); err != nil {
+	if err := s.repo.Create(ctx, &model, utils.ViewerID(user)); err != nil {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
 	return nil
@@ -113,9 +193,13 @@ func (s *storageService) CreateStorage(ctx context.Context, payload *params.Crea
 
 func (s *storageService) UpdateStorage(
 	ctx context.Context,
+	user *params.TokenPayload,
 	id int,
 	payload *params.UpdateStorage,
 ) error {
+	if err := s.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	model, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to find storage: %w", err)
@@ -138,14 +222,20 @@ func (s *storageService) UpdateStorage(
 	return nil
 }
 
-func (s *storageService) DeleteStorage(ctx context.Context, id int) error {
+func (s *storageService) DeleteStorage(ctx context.Context, user *params.TokenPayload, id int) error {
+	if err := s.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	if err := s.repo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete storage: %w", err)
 	}
 	return nil
 }
 
-func (s *storageService) RestoreStorage(ctx context.Context, id int) error {
+func (s *storageService) RestoreStorage(ctx context.Context, user *params.TokenPayload, id int) error {
+	if err := s.authorizeWrite(ctx, user, id); err != nil {
+		return err
+	}
 	if err := s.repo.Restore(ctx, id); err != nil {
 		return fmt.Errorf("failed to restore storage: %w", err)
 	}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	errs "errors"
+	"testing"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRepository keeps the users each storage is shared with in memory.
+// Viewers can only read the storage.
+type fakeRepository struct {
+	storage.StorageRepositorer
+	shared    map[int][]int
+	viewers   map[int][]int
+	filter    models.StorageFilter
+	createdBy int
+	deleted   bool
+}
+
+func (r *fakeRepository) IsAccessible(ctx context.Context, id, userID int) (bool, error) {
+	for _, user := range r.viewers[id] {
+		if user == userID {
+			return true, nil
+		}
+	}
+	return r.IsWritable(ctx, id, userID)
+}
+
+func (r *fakeRepository) IsWritable(_ context.Context, id, userID int) (bool, error) {
+	for _, user := range r.shared[id] {
+		if user == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepository) FindByID(_ context.Context, id int) (models.Vault, error) {
+	return models.Vault{ID: id}, nil
+}
+
+func (r *fakeRepository) FindMany(_ context.Context, filter models.StorageFilter) ([]models.Vault, error) {
+	r.filter = filter
+	return nil, nil
+}
+
+func (r *fakeRepository) FindShelfLives(_ context.Context, _ int) ([]models.ShelfLife, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) Create(_ context.Context, _ *models.Vault, userID int) error {
+	r.createdBy = userID
+	return nil
+}
+
+func (r *fakeRepository) Delete(_ context.Context, _ int) error {
+	r.deleted = true
+	return nil
+}
+
+var (
+	owner  = &params.TokenPayload{UserID: 1, Roles: []string{"user"}}
+	other  = &params.TokenPayload{UserID: 2, Roles: []string{"user"}}
+	admin  = &params.TokenPayload{UserID: 3, Roles: []string{"admin"}}
+	viewer = &params.TokenPayload{UserID: 4, Roles: []string{"user"}}
+)
+
+func Test_StorageAccess(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     *params.TokenPayload
+		denied   bool
+		readOnly bool
+	}{
+		{name: "shared with user", user: owner},
+		{name: "admin", user: admin},
+		{name: "household viewer", user: viewer, readOnly: true},
+		{name: "other user", user: other, denied: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepository{shared: map[int][]int{1: {1}}, viewers: map[int][]int{1: {4}}}
+			svc := New(repo)
+			ctx := context.Background()
+
+			_, err := svc.FindStorageByID(ctx, tc.user, 1)
+			assert.Equal(t, tc.denied, errs.Is(err, errors.ErrNotOwner))
+			_, err = svc.FindShelfLives(ctx, tc.user, 1)
+			assert.Equal(t, tc.denied, errs.Is(err, errors.ErrNotOwner))
+			err = svc.DeleteStorage(ctx, tc.user, 1)
+			assert.Equal(t, tc.denied || tc.readOnly, errs.Is(err, errors.ErrNotOwner))
+			assert.Equal(t, !tc.denied && !tc.readOnly, repo.deleted)
+		})
+	}
+}
+
+func Test_StorageScope(t *testing.T) {
+	testCases := []struct {
+		name     string
+		user     *params.TokenPayload
+		expected int
+	}{
+		{name: "user", user: owner, expected: 1},
+		{name: "admin", user: admin, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &fakeRepository{}
+			svc := New(repo)
+			ctx := context.Background()
+
+			_, err := svc.FindStorages(ctx, tc.user, &params.StorageFilter{})
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, repo.filter.ViewerID)
+			err = svc.CreateStorage(ctx, tc.user, &params.CreateStorage{})
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, repo.createdBy)
+		})
+	}
+}
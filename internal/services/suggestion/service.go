@@ -0,0 +1,221 @@
+package suggestion
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/services/utils"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+)
+
+const defaultLimit = 10
+
+// SuggestionServicer suggests recipes to use up the food of the user.
+type SuggestionServicer interface {
+	FindSuggestions(
+		ctx context.Context,
+		userID int,
+		filter *params.RecipeSuggestionFilter,
+	) ([]params.RecipeSuggestion, error)
+}
+
+type suggestionService struct {
+	users       user.UserStorage
+	recipes     recipes.RecipesRepositorer
+	conversions conversion.ConversionServicer
+	now         func() time.Time
+}
+
+// FindSuggestions implements SuggestionServicer
+func (s *suggestionService) FindSuggestions(
+	ctx context.Context,
+	userID int,
+	filter *params.RecipeSuggestionFilter,
+) ([]params.RecipeSuggestion, error) {
+	shelfLives, err := s.users.FindShelfLives(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error finding shelf lives: %w", err)
+	}
+	stock := newStock(shelfLives, s.now())
+	if len(stock) == 0 {
+		return []params.RecipeSuggestion{}, nil
+	}
+	productIDs := make([]int, 0, len(stock))
+	for id := range stock {
+		productIDs = append(productIDs, id)
+	}
+	sort.Ints(productIDs)
+	candidates, err := s.recipes.FindByProducts(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error finding recipes: %w", err)
+	}
+	converter, err := s.conversions.Converter(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error finding conversions: %w", err)
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	return rank(candidates, stock, converter, limit), nil
+}
+
+// item is a shelf life the user has in stock.
+type item struct {
+	measure  models.Measure
+	quantity float32
+	endDate  *time.Time
+	daysLeft int
+}
+
+// stock holds the items of the user by product. Expired items are left out.
+type stock map[int][]item
+
+func newStock(shelfLives []models.ShelfLife, now time.Time) stock {
+	result := stock{}
+	for _, shelfLife := range shelfLives {
+		if shelfLife.Quantity <= 0 {
+			continue
+		}
+		it := item{
+			measure:  shelfLife.Measure,
+			quantity: shelfLife.Quantity,
+			endDate:  shelfLife.EndDate,
+			daysLeft: -1,
+		}
+		if shelfLife.EndDate != nil {
+			it.daysLeft = utils.DaysLeft(*shelfLife.EndDate, now)
+			if it.daysLeft < 0 {
+				continue
+			}
+		}
+		result[shelfLife.Product.ID] = append(result[shelfLife.Product.ID], it)
+	}
+	return result
+}
+
+// urgency is 1 for items expiring today and decreases with the days left.
+// Items without an end date are not urgent at all.
+func (it item) urgency() float64 {
+	if it.daysLeft < 0 {
+		return 0
+	}
+	return 1 / float64(1+it.daysLeft)
+}
+
+// rank scores the recipes and returns the best ones. Every ingredient in
+// stock in sufficient quantity scores 1 plus the urgency of the soonest
+// expiring item of the product, and the sum is divided by the number of
+// ingredients. Recipes without ingredients in stock are left out. Ties are
+// broken by the number of missing ingredients and then by the recipe ID, so
+// the order is deterministic.
+func rank(
+	candidates []models.Recipe,
+	stock stock,
+	converter conversion.Converter,
+	limit int,
+) []params.RecipeSuggestion {
+	type scored struct {
+		suggestion params.RecipeSuggestion
+		id         int
+	}
+	result := make([]scored, 0, len(candidates))
+	for _, recipe := range candidates {
+		if len(recipe.Ingredients) == 0 {
+			continue
+		}
+		suggestion := params.RecipeSuggestion{
+			Recipe:    utils.RecipeModelToFind(&recipe),
+			Available: []params.SuggestedIngredient{},
+			Missing:   []params.SuggestedIngredient{},
+		}
+		score := 0.0
+		for _, ingredient := range recipe.Ingredients {
+			inStock, urgency, endDate := stock.available(ingredient, converter)
+			suggested := params.SuggestedIngredient{
+				Product:  utils.ProductModelToFind(&ingredient.Product),
+				Measure:  utils.MeasureModelToFind(&ingredient.Measure),
+				Required: ingredient.Quantity,
+				InStock:  inStock,
+				EndDate:  endDate,
+			}
+			if inStock+1e-6 >= ingredient.Quantity {
+				score += 1 + urgency
+				suggestion.Available = append(suggestion.Available, suggested)
+			} else {
+				suggestion.Missing = append(suggestion.Missing, suggested)
+			}
+		}
+		if len(suggestion.Available) == 0 {
+			continue
+		}
+		suggestion.Score = math.Round(score/float64(len(recipe.Ingredients))*1e4) / 1e4
+		result = append(result, scored{suggestion: suggestion, id: recipe.ID})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i].suggestion, result[j].suggestion
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if len(a.Missing) != len(b.Missing) {
+			return len(a.Missing) < len(b.Missing)
+		}
+		return result[i].id < result[j].id
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	suggestions := make([]params.RecipeSuggestion, len(result))
+	for i := range result {
+		suggestions[i] = result[i].suggestion
+	}
+	return suggestions
+}
+
+// available sums the quantity of the product in the measure of the
+// ingredient. Items which can't be converted are skipped. It also returns
+// the urgency and the end date of the soonest expiring item.
+func (s stock) available(
+	ingredient models.RecipeIngredient,
+	converter conversion.Converter,
+) (float32, float64, *time.Time) {
+	var (
+		total   float32
+		urgency float64
+		endDate *time.Time
+	)
+	for _, it := range s[ingredient.Product.ID] {
+		quantity, ok := converter.Convert(ingredient.Product.ID, it.quantity, it.measure, ingredient.Measure)
+		if !ok {
+			continue
+		}
+		total += quantity
+		if u := it.urgency(); u > urgency {
+			urgency = u
+		}
+		if it.endDate != nil && (endDate == nil || it.endDate.Before(*endDate)) {
+			endDate = it.endDate
+		}
+	}
+	return total, urgency, endDate
+}
+
+func New(
+	users user.UserStorage,
+	recipes recipes.RecipesRepositorer,
+	conversions conversion.ConversionServicer,
+) SuggestionServicer {
+	return &suggestionService{
+		users:       users,
+		recipes:     recipes,
+		conversions: conversions,
+		now:         time.Now,
+	}
+}
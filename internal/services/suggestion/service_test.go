@@ -0,0 +1,160 @@
+package suggestion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/services/conversion"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	recipes "github.com/romankravchuk/muerta/internal/storage/postgres/recipe"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUsers struct {
+	user.UserStorage
+	shelfLives []models.ShelfLife
+}
+
+func (u *fakeUsers) FindShelfLives(_ context.Context, _ int) ([]models.ShelfLife, error) {
+	return u.shelfLives, nil
+}
+
+// fakeRecipes keeps the recipes in memory and finds the ones using any of
+// the products like the real repository does.
+type fakeRecipes struct {
+	recipes.RecipesRepositorer
+	recipes []models.Recipe
+}
+
+func (r *fakeRecipes) FindByProducts(_ context.Context, productIDs []int) ([]models.Recipe, error) {
+	var result []models.Recipe
+	for _, recipe := range r.recipes {
+		for _, ingredient := range recipe.Ingredients {
+			if contains(productIDs, ingredient.Product.ID) {
+				result = append(result, recipe)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// fakeConversions converts the common units known by their names.
+type fakeConversions struct {
+	conversion.ConversionServicer
+}
+
+func (fakeConversions) Converter(_ context.Context, _ []int) (conversion.Converter, error) {
+	return conversion.NewConverter(nil, nil), nil
+}
+
+func contains(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	now   = time.Date(2023, 5, 10, 12, 0, 0, 0, time.UTC)
+	gram  = models.Measure{ID: 1, Name: "г"}
+	kilo  = models.Measure{ID: 2, Name: "кг"}
+	piece = models.Measure{ID: 3, Name: "шт"}
+
+	milk   = models.Product{ID: 1, Name: "молоко"}
+	eggs   = models.Product{ID: 2, Name: "яйца"}
+	flour  = models.Product{ID: 3, Name: "мука"}
+	cheese = models.Product{ID: 4, Name: "сыр"}
+)
+
+func days(n int) *time.Time {
+	t := now.AddDate(0, 0, n)
+	return &t
+}
+
+func ingredient(product models.Product, measure models.Measure, quantity float32) models.RecipeIngredient {
+	return models.RecipeIngredient{Product: product, Measure: measure, Quantity: quantity}
+}
+
+func newService(shelfLives []models.ShelfLife, list []models.Recipe) *suggestionService {
+	return &suggestionService{
+		users:       &fakeUsers{shelfLives: shelfLives},
+		recipes:     &fakeRecipes{recipes: list},
+		conversions: fakeConversions{},
+		now:         func() time.Time { return now },
+	}
+}
+
+func Test_FindSuggestions(t *testing.T) {
+	shelfLives := []models.ShelfLife{
+		{Product: milk, Measure: gram, Quantity: 500, EndDate: days(0)},
+		{Product: eggs, Measure: piece, Quantity: 6, EndDate: days(9)},
+		{Product: flour, Measure: kilo, Quantity: 0.25},
+		{Product: cheese, Measure: gram, Quantity: 300, EndDate: days(-1)},
+	}
+	list := []models.Recipe{
+		{ID: 1, Name: "омлет", Ingredients: []models.RecipeIngredient{
+			ingredient(milk, gram, 100),
+			ingredient(eggs, piece, 3),
+		}},
+		{ID: 2, Name: "блины", Ingredients: []models.RecipeIngredient{
+			ingredient(milk, gram, 400),
+			ingredient(eggs, piece, 2),
+			ingredient(flour, gram, 300),
+		}},
+		{ID: 3, Name: "сырники", Ingredients: []models.RecipeIngredient{
+			ingredient(cheese, gram, 200),
+			ingredient(eggs, piece, 1),
+		}},
+		{ID: 4, Name: "гренки", Ingredients: []models.RecipeIngredient{
+			ingredient(cheese, gram, 50),
+		}},
+		{ID: 5, Name: "яичница", Ingredients: []models.RecipeIngredient{
+			ingredient(eggs, piece, 3),
+		}},
+	}
+	svc := newService(shelfLives, list)
+
+	result, err := svc.FindSuggestions(context.Background(), 1, &params.RecipeSuggestionFilter{})
+
+	assert.Nil(t, err)
+	names := make([]string, len(result))
+	scores := make([]float64, len(result))
+	for i, suggestion := range result {
+		names[i] = suggestion.Recipe.Name
+		scores[i] = suggestion.Score
+	}
+	// омлет: (1 + 1) + (1 + 0.1) of 2; блины: flour is short after conversion;
+	// сырники: the cheese has expired; гренки: nothing in stock.
+	assert.Equal(t, []string{"омлет", "яичница", "блины", "сырники"}, names)
+	assert.Equal(t, []float64{1.55, 1.1, 1.0333, 0.55}, scores)
+
+	pancakes := result[2]
+	assert.Len(t, pancakes.Missing, 1)
+	assert.Equal(t, "мука", pancakes.Missing[0].Product.Name)
+	assert.Equal(t, float32(250), pancakes.Missing[0].InStock)
+	assert.Equal(t, float32(300), pancakes.Missing[0].Required)
+	assert.Equal(t, days(0), result[0].Available[0].EndDate)
+}
+
+func Test_FindSuggestionsDeterministic(t *testing.T) {
+	shelfLives := []models.ShelfLife{{Product: eggs, Measure: piece, Quantity: 6}}
+	list := []models.Recipe{
+		{ID: 3, Name: "c", Ingredients: []models.RecipeIngredient{ingredient(eggs, piece, 1)}},
+		{ID: 1, Name: "a", Ingredients: []models.RecipeIngredient{ingredient(eggs, piece, 1)}},
+		{ID: 2, Name: "b", Ingredients: []models.RecipeIngredient{ingredient(eggs, piece, 1)}},
+	}
+	svc := newService(shelfLives, list)
+
+	result, err := svc.FindSuggestions(context.Background(), 1, &params.RecipeSuggestionFilter{Limit: 2})
+
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, 1, result[0].Recipe.ID)
+	assert.Equal(t, 2, result[1].Recipe.ID)
+}
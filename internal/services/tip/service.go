@@ -172,6 +172,9 @@ func (svc *tipService) UpdateTip(ctx context.Context, id int, payload *params.Up
 	if payload.Description != "" {
 		model.Description = payload.Description
 	}
+	if payload.Rule != nil {
+		model.Rule = utils.TipRuleToModel(payload.Rule)
+	}
 	if err := svc.repo.Update(ctx, model); err != nil {
 		return err
 	}
@@ -3,10 +3,16 @@ package user
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/pkg/safehttp"
+	"github.com/romankravchuk/muerta/internal/services/notification"
+	shelflifesvc "github.com/romankravchuk/muerta/internal/services/shelf-life"
 	"github.com/romankravchuk/muerta/internal/services/utils"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	shelflife "github.com/romankravchuk/muerta/internal/storage/postgres/shelf-life"
 	repo "github.com/romankravchuk/muerta/internal/storage/postgres/user"
 )
 
@@ -21,16 +27,23 @@ type UserServicer interface {
 	UpdateSetting(
 		ctx context.Context,
 		id int,
+		settingID int,
 		payload *params.UpdateUserSetting,
 	) (params.FindSetting, error)
 	FindRoles(ctx context.Context, id int) ([]params.FindRole, error)
 	AddStorage(
 		ctx context.Context,
+		user *params.TokenPayload,
 		id, storageID int,
 	) (params.FindStorage, error)
 	RemoveStorage(ctx context.Context, id, storageID int) error
 	FindStorages(ctx context.Context, id int) ([]params.FindStorage, error)
-	FindShelfLives(ctx context.Context, id int) ([]params.FindShelfLife, error)
+	FindShelfLives(
+		ctx context.Context,
+		id int,
+		filter *params.ShelfLifeFilter,
+	) ([]params.FindShelfLife, error)
+	CountShelfLives(ctx context.Context, id int, filter params.ShelfLifeFilter) (int, error)
 	CreateShelfLife(
 		ctx context.Context,
 		id int,
@@ -50,7 +63,8 @@ type UserServicer interface {
 }
 
 type userService struct {
-	repo repo.UserStorage
+	repo       repo.UserStorage
+	shelfLives shelflife.ShelfLifeRepositorer
 }
 
 // Count implements UserServicer
@@ -62,9 +76,22 @@ func (s *userService) Count(ctx context.Context, filter params.UserFilter) (int,
 	return count, nil
 }
 
-func New(repo repo.UserStorage) UserServicer {
+// authorizeStorage returns errors.ErrNotOwner if the storage is not shared with the user.
+func (svc *userService) authorizeStorage(ctx context.Context, id, storageID int) error {
+	ok, err := svc.shelfLives.IsStorageWritable(ctx, storageID, id)
+	if err != nil {
+		return fmt.Errorf("error checking storage access: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("storage %d: %w", storageID, errors.ErrNotOwner)
+	}
+	return nil
+}
+
+func New(repo repo.UserStorage, shelfLives shelflife.ShelfLifeRepositorer) UserServicer {
 	return &userService{
-		repo: repo,
+		repo:       repo,
+		shelfLives: shelfLives,
 	}
 }
 
@@ -74,12 +101,21 @@ func (svc *userService) CreateShelfLife(
 	id int,
 	payload *params.CreateShelfLife,
 ) (params.FindShelfLife, error) {
+	if err := svc.authorizeStorage(ctx, id, payload.StorageID); err != nil {
+		return params.FindShelfLife{}, err
+	}
+	rule, err := shelflifesvc.DefaultEndDate(ctx, svc.shelfLives, payload)
+	if err != nil {
+		return params.FindShelfLife{}, err
+	}
 	model := utils.CreateShelfLifeToModel(payload)
 	createdModel, err := svc.repo.CreateShelfLife(ctx, id, model)
 	if err != nil {
 		return params.FindShelfLife{}, fmt.Errorf("error creating shelf life: %w", err)
 	}
-	return utils.ShelfLifeModelToFind(&createdModel), nil
+	result := utils.ShelfLifeModelToFind(&createdModel)
+	result.EndDateRule = rule
+	return result, nil
 }
 
 // DeleteShelfLife implements UserServicer
@@ -97,14 +133,32 @@ func (svc *userService) DeleteShelfLife(
 func (svc *userService) FindShelfLives(
 	ctx context.Context,
 	id int,
+	filter *params.ShelfLifeFilter,
 ) ([]params.FindShelfLife, error) {
-	models, err := svc.repo.FindShelfLives(ctx, id)
+	model := utils.ShelfLifeFilterToModel(filter)
+	model.UserID = id
+	models, err := svc.shelfLives.FindMany(ctx, model)
 	if err != nil {
 		return nil, fmt.Errorf("error finding shelf lives: %w", err)
 	}
 	return utils.ShelfLifeModelsToFinds(models), nil
 }
 
+// CountShelfLives implements UserServicer
+func (svc *userService) CountShelfLives(
+	ctx context.Context,
+	id int,
+	filter params.ShelfLifeFilter,
+) (int, error) {
+	model := utils.ShelfLifeFilterToModel(&filter)
+	model.UserID = id
+	count, err := svc.shelfLives.Count(ctx, model)
+	if err != nil {
+		return 0, fmt.Errorf("error counting shelf lives: %w", err)
+	}
+	return count, nil
+}
+
 // RestoreShelfLife implements UserServicer
 func (svc *userService) RestoreShelfLife(
 	ctx context.Context,
@@ -133,7 +187,10 @@ func (svc *userService) UpdateShelfLife(
 	if payload.ProductID != 0 {
 		model.Product.ID = payload.ProductID
 	}
-	if payload.StorageID != 0 {
+	if payload.StorageID != 0 && payload.StorageID != model.Storage.ID {
+		if err := svc.authorizeStorage(ctx, id, payload.StorageID); err != nil {
+			return params.FindShelfLife{}, err
+		}
 		model.Storage.ID = payload.StorageID
 	}
 	if payload.Quantity != 0 {
@@ -165,10 +222,25 @@ func (svc *userService) RemoveStorage(
 }
 
 // AddStorage implements UserServicer
+//
+// Non-admins can add only the storages already shared with them directly, and
+// only to themselves, the router allows the owner only. The storages of the
+// households are left out, so that the members keep no access to them after
+// leaving, and are shared with the other users through the invitations.
 func (svc *userService) AddStorage(
 	ctx context.Context,
+	user *params.TokenPayload,
 	id, storageID int,
 ) (params.FindStorage, error) {
+	if !user.IsAdmin() {
+		ok, err := svc.repo.HasVault(ctx, user.UserID, storageID)
+		if err != nil {
+			return params.FindStorage{}, fmt.Errorf("error checking storage access: %w", err)
+		}
+		if !ok {
+			return params.FindStorage{}, fmt.Errorf("storage %d: %w", storageID, errors.ErrNotOwner)
+		}
+	}
 	model, err := svc.repo.AddVault(ctx, id, storageID)
 	if err != nil {
 		return params.FindStorage{}, fmt.Errorf("error creating storage: %w", err)
@@ -212,15 +284,32 @@ func (svc *userService) FindSettings(ctx context.Context, id int) ([]params.Find
 }
 
 // UpdateSetting implements UserServicer
+//
+// The webhook URL must be an https one not pointing to the internal network,
+// as the notifications are posted to it by the server.
 func (svc *userService) UpdateSetting(
 	ctx context.Context,
 	id int,
+	settingID int,
 	payload *params.UpdateUserSetting,
 ) (params.FindSetting, error) {
 	if _, err := svc.repo.FindByID(ctx, id); err != nil {
 		return params.FindSetting{}, fmt.Errorf("error finding user: %w", err)
 	}
+	settings, err := svc.repo.FindSettings(ctx, id)
+	if err != nil {
+		return params.FindSetting{}, fmt.Errorf("error finding settings: %w", err)
+	}
+	for _, setting := range settings {
+		if setting.ID != settingID || !strings.EqualFold(setting.Name, notification.SettingWebhook) {
+			continue
+		}
+		if err := safehttp.ValidateURL(strings.TrimSpace(payload.Value)); err != nil {
+			return params.FindSetting{}, err
+		}
+	}
 	entity := utils.UpdateSettingToModel(payload)
+	entity.ID = settingID
 	result, err := svc.repo.UpdateSetting(ctx, id, entity)
 	if err != nil {
 		return params.FindSetting{}, fmt.Errorf("error updating setting: %w", err)
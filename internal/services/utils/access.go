@@ -0,0 +1,12 @@
+package utils
+
+import "github.com/romankravchuk/muerta/internal/api/router/params"
+
+// ViewerID returns the id that scopes queries to the user, or zero for
+// admins who keep a global view.
+func ViewerID(user *params.TokenPayload) int {
+	if user.IsAdmin() {
+		return 0
+	}
+	return user.UserID
+}
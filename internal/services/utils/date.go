@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// Day returns the calendar day of the time in UTC as its midnight. The dates
+// of the shelf lives are kept as days at midnight UTC.
+func Day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DaysLeft returns the number of calendar days from now until endDate.
+// It is negative once the end date has passed.
+func DaysLeft(endDate, now time.Time) int {
+	return int(Day(endDate).Sub(Day(now)).Hours() / 24)
+}
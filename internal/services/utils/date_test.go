@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DaysLeft(t *testing.T) {
+	now := time.Date(2023, 5, 10, 23, 30, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		endDate  time.Time
+		expected int
+	}{
+		{name: "today", endDate: time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC), expected: 0},
+		{name: "tomorrow", endDate: time.Date(2023, 5, 11, 0, 0, 0, 0, time.UTC), expected: 1},
+		{name: "yesterday", endDate: time.Date(2023, 5, 9, 0, 0, 0, 0, time.UTC), expected: -1},
+		{name: "other zone", endDate: time.Date(2023, 5, 11, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*3600)), expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DaysLeft(tc.endDate, now))
+		})
+	}
+}
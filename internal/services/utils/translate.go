@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/romankravchuk/muerta/internal/api/router/params"
 	"github.com/romankravchuk/muerta/internal/pkg/auth"
@@ -20,6 +22,7 @@ func RecipeModelToFind(model *models.Recipe) params.FindRecipe {
 		ID:          model.ID,
 		Name:        model.Name,
 		Description: model.Description,
+		Servings:    model.Servings,
 		Steps:       steps,
 	}
 }
@@ -31,6 +34,7 @@ func RecipeModelsToFinds(models []models.Recipe) []params.FindRecipe {
 			ID:          recipe.ID,
 			Name:        recipe.Name,
 			Description: recipe.Description,
+			Servings:    recipe.Servings,
 		}
 	}
 	return result
@@ -106,6 +110,7 @@ func CreateRecipeToModel(dto *params.CreateRecipe) models.Recipe {
 		},
 		Name:        dto.Name,
 		Description: dto.Description,
+		Servings:    dto.Servings,
 		Steps:       steps,
 		Ingredients: ingredients,
 	}
@@ -237,6 +242,7 @@ func CreateCategoryToModel(dto *params.CreateProductCategory) models.ProductCate
 func CreateTipToModel(dto *params.CreateTip) models.Tip {
 	return models.Tip{
 		Description: dto.Description,
+		Rule:        TipRuleToModel(dto.Rule),
 	}
 }
 
@@ -244,6 +250,30 @@ func TipModelToFind(model *models.Tip) params.FindTip {
 	return params.FindTip{
 		ID:          model.ID,
 		Description: model.Description,
+		Rule:        TipRuleModelToFind(&model.Rule),
+	}
+}
+
+func TipRuleToModel(dto *params.TipRule) models.TipRule {
+	if dto == nil {
+		return models.TipRule{}
+	}
+	return models.TipRule{
+		ExpiresWithin:    dto.ExpiresWithin,
+		TemperatureAbove: dto.TemperatureAbove,
+		TemperatureBelow: dto.TemperatureBelow,
+	}
+}
+
+// TipRuleModelToFind returns nil for the rule without conditions.
+func TipRuleModelToFind(model *models.TipRule) *params.TipRule {
+	if model.IsZero() {
+		return nil
+	}
+	return &params.TipRule{
+		ExpiresWithin:    model.ExpiresWithin,
+		TemperatureAbove: model.TemperatureAbove,
+		TemperatureBelow: model.TemperatureBelow,
 	}
 }
 
@@ -257,14 +287,18 @@ func TipModelsToFinds(models []models.Tip) []params.FindTip {
 
 func CreateMeasureToModel(dto *params.CreateMeasure) models.Measure {
 	return models.Measure{
-		Name: dto.Name,
+		Name:      dto.Name,
+		Dimension: dto.Dimension,
+		Factor:    dto.Factor,
 	}
 }
 
 func MeasureModelToFind(model *models.Measure) params.FindMeasure {
 	return params.FindMeasure{
-		ID:   model.ID,
-		Name: model.Name,
+		ID:        model.ID,
+		Name:      model.Name,
+		Dimension: model.Dimension,
+		Factor:    model.Factor,
 	}
 }
 
@@ -349,6 +383,62 @@ func ShelfLifeModelsToFinds(models []models.ShelfLife) []params.FindShelfLife {
 	return dtos
 }
 
+// ShelfLifeFilterToModel translates the query filter. Dates are expected to
+// be validated already, malformed ones are ignored.
+func ShelfLifeFilterToModel(dto *params.ShelfLifeFilter) models.ShelfLifeFilter {
+	return models.ShelfLifeFilter{
+		PageFilter: models.PageFilter{
+			Limit:  dto.Limit,
+			Offset: dto.Offset,
+		},
+		ProductID:     dto.ProductID,
+		StorageID:     dto.StorageID,
+		MeasureID:     dto.MeasureID,
+		StatusID:      dto.StatusID,
+		PurchasedFrom: parseDate(dto.PurchasedFrom),
+		PurchasedTo:   parseDate(dto.PurchasedTo),
+		EndFrom:       parseDate(dto.EndFrom),
+		EndTo:         parseDate(dto.EndTo),
+		ExpiresWithin: dto.ExpiresWithin,
+		SortBy:        dto.SortBy,
+		Descending:    dto.Order == "desc",
+	}
+}
+
+func parseDate(value string) *time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+func CreateShelfLifeEventToModel(id int, dto *params.CreateShelfLifeEvent) models.ShelfLifeEvent {
+	return models.ShelfLifeEvent{
+		ShelfLifeID: id,
+		Type:        dto.Type,
+		Quantity:    dto.Quantity,
+		OccurredAt:  dto.OccurredAt,
+	}
+}
+
+func ShelfLifeEventModelToFind(model *models.ShelfLifeEvent) params.FindShelfLifeEvent {
+	return params.FindShelfLifeEvent{
+		ID:         model.ID,
+		Type:       model.Type,
+		Quantity:   model.Quantity,
+		OccurredAt: model.OccurredAt,
+	}
+}
+
+func ShelfLifeEventModelsToFinds(models []models.ShelfLifeEvent) []params.FindShelfLifeEvent {
+	dtos := make([]params.FindShelfLifeEvent, len(models))
+	for i, model := range models {
+		dtos[i] = ShelfLifeEventModelToFind(&model)
+	}
+	return dtos
+}
+
 func CreateShelfLifeStatusToModel(dto *params.CreateShelfLifeStatus) models.ShelfLifeStatus {
 	return models.ShelfLifeStatus{
 		Name: dto.Name,
@@ -449,3 +539,262 @@ func CreateToStepModel(dto *params.CreateStep) models.Step {
 		Name: dto.Name,
 	}
 }
+
+func NotificationModelToFind(model *models.Notification) params.FindNotification {
+	return params.FindNotification{
+		ID:          model.ID,
+		ShelfLifeID: model.ShelfLife.ID,
+		Threshold:   model.Threshold,
+		Title:       model.Title,
+		Message:     model.Message,
+		Read:        model.ReadAt != nil,
+		ReadAt:      model.ReadAt,
+		CreatedAt:   model.CreatedAt,
+	}
+}
+
+func NotificationModelsToFinds(models []models.Notification) []params.FindNotification {
+	dtos := make([]params.FindNotification, len(models))
+	for i, model := range models {
+		dtos[i] = NotificationModelToFind(&model)
+	}
+	return dtos
+}
+
+func DefaultDurationsToModels(dtos []params.DefaultDuration) []models.DefaultDuration {
+	result := make([]models.DefaultDuration, len(dtos))
+	for i, dto := range dtos {
+		result[i] = models.DefaultDuration{
+			StorageType: models.StorageType{ID: dto.StorageTypeID},
+			Days:        dto.Days,
+		}
+	}
+	return result
+}
+
+func DefaultDurationModelsToFinds(models []models.DefaultDuration) []params.FindDefaultDuration {
+	result := make([]params.FindDefaultDuration, len(models))
+	for i, model := range models {
+		result[i] = params.FindDefaultDuration{
+			StorageType: storageTypeModelToFindOrNil(&model.StorageType),
+			Days:        model.Days,
+		}
+	}
+	return result
+}
+
+func ShelfLifeRuleModelToFind(model *models.ShelfLifeRule) *params.ShelfLifeRule {
+	return &params.ShelfLifeRule{
+		Source:      model.Source,
+		ID:          model.SourceID,
+		Name:        model.Name,
+		StorageType: storageTypeModelToFindOrNil(&model.StorageType),
+		Days:        model.Days,
+	}
+}
+
+// storageTypeModelToFindOrNil returns nil for the zero storage type which
+// stands for any storage.
+func storageTypeModelToFindOrNil(model *models.StorageType) *params.FindStorageType {
+	if model.ID == 0 {
+		return nil
+	}
+	return &params.FindStorageType{ID: model.ID, Name: model.Name}
+}
+
+func ShoppingListModelToFind(model *models.ShoppingList) params.FindShoppingList {
+	return params.FindShoppingList{
+		ID:        model.ID,
+		Name:      model.Name,
+		CreatedAt: model.CreatedAt,
+		Items:     ShoppingListItemModelsToFinds(model.Items),
+	}
+}
+
+func ShoppingListModelsToFinds(models []models.ShoppingList) []params.FindShoppingList {
+	dtos := make([]params.FindShoppingList, len(models))
+	for i, model := range models {
+		dtos[i] = ShoppingListModelToFind(&model)
+	}
+	return dtos
+}
+
+func ShoppingListItemModelToFind(model *models.ShoppingListItem) params.FindShoppingListItem {
+	return params.FindShoppingListItem{
+		ID:          model.ID,
+		Product:     ProductModelToFind(&model.Product),
+		Measure:     MeasureModelToFind(&model.Measure),
+		Quantity:    model.Quantity,
+		Checked:     model.CheckedAt != nil,
+		CheckedAt:   model.CheckedAt,
+		ShelfLifeID: model.ShelfLifeID,
+	}
+}
+
+func ShoppingListItemModelsToFinds(models []models.ShoppingListItem) []params.FindShoppingListItem {
+	dtos := make([]params.FindShoppingListItem, len(models))
+	for i, model := range models {
+		dtos[i] = ShoppingListItemModelToFind(&model)
+	}
+	return dtos
+}
+
+func CreateShoppingListItemToModel(listID int, dto *params.CreateShoppingListItem) models.ShoppingListItem {
+	return models.ShoppingListItem{
+		ListID:   listID,
+		Product:  models.Product{ID: dto.ProductID},
+		Measure:  models.Measure{ID: dto.MeasureID},
+		Quantity: dto.Quantity,
+	}
+}
+
+func ProductConversionToModel(productID int, dto *params.ProductConversion) models.ProductConversion {
+	return models.ProductConversion{
+		ProductID:   productID,
+		Density:     dto.Density,
+		PieceWeight: dto.PieceWeight,
+	}
+}
+
+func ProductConversionModelToFind(model *models.ProductConversion) params.ProductConversion {
+	return params.ProductConversion{
+		Density:     model.Density,
+		PieceWeight: model.PieceWeight,
+	}
+}
+
+func HouseholdModelToFind(model *models.Household) params.FindHousehold {
+	return params.FindHousehold{
+		ID:        model.ID,
+		Name:      model.Name,
+		Role:      model.Role,
+		CreatedAt: model.CreatedAt,
+	}
+}
+
+func HouseholdModelsToFinds(models []models.Household) []params.FindHousehold {
+	dtos := make([]params.FindHousehold, len(models))
+	for i, model := range models {
+		dtos[i] = HouseholdModelToFind(&model)
+	}
+	return dtos
+}
+
+func HouseholdMemberModelToFind(model *models.HouseholdMember) params.FindHouseholdMember {
+	return params.FindHouseholdMember{
+		ID:       model.User.ID,
+		Name:     model.User.Name,
+		Role:     model.Role,
+		JoinedAt: model.JoinedAt,
+	}
+}
+
+func HouseholdMemberModelsToFinds(models []models.HouseholdMember) []params.FindHouseholdMember {
+	dtos := make([]params.FindHouseholdMember, len(models))
+	for i, model := range models {
+		dtos[i] = HouseholdMemberModelToFind(&model)
+	}
+	return dtos
+}
+
+func DetectedDatesToModels(dtos []params.DetectedDate) []models.DetectedDate {
+	result := make([]models.DetectedDate, len(dtos))
+	for i, dto := range dtos {
+		result[i] = models.DetectedDate{
+			Date:       dto.Date,
+			Kind:       dto.Kind,
+			Confidence: dto.Confidence,
+			Text:       dto.Text,
+			Derived:    dto.Derived,
+		}
+		if dto.Box != nil {
+			result[i].Box = &models.Box{X: dto.Box.X, Y: dto.Box.Y, Width: dto.Box.Width, Height: dto.Box.Height}
+		}
+	}
+	return result
+}
+
+func DetectedDateModelsToParams(models []models.DetectedDate) []params.DetectedDate {
+	dtos := make([]params.DetectedDate, len(models))
+	for i, model := range models {
+		dtos[i] = params.DetectedDate{
+			Date:       model.Date,
+			Kind:       model.Kind,
+			Confidence: model.Confidence,
+			Text:       model.Text,
+			Derived:    model.Derived,
+		}
+		if model.Box != nil {
+			dtos[i].Box = &params.Box{X: model.Box.X, Y: model.Box.Y, Width: model.Box.Width, Height: model.Box.Height}
+		}
+	}
+	return dtos
+}
+
+func ShelfLifeScanToModel(dto *params.ShelfLifeScan) models.ShelfLifeScan {
+	return models.ShelfLifeScan{
+		Image:       dto.Image,
+		ContentType: dto.ContentType,
+		Dates:       DetectedDatesToModels(dto.Dates),
+	}
+}
+
+func ShelfLifeScanModelToParams(model *models.ShelfLifeScan) params.ShelfLifeScan {
+	return params.ShelfLifeScan{
+		Image:       model.Image,
+		ContentType: model.ContentType,
+		Dates:       DetectedDateModelsToParams(model.Dates),
+	}
+}
+
+func DetectionJobModelToFind(model *models.DetectionJob) params.FindDetectionJob {
+	dto := params.FindDetectionJob{
+		ID:         model.ID,
+		Status:     model.Status,
+		Attempts:   model.Attempts,
+		Error:      model.Error,
+		CreatedAt:  model.CreatedAt,
+		FinishedAt: model.FinishedAt,
+	}
+	if len(model.Dates) > 0 {
+		dto.Dates = DetectedDateModelsToParams(model.Dates)
+	}
+	return dto
+}
+
+func DetectionModelToFind(model *models.Detection) params.FindDetection {
+	dto := params.FindDetection{
+		ID:        model.ID,
+		ImageHash: model.Image.Hash,
+		Text:      model.Text,
+		Dates:     DetectedDateModelsToParams(model.Dates),
+		LatencyMs: model.LatencyMs,
+		CreatedAt: model.CreatedAt,
+	}
+	if model.CorrectedAt != nil {
+		dto.Correction = &params.DetectionCorrection{
+			Manufactured: model.Manufactured,
+			Expiry:       model.Expiry,
+			CorrectedAt:  model.CorrectedAt,
+		}
+	}
+	return dto
+}
+
+func DetectionModelsToFinds(models []models.Detection) []params.FindDetection {
+	dtos := make([]params.FindDetection, len(models))
+	for i, model := range models {
+		dtos[i] = DetectionModelToFind(&model)
+	}
+	return dtos
+}
+
+func DetectionFilterToModel(dto *params.DetectionFilter) models.DetectionFilter {
+	return models.DetectionFilter{
+		PageFilter: models.PageFilter{
+			Limit:  dto.Limit,
+			Offset: dto.Offset,
+		},
+		Corrected: dto.Corrected,
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romankravchuk/muerta/internal/api/router/params"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ShelfLifeFilterToModel(t *testing.T) {
+	var (
+		within = 3
+		day    = func(month time.Month, day int) *time.Time {
+			date := time.Date(2023, month, day, 0, 0, 0, 0, time.UTC)
+			return &date
+		}
+	)
+	testCases := []struct {
+		name     string
+		filter   params.ShelfLifeFilter
+		expected models.ShelfLifeFilter
+	}{
+		{
+			name:     "no paging",
+			filter:   params.ShelfLifeFilter{},
+			expected: models.ShelfLifeFilter{},
+		},
+		{
+			name: "every field",
+			filter: params.ShelfLifeFilter{
+				OptionalPaging: params.OptionalPaging{Limit: 10, Offset: 20},
+				ProductID:      1,
+				StorageID:      2,
+				MeasureID:      3,
+				StatusID:       4,
+				PurchasedFrom:  "2023-01-01",
+				PurchasedTo:    "2023-01-31",
+				EndFrom:        "2023-02-01",
+				EndTo:          "2023-02-28",
+				ExpiresWithin:  &within,
+				SortBy:         "end_date",
+				Order:          "desc",
+			},
+			expected: models.ShelfLifeFilter{
+				PageFilter:    models.PageFilter{Limit: 10, Offset: 20},
+				ProductID:     1,
+				StorageID:     2,
+				MeasureID:     3,
+				StatusID:      4,
+				PurchasedFrom: day(time.January, 1),
+				PurchasedTo:   day(time.January, 31),
+				EndFrom:       day(time.February, 1),
+				EndTo:         day(time.February, 28),
+				ExpiresWithin: &within,
+				SortBy:        "end_date",
+				Descending:    true,
+			},
+		},
+		{
+			name:     "ascending",
+			filter:   params.ShelfLifeFilter{SortBy: "product", Order: "asc"},
+			expected: models.ShelfLifeFilter{SortBy: "product"},
+		},
+		{
+			name:     "malformed dates are ignored",
+			filter:   params.ShelfLifeFilter{PurchasedFrom: "01.01.2023", EndTo: "2023-02-30"},
+			expected: models.ShelfLifeFilter{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ShelfLifeFilterToModel(&tc.filter))
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// AccessibleStorages returns the subquery selecting the storages the user
+// passed as the argument, e.g. "$2", can access. These are the storages
+// shared with the user and the ones owned by the households the user is a
+// member of. Viewers are left out unless read is true.
+func AccessibleStorages(arg string, read bool) string {
+	roles := fmt.Sprintf("'%s', '%s'", models.RoleOwner, models.RoleEditor)
+	if read {
+		roles += fmt.Sprintf(", '%s'", models.RoleViewer)
+	}
+	return fmt.Sprintf(`
+		SELECT id_storage FROM users_storages WHERE id_user = %[1]s
+		UNION
+		SELECT hs.id_storage
+		FROM households_storages hs
+		JOIN households_members hm ON hm.id_household = hs.id_household
+		WHERE hm.id_user = %[1]s AND hm.role IN (%[2]s)
+	`, arg, roles)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 )
@@ -19,6 +20,11 @@ type CategoryRepositorer interface {
 	Delete(ctx context.Context, id int) error
 	Restore(ctx context.Context, id int) error
 	Count(ctx context.Context, filter models.ProductCategoryFilter) (int, error)
+	FindDurations(ctx context.Context, id int) ([]models.DefaultDuration, error)
+	UpdateDurations(ctx context.Context, id int, durations []models.DefaultDuration) error
+	FindTips(ctx context.Context, id int) ([]models.Tip, error)
+	CreateTip(ctx context.Context, id, tipID int) (models.Tip, error)
+	DeleteTip(ctx context.Context, id, tipID int) error
 }
 
 type categoryRepository struct {
@@ -143,8 +149,139 @@ func (r *categoryRepository) Update(ctx context.Context, role models.ProductCate
 	return nil
 }
 
+// FindDurations implements CategoryRepositorer
+func (r *categoryRepository) FindDurations(
+	ctx context.Context,
+	id int,
+) ([]models.DefaultDuration, error) {
+	var (
+		query = `
+			SELECT COALESCE(st.id, 0), COALESCE(st.name, ''), cd.days
+			FROM categories_durations cd
+			LEFT JOIN storages_types st ON st.id = cd.id_type
+			WHERE cd.id_category = $1
+			ORDER BY st.name NULLS FIRST
+		`
+		durations []models.DefaultDuration
+	)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find durations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var duration models.DefaultDuration
+		if err := rows.Scan(
+			&duration.StorageType.ID,
+			&duration.StorageType.Name,
+			&duration.Days,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan duration: %w", err)
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
+
+// UpdateDurations implements CategoryRepositorer
+//
+// The durations replace the ones the category had.
+func (r *categoryRepository) UpdateDurations(
+	ctx context.Context,
+	id int,
+	durations []models.DefaultDuration,
+) error {
+	var (
+		queryDelete = `
+			DELETE FROM categories_durations
+			WHERE id_category = $1
+		`
+		queryInsert = `
+			INSERT INTO categories_durations (id_category, id_type, days)
+			VALUES ($1, NULLIF($2, 0), $3)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, queryDelete, id); err != nil {
+		return fmt.Errorf("failed to delete durations: %w", err)
+	}
+	for _, duration := range durations {
+		if _, err := tx.Exec(ctx, queryInsert, id, duration.StorageType.ID, duration.Days); err != nil {
+			return fmt.Errorf("failed to insert duration: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
 func New(client postgres.Client) CategoryRepositorer {
 	return &categoryRepository{
 		client: client,
 	}
 }
+
+// FindTips implements CategoryRepositorer
+func (r *categoryRepository) FindTips(ctx context.Context, id int) ([]models.Tip, error) {
+	var (
+		query = `
+			SELECT t.id, t.description
+			FROM tips t
+			JOIN categories_tips ct ON ct.id_tip = t.id
+			WHERE ct.id_category = $1 AND t.deleted_at IS NULL
+		`
+		result []models.Tip
+	)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tips: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tip models.Tip
+		if err := rows.Scan(&tip.ID, &tip.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan tip: %w", err)
+		}
+		result = append(result, tip)
+	}
+	return result, nil
+}
+
+// CreateTip implements CategoryRepositorer
+func (r *categoryRepository) CreateTip(ctx context.Context, id, tipID int) (models.Tip, error) {
+	var (
+		query = `
+			WITH inserted AS (
+				INSERT INTO categories_tips (id_category, id_tip)
+				VALUES ($1, $2)
+				RETURNING id_tip
+			)
+			SELECT t.id, t.description
+			FROM tips t
+			JOIN inserted i ON i.id_tip = t.id
+			LIMIT 1
+		`
+		result models.Tip
+	)
+	if err := r.client.QueryRow(ctx, query, id, tipID).Scan(&result.ID, &result.Description); err != nil {
+		return models.Tip{}, fmt.Errorf("failed to create tip: %w", err)
+	}
+	return result, nil
+}
+
+// DeleteTip implements CategoryRepositorer
+func (r *categoryRepository) DeleteTip(ctx context.Context, id, tipID int) error {
+	query := `
+		DELETE FROM categories_tips
+		WHERE id_category = $1 AND id_tip = $2
+	`
+	if _, err := r.client.Exec(ctx, query, id, tipID); err != nil {
+		return fmt.Errorf("failed to delete tip: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package detectionjob
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+type DetectionJobRepositorer interface {
+	Create(ctx context.Context, job *models.DetectionJob) error
+	FindByID(ctx context.Context, id int) (models.DetectionJob, error)
+	Claim(ctx context.Context, now, lockedUntil time.Time) (models.DetectionJob, bool, error)
+	Succeed(ctx context.Context, id int, dates []models.DetectedDate, now time.Time) error
+	Fail(ctx context.Context, id int, message string, now time.Time) error
+	Retry(ctx context.Context, id int, message string, runAt time.Time) error
+	ClaimCallback(ctx context.Context, now, lockedUntil time.Time) (models.DetectionJob, bool, error)
+	SucceedCallback(ctx context.Context, id int) error
+	FailCallback(ctx context.Context, id int, message string) error
+	RetryCallback(ctx context.Context, id int, message string, runAt time.Time) error
+	DeleteFinished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type detectionJobRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) DetectionJobRepositorer {
+	return &detectionJobRepository{
+		client: client,
+	}
+}
+
+// Create implements DetectionJobRepositorer
+func (r *detectionJobRepository) Create(ctx context.Context, job *models.DetectionJob) error {
+	query := `
+		INSERT INTO detection_jobs
+			(id_user, status, image, callback_url, run_at)
+		VALUES
+			($1, $2, $3, NULLIF($4, ''), NOW())
+		RETURNING id, run_at, created_at
+	`
+	if err := r.client.QueryRow(ctx, query,
+		job.UserID,
+		models.JobPending,
+		job.Image,
+		job.CallbackURL,
+	).Scan(&job.ID, &job.RunAt, &job.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create detection job: %w", err)
+	}
+	job.Status = models.JobPending
+	return nil
+}
+
+// FindByID implements DetectionJobRepositorer
+func (r *detectionJobRepository) FindByID(ctx context.Context, id int) (models.DetectionJob, error) {
+	query := `
+		SELECT id, id_user, status, COALESCE(callback_url, ''), attempts, result,
+			COALESCE(error, ''), run_at, created_at, finished_at
+		FROM detection_jobs
+		WHERE id = $1
+	`
+	var job models.DetectionJob
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&job.CallbackURL,
+		&job.Attempts,
+		&job.Dates,
+		&job.Error,
+		&job.RunAt,
+		&job.CreatedAt,
+		&job.FinishedAt,
+	); err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return models.DetectionJob{}, fmt.Errorf("detection job %d: %w", id, errors.ErrJobNotFound)
+		}
+		return models.DetectionJob{}, fmt.Errorf("failed to find detection job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim implements DetectionJobRepositorer
+//
+// It locks the pending job which has waited the longest, or a running one
+// whose worker has not finished it before the lock expired, and reports
+// whether there was one. The concurrent claims skip the locked rows.
+func (r *detectionJobRepository) Claim(
+	ctx context.Context,
+	now, lockedUntil time.Time,
+) (models.DetectionJob, bool, error) {
+	query := `
+		UPDATE detection_jobs
+		SET status = $3, attempts = attempts + 1, locked_until = $2
+		WHERE id = (
+			SELECT id
+			FROM detection_jobs
+			WHERE (status = $4 AND run_at <= $1) OR
+				(status = $3 AND locked_until <= $1)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, id_user, status, image, COALESCE(callback_url, ''), attempts, run_at, created_at
+	`
+	var job models.DetectionJob
+	if err := r.client.QueryRow(ctx, query, now, lockedUntil, models.JobRunning, models.JobPending).Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&job.Image,
+		&job.CallbackURL,
+		&job.Attempts,
+		&job.RunAt,
+		&job.CreatedAt,
+	); err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return models.DetectionJob{}, false, nil
+		}
+		return models.DetectionJob{}, false, fmt.Errorf("failed to claim detection job: %w", err)
+	}
+	return job, true, nil
+}
+
+// Succeed implements DetectionJobRepositorer
+func (r *detectionJobRepository) Succeed(
+	ctx context.Context,
+	id int,
+	dates []models.DetectedDate,
+	now time.Time,
+) error {
+	query := `
+		UPDATE detection_jobs
+		SET status = $2, result = $3, error = NULL, image = NULL, locked_until = NULL, finished_at = $4,
+			callback_status = CASE WHEN callback_url IS NULL THEN NULL ELSE $5 END, callback_at = $4
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id, models.JobSucceeded, dates, now, models.CallbackPending); err != nil {
+		return fmt.Errorf("failed to finish detection job: %w", err)
+	}
+	return nil
+}
+
+// Fail implements DetectionJobRepositorer
+func (r *detectionJobRepository) Fail(ctx context.Context, id int, message string, now time.Time) error {
+	query := `
+		UPDATE detection_jobs
+		SET status = $2, error = $3, image = NULL, locked_until = NULL, finished_at = $4,
+			callback_status = CASE WHEN callback_url IS NULL THEN NULL ELSE $5 END, callback_at = $4
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id, models.JobFailed, message, now, models.CallbackPending); err != nil {
+		return fmt.Errorf("failed to finish detection job: %w", err)
+	}
+	return nil
+}
+
+// Retry implements DetectionJobRepositorer
+func (r *detectionJobRepository) Retry(ctx context.Context, id int, message string, runAt time.Time) error {
+	query := `
+		UPDATE detection_jobs
+		SET status = $2, error = $3, run_at = $4, locked_until = NULL
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id, models.JobPending, message, runAt); err != nil {
+		return fmt.Errorf("failed to retry detection job: %w", err)
+	}
+	return nil
+}
+
+// ClaimCallback implements DetectionJobRepositorer
+//
+// It locks the finished job whose callback has waited the longest and
+// reports whether there was one. The callback is held until lockedUntil and
+// is claimed again from then on if its worker has not finished it.
+func (r *detectionJobRepository) ClaimCallback(
+	ctx context.Context,
+	now, lockedUntil time.Time,
+) (models.DetectionJob, bool, error) {
+	query := `
+		UPDATE detection_jobs
+		SET callback_attempts = callback_attempts + 1, callback_at = $2
+		WHERE id = (
+			SELECT id
+			FROM detection_jobs
+			WHERE callback_status = $3 AND callback_at <= $1
+			ORDER BY callback_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, id_user, status, callback_url, attempts, result, COALESCE(error, ''),
+			run_at, created_at, finished_at, callback_status, callback_attempts
+	`
+	var job models.DetectionJob
+	if err := r.client.QueryRow(ctx, query, now, lockedUntil, models.CallbackPending).Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&job.CallbackURL,
+		&job.Attempts,
+		&job.Dates,
+		&job.Error,
+		&job.RunAt,
+		&job.CreatedAt,
+		&job.FinishedAt,
+		&job.CallbackStatus,
+		&job.CallbackAttempts,
+	); err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return models.DetectionJob{}, false, nil
+		}
+		return models.DetectionJob{}, false, fmt.Errorf("failed to claim detection job callback: %w", err)
+	}
+	return job, true, nil
+}
+
+// SucceedCallback implements DetectionJobRepositorer
+func (r *detectionJobRepository) SucceedCallback(ctx context.Context, id int) error {
+	query := `
+		UPDATE detection_jobs
+		SET callback_status = $2, callback_error = NULL, callback_at = NULL
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id, models.CallbackDelivered); err != nil {
+		return fmt.Errorf("failed to finish detection job callback: %w", err)
+	}
+	return nil
+}
+
+// FailCallback implements DetectionJobRepositorer
+func (r *detectionJobRepository) FailCallback(ctx context.Context, id int, message string) error {
+	query := `
+		UPDATE detection_jobs
+		SET callback_status = $2, callback_error = $3, callback_at = NULL
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id, models.CallbackFailed, message); err != nil {
+		return fmt.Errorf("failed to finish detection job callback: %w", err)
+	}
+	return nil
+}
+
+// RetryCallback implements DetectionJobRepositorer
+func (r *detectionJobRepository) RetryCallback(ctx context.Context, id int, message string, runAt time.Time) error {
+	query := `
+		UPDATE detection_jobs
+		SET callback_error = $2, callback_at = $3
+		WHERE id = $1
+	`
+	if _, err := r.client.Exec(ctx, query, id, message, runAt); err != nil {
+		return fmt.Errorf("failed to retry detection job callback: %w", err)
+	}
+	return nil
+}
+
+// DeleteFinished implements DetectionJobRepositorer
+//
+// The jobs whose callback is still pending are kept.
+func (r *detectionJobRepository) DeleteFinished(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM detection_jobs
+		WHERE finished_at < $1 AND callback_status IS DISTINCT FROM $2
+	`
+	tag, err := r.client.Exec(ctx, query, before, models.CallbackPending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete detection jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,216 @@
+package detection
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+type DetectionRepositorer interface {
+	Create(ctx context.Context, detection *models.Detection) error
+	FindByID(ctx context.Context, id int) (models.Detection, error)
+	FindMany(ctx context.Context, filter models.DetectionFilter) ([]models.Detection, error)
+	Correct(ctx context.Context, id int, manufactured, expiry *time.Time, now time.Time) error
+	FindCorrected(ctx context.Context) ([]models.Detection, error)
+}
+
+type detectionRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) DetectionRepositorer {
+	return &detectionRepository{
+		client: client,
+	}
+}
+
+// Create implements DetectionRepositorer
+//
+// The image is stored unless an image with the same hash already is.
+func (r *detectionRepository) Create(ctx context.Context, detection *models.Detection) error {
+	var (
+		queryImage = `
+			INSERT INTO detection_images
+				(hash, image, content_type)
+			VALUES
+				($1, $2, $3)
+			ON CONFLICT (hash) DO NOTHING
+		`
+		queryDetection = `
+			INSERT INTO detections
+				(id_user, image_hash, text, dates, latency_ms)
+			VALUES
+				($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, queryImage,
+		detection.Image.Hash,
+		detection.Image.Image,
+		detection.Image.ContentType,
+	); err != nil {
+		return fmt.Errorf("failed to create detection image: %w", err)
+	}
+	if err := tx.QueryRow(ctx, queryDetection,
+		detection.UserID,
+		detection.Image.Hash,
+		detection.Text,
+		detection.Dates,
+		detection.LatencyMs,
+	).Scan(&detection.ID, &detection.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create detection: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// FindByID implements DetectionRepositorer
+func (r *detectionRepository) FindByID(ctx context.Context, id int) (models.Detection, error) {
+	query := `
+		SELECT id, id_user, image_hash, text, dates, latency_ms,
+			manufactured_date, expiry_date, corrected_at, created_at
+		FROM detections
+		WHERE id = $1
+	`
+	detection, err := scanDetection(r.client.QueryRow(ctx, query, id))
+	if err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return models.Detection{}, fmt.Errorf("detection %d: %w", id, errors.ErrDetectionNotFound)
+		}
+		return models.Detection{}, fmt.Errorf("failed to find detection: %w", err)
+	}
+	return detection, nil
+}
+
+// FindMany implements DetectionRepositorer
+//
+// The latest detections come first.
+func (r *detectionRepository) FindMany(
+	ctx context.Context,
+	filter models.DetectionFilter,
+) ([]models.Detection, error) {
+	query := `
+		SELECT id, id_user, image_hash, text, dates, latency_ms,
+			manufactured_date, expiry_date, corrected_at, created_at
+		FROM detections
+		WHERE ($1 = 0 OR id_user = $1) AND
+			($2::boolean IS NULL OR (corrected_at IS NOT NULL) = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.client.Query(ctx, query, filter.UserID, filter.Corrected, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find detections: %w", err)
+	}
+	defer rows.Close()
+	detections := make([]models.Detection, 0, filter.Limit)
+	for rows.Next() {
+		detection, err := scanDetection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan detection: %w", err)
+		}
+		detections = append(detections, detection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find detections: %w", err)
+	}
+	return detections, nil
+}
+
+// Correct implements DetectionRepositorer
+func (r *detectionRepository) Correct(
+	ctx context.Context,
+	id int,
+	manufactured, expiry *time.Time,
+	now time.Time,
+) error {
+	query := `
+		UPDATE detections
+		SET manufactured_date = $2, expiry_date = $3, corrected_at = $4
+		WHERE id = $1
+	`
+	tag, err := r.client.Exec(ctx, query, id, manufactured, expiry, now)
+	if err != nil {
+		return fmt.Errorf("failed to correct detection: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("detection %d: %w", id, errors.ErrDetectionNotFound)
+	}
+	return nil
+}
+
+// FindCorrected implements DetectionRepositorer
+//
+// It returns the latest corrected detection of each image with the image.
+func (r *detectionRepository) FindCorrected(ctx context.Context) ([]models.Detection, error) {
+	query := `
+		SELECT DISTINCT ON (d.image_hash)
+			d.id, d.id_user, d.image_hash, d.text, d.dates, d.latency_ms,
+			d.manufactured_date, d.expiry_date, d.corrected_at, d.created_at,
+			i.image, i.content_type
+		FROM detections d
+		JOIN detection_images i ON i.hash = d.image_hash
+		WHERE d.corrected_at IS NOT NULL
+		ORDER BY d.image_hash, d.corrected_at DESC
+	`
+	rows, err := r.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find corrected detections: %w", err)
+	}
+	defer rows.Close()
+	var detections []models.Detection
+	for rows.Next() {
+		var detection models.Detection
+		if err := rows.Scan(
+			&detection.ID,
+			&detection.UserID,
+			&detection.Image.Hash,
+			&detection.Text,
+			&detection.Dates,
+			&detection.LatencyMs,
+			&detection.Manufactured,
+			&detection.Expiry,
+			&detection.CorrectedAt,
+			&detection.CreatedAt,
+			&detection.Image.Image,
+			&detection.Image.ContentType,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan detection: %w", err)
+		}
+		detections = append(detections, detection)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find corrected detections: %w", err)
+	}
+	return detections, nil
+}
+
+func scanDetection(row pgx.Row) (models.Detection, error) {
+	var detection models.Detection
+	err := row.Scan(
+		&detection.ID,
+		&detection.UserID,
+		&detection.Image.Hash,
+		&detection.Text,
+		&detection.Dates,
+		&detection.LatencyMs,
+		&detection.Manufactured,
+		&detection.Expiry,
+		&detection.CorrectedAt,
+		&detection.CreatedAt,
+	)
+	return detection, err
+}
@@ -0,0 +1,438 @@
+package household
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// HouseholdRepositorer keeps the households, their members, storages and
+// invitations. Missing households are reported as errors.ErrHouseholdNotFound.
+type HouseholdRepositorer interface {
+	FindMany(ctx context.Context, userID int) ([]models.Household, error)
+	FindByID(ctx context.Context, id int) (models.Household, error)
+	// FindRole returns an empty role if the user is not a member.
+	FindRole(ctx context.Context, id, userID int) (string, error)
+	Create(ctx context.Context, household *models.Household, ownerID int) error
+	Update(ctx context.Context, household *models.Household) error
+	Delete(ctx context.Context, id int) error
+	FindMembers(ctx context.Context, id int) ([]models.HouseholdMember, error)
+	// UpdateMember and DeleteMember return errors.ErrLastOwner if the last
+	// owner of the household would lose the role.
+	UpdateMember(ctx context.Context, id, userID int, role string) error
+	DeleteMember(ctx context.Context, id, userID int) error
+	FindStorages(ctx context.Context, id int) ([]models.Vault, error)
+	AddStorage(ctx context.Context, id, storageID int) error
+	RemoveStorage(ctx context.Context, id, storageID int) error
+	CreateInvitation(ctx context.Context, invitation *models.HouseholdInvitation) error
+	// Join adds the user to the household of the invitation and deletes the
+	// invitation. It returns errors.ErrInvalidInvitation if the invitation is
+	// unknown or expired.
+	Join(ctx context.Context, codeHash string, userID int, now time.Time) (models.Household, error)
+}
+
+type householdRepository struct {
+	client postgres.Client
+}
+
+// FindMany implements HouseholdRepositorer
+func (r *householdRepository) FindMany(ctx context.Context, userID int) ([]models.Household, error) {
+	var (
+		query = `
+			SELECT h.id, h.name, hm.role, h.created_at
+			FROM households h
+			JOIN households_members hm ON hm.id_household = h.id
+			WHERE hm.id_user = $1
+			ORDER BY h.created_at DESC, h.id DESC
+		`
+		households = make([]models.Household, 0)
+	)
+	rows, err := r.client.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find households: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var household models.Household
+		if err := rows.Scan(&household.ID, &household.Name, &household.Role, &household.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan household: %w", err)
+		}
+		households = append(households, household)
+	}
+	return households, nil
+}
+
+// FindByID implements HouseholdRepositorer
+func (r *householdRepository) FindByID(ctx context.Context, id int) (models.Household, error) {
+	var (
+		query = `
+			SELECT id, name, created_at
+			FROM households
+			WHERE id = $1
+		`
+		household models.Household
+	)
+	err := r.client.QueryRow(ctx, query, id).Scan(&household.ID, &household.Name, &household.CreatedAt)
+	if errs.Is(err, pgx.ErrNoRows) {
+		return models.Household{}, fmt.Errorf("household %d: %w", id, errors.ErrHouseholdNotFound)
+	}
+	if err != nil {
+		return models.Household{}, fmt.Errorf("failed to find household: %w", err)
+	}
+	return household, nil
+}
+
+// FindRole implements HouseholdRepositorer
+func (r *householdRepository) FindRole(ctx context.Context, id, userID int) (string, error) {
+	var (
+		query = `
+			SELECT role
+			FROM households_members
+			WHERE id_household = $1 AND id_user = $2
+		`
+		role string
+	)
+	err := r.client.QueryRow(ctx, query, id, userID).Scan(&role)
+	if errs.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find household role: %w", err)
+	}
+	return role, nil
+}
+
+// Create implements HouseholdRepositorer
+func (r *householdRepository) Create(ctx context.Context, household *models.Household, ownerID int) error {
+	var (
+		query = `
+			INSERT INTO households (name)
+			VALUES ($1)
+			RETURNING id, created_at
+		`
+		queryOwner = `
+			INSERT INTO households_members (id_household, id_user, role)
+			VALUES ($1, $2, $3)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, query, household.Name).Scan(&household.ID, &household.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create household: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryOwner, household.ID, ownerID, models.RoleOwner); err != nil {
+		return fmt.Errorf("failed to add household owner: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	household.Role = models.RoleOwner
+	return nil
+}
+
+// Update implements HouseholdRepositorer
+func (r *householdRepository) Update(ctx context.Context, household *models.Household) error {
+	query := `
+		UPDATE households
+		SET name = $1
+		WHERE id = $2
+	`
+	tag, err := r.client.Exec(ctx, query, household.Name, household.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update household: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("household %d: %w", household.ID, errors.ErrHouseholdNotFound)
+	}
+	return nil
+}
+
+// Delete implements HouseholdRepositorer
+//
+// The storages of the household are kept and stay shared with the users
+// they were shared with directly.
+func (r *householdRepository) Delete(ctx context.Context, id int) error {
+	var (
+		queries = []string{
+			`DELETE FROM households_invitations WHERE id_household = $1`,
+			`DELETE FROM households_storages WHERE id_household = $1`,
+			`DELETE FROM households_members WHERE id_household = $1`,
+		}
+		queryHousehold = `
+			DELETE FROM households
+			WHERE id = $1
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	for _, query := range queries {
+		if _, err := tx.Exec(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete household: %w", err)
+		}
+	}
+	tag, err := tx.Exec(ctx, queryHousehold, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete household: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("household %d: %w", id, errors.ErrHouseholdNotFound)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// FindMembers implements HouseholdRepositorer
+func (r *householdRepository) FindMembers(ctx context.Context, id int) ([]models.HouseholdMember, error) {
+	var (
+		query = `
+			SELECT u.id, u.name, hm.role, hm.joined_at
+			FROM households_members hm
+			JOIN users u ON u.id = hm.id_user
+			WHERE hm.id_household = $1
+			ORDER BY hm.joined_at, u.id
+		`
+		members = make([]models.HouseholdMember, 0)
+	)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find household members: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var member models.HouseholdMember
+		if err := rows.Scan(&member.User.ID, &member.User.Name, &member.Role, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan household member: %w", err)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// UpdateMember implements HouseholdRepositorer
+func (r *householdRepository) UpdateMember(ctx context.Context, id, userID int, role string) error {
+	query := `
+		UPDATE households_members
+		SET role = $3
+		WHERE id_household = $1 AND id_user = $2
+	`
+	return r.changeMember(ctx, id, userID, role != models.RoleOwner, query, id, userID, role)
+}
+
+// DeleteMember implements HouseholdRepositorer
+func (r *householdRepository) DeleteMember(ctx context.Context, id, userID int) error {
+	query := `
+		DELETE FROM households_members
+		WHERE id_household = $1 AND id_user = $2
+	`
+	return r.changeMember(ctx, id, userID, true, query, id, userID)
+}
+
+// changeMember runs the query updating or deleting the member with the rows
+// of the owners of the household locked, so that the owners changed at the
+// same time cannot leave the household without one. The member losing the
+// owner role, if demote is true, must not be the last owner.
+func (r *householdRepository) changeMember(
+	ctx context.Context,
+	id, userID int,
+	demote bool,
+	query string,
+	args ...any,
+) error {
+	queryOwners := `
+		SELECT id_user
+		FROM households_members
+		WHERE id_household = $1 AND role = $2
+		FOR UPDATE
+	`
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	rows, err := tx.Query(ctx, queryOwners, id, models.RoleOwner)
+	if err != nil {
+		return fmt.Errorf("failed to lock household owners: %w", err)
+	}
+	owners, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("failed to scan household owner: %w", err)
+	}
+	if demote && len(owners) == 1 && owners[0] == userID {
+		return fmt.Errorf("household %d: %w", id, errors.ErrLastOwner)
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to change household member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("user %d: %w", userID, errors.ErrMemberNotFound)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// FindStorages implements HouseholdRepositorer
+func (r *householdRepository) FindStorages(ctx context.Context, id int) ([]models.Vault, error) {
+	var (
+		query = `
+			SELECT
+				s.id, s.name,
+				s.temperature, s.humidity,
+				s.created_at, st.id, st.name
+			FROM storages s
+			JOIN storages_types st ON st.id = s.id_type
+			JOIN households_storages hs ON hs.id_storage = s.id
+			WHERE hs.id_household = $1 AND s.deleted_at IS NULL
+			ORDER BY s.created_at DESC
+		`
+		storages = make([]models.Vault, 0)
+	)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find household storages: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var storage models.Vault
+		if err := rows.Scan(&storage.ID, &storage.Name, &storage.Temperature, &storage.Humidity, &storage.CreatedAt, &storage.Type.ID, &storage.Type.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan household storage: %w", err)
+		}
+		storages = append(storages, storage)
+	}
+	return storages, nil
+}
+
+// AddStorage implements HouseholdRepositorer
+//
+// A storage belongs to one household at most, otherwise
+// errors.ErrStorageInHousehold is returned.
+func (r *householdRepository) AddStorage(ctx context.Context, id, storageID int) error {
+	query := `
+		INSERT INTO households_storages (id_household, id_storage)
+		VALUES ($1, $2)
+		ON CONFLICT (id_storage) DO NOTHING
+	`
+	tag, err := r.client.Exec(ctx, query, id, storageID)
+	if err != nil {
+		return fmt.Errorf("failed to add household storage: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("storage %d: %w", storageID, errors.ErrStorageInHousehold)
+	}
+	return nil
+}
+
+// RemoveStorage implements HouseholdRepositorer
+func (r *householdRepository) RemoveStorage(ctx context.Context, id, storageID int) error {
+	query := `
+		DELETE FROM households_storages
+		WHERE id_household = $1 AND id_storage = $2
+	`
+	if _, err := r.client.Exec(ctx, query, id, storageID); err != nil {
+		return fmt.Errorf("failed to remove household storage: %w", err)
+	}
+	return nil
+}
+
+// CreateInvitation implements HouseholdRepositorer
+func (r *householdRepository) CreateInvitation(ctx context.Context, invitation *models.HouseholdInvitation) error {
+	query := `
+		INSERT INTO households_invitations (id_household, code_hash, role, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	if err := r.client.QueryRow(
+		ctx,
+		query,
+		invitation.HouseholdID,
+		invitation.CodeHash,
+		invitation.Role,
+		invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create household invitation: %w", err)
+	}
+	return nil
+}
+
+// Join implements HouseholdRepositorer
+//
+// Members keep their role when they join again. The invitation is used up
+// either way.
+func (r *householdRepository) Join(
+	ctx context.Context,
+	codeHash string,
+	userID int,
+	now time.Time,
+) (models.Household, error) {
+	var (
+		queryInvitation = `
+			SELECT id, id_household, role
+			FROM households_invitations
+			WHERE code_hash = $1 AND expires_at > $2
+			FOR UPDATE
+		`
+		queryMember = `
+			INSERT INTO households_members (id_household, id_user, role)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (id_household, id_user) DO NOTHING
+		`
+		queryDelete = `
+			DELETE FROM households_invitations
+			WHERE id = $1
+		`
+		invitation models.HouseholdInvitation
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return models.Household{}, errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	err = tx.QueryRow(ctx, queryInvitation, codeHash, now).
+		Scan(&invitation.ID, &invitation.HouseholdID, &invitation.Role)
+	if errs.Is(err, pgx.ErrNoRows) {
+		return models.Household{}, errors.ErrInvalidInvitation
+	}
+	if err != nil {
+		return models.Household{}, fmt.Errorf("failed to find household invitation: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryMember, invitation.HouseholdID, userID, invitation.Role); err != nil {
+		return models.Household{}, fmt.Errorf("failed to add household member: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryDelete, invitation.ID); err != nil {
+		return models.Household{}, fmt.Errorf("failed to delete household invitation: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Household{}, errors.ErrFailedToCommitTransaction.With(err)
+	}
+	household, err := r.FindByID(ctx, invitation.HouseholdID)
+	if err != nil {
+		return models.Household{}, err
+	}
+	household.Role, err = r.FindRole(ctx, invitation.HouseholdID, userID)
+	if err != nil {
+		return models.Household{}, err
+	}
+	return household, nil
+}
+
+func New(client postgres.Client) HouseholdRepositorer {
+	return &householdRepository{
+		client: client,
+	}
+}
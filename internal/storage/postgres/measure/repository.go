@@ -11,6 +11,7 @@ import (
 type MeasureRepositorer interface {
 	FindByID(ctx context.Context, id int) (models.Measure, error)
 	FindMany(ctx context.Context, filter models.MeasureFilter) ([]models.Measure, error)
+	FindAll(ctx context.Context) ([]models.Measure, error)
 	Create(ctx context.Context, measure models.Measure) error
 	Update(ctx context.Context, measure models.Measure) error
 	Delete(ctx context.Context, id int) error
@@ -37,10 +38,10 @@ func (r *measureRepository) Count(ctx context.Context, filter models.MeasureFilt
 // Create implements MeasureRepositorer
 func (r *measureRepository) Create(ctx context.Context, measure models.Measure) error {
 	query := `
-			INSERT INTO measures (name)
-			VALUES ($1)
+			INSERT INTO measures (name, dimension, factor)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, 0))
 		`
-	if _, err := r.client.Exec(ctx, query, measure.Name); err != nil {
+	if _, err := r.client.Exec(ctx, query, measure.Name, measure.Dimension, measure.Factor); err != nil {
 		return fmt.Errorf("failed to create measure: %w", err)
 	}
 	return nil
@@ -62,14 +63,19 @@ func (r *measureRepository) Delete(ctx context.Context, id int) error {
 func (r *measureRepository) FindByID(ctx context.Context, id int) (models.Measure, error) {
 	var (
 		query = `
-			SELECT id, name
+			SELECT id, name, COALESCE(dimension, ''), COALESCE(factor, 0)
 			FROM measures
 			WHERE id = $1
 			LIMIT 1	
 		`
 		measure models.Measure
 	)
-	if err := r.client.QueryRow(ctx, query, id).Scan(&measure.ID, &measure.Name); err != nil {
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&measure.ID,
+		&measure.Name,
+		&measure.Dimension,
+		&measure.Factor,
+	); err != nil {
 		return models.Measure{}, fmt.Errorf("failed to find measure: %w", err)
 	}
 	return measure, nil
@@ -82,7 +88,7 @@ func (r *measureRepository) FindMany(
 ) ([]models.Measure, error) {
 	var (
 		query = `
-			SELECT id, name
+			SELECT id, name, COALESCE(dimension, ''), COALESCE(factor, 0)
 			FROM measures
 			WHERE name ILIKE $1
 			LIMIT $2
@@ -97,7 +103,32 @@ func (r *measureRepository) FindMany(
 	defer rows.Close()
 	for rows.Next() {
 		var measure models.Measure
-		if err := rows.Scan(&measure.ID, &measure.Name); err != nil {
+		if err := rows.Scan(&measure.ID, &measure.Name, &measure.Dimension, &measure.Factor); err != nil {
+			return nil, fmt.Errorf("failed to scan measure: %w", err)
+		}
+		measures = append(measures, measure)
+	}
+	return measures, nil
+}
+
+// FindAll implements MeasureRepositorer
+func (r *measureRepository) FindAll(ctx context.Context) ([]models.Measure, error) {
+	var (
+		query = `
+			SELECT id, name, COALESCE(dimension, ''), COALESCE(factor, 0)
+			FROM measures
+			ORDER BY id
+		`
+		measures []models.Measure
+	)
+	rows, err := r.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find measures: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var measure models.Measure
+		if err := rows.Scan(&measure.ID, &measure.Name, &measure.Dimension, &measure.Factor); err != nil {
 			return nil, fmt.Errorf("failed to scan measure: %w", err)
 		}
 		measures = append(measures, measure)
@@ -109,10 +140,17 @@ func (r *measureRepository) FindMany(
 func (r *measureRepository) Update(ctx context.Context, measure models.Measure) error {
 	query := `
 			UPDATE measures
-			SET name = $1
-			WHERE id = $2
+			SET name = $1,
+				dimension = NULLIF($2, ''),
+				factor = NULLIF($3, 0)
+			WHERE id = $4
 		`
-	if _, err := r.client.Exec(ctx, query, measure.Name, measure.ID); err != nil {
+	if _, err := r.client.Exec(ctx, query,
+		measure.Name,
+		measure.Dimension,
+		measure.Factor,
+		measure.ID,
+	); err != nil {
 		return fmt.Errorf("failed to update measure: %w", err)
 	}
 	return nil
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// States of the detection jobs. Pending jobs wait for a worker, including
+// the ones retried after a failure, running jobs are held by a worker until
+// the lock expires.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// States of the callbacks of the finished jobs. Pending callbacks are
+// delivered by the workers and retried like the jobs.
+const (
+	CallbackPending   = "pending"
+	CallbackDelivered = "delivered"
+	CallbackFailed    = "failed"
+)
+
+// DetectionJob detects the dates on the image of the user in background.
+// The image is dropped once the job is finished.
+type DetectionJob struct {
+	ID          int            `db:"id"`
+	UserID      int            `db:"id_user"`
+	Status      string         `db:"status"`
+	Image       []byte         `db:"image"`
+	CallbackURL string         `db:"callback_url"`
+	Attempts    int            `db:"attempts"`
+	Dates       []DetectedDate `db:"result"`
+	Error       string         `db:"error"`
+	RunAt       *time.Time     `db:"run_at"`
+	CreatedAt   *time.Time     `db:"created_at"`
+	FinishedAt  *time.Time     `db:"finished_at"`
+
+	CallbackStatus   string     `db:"callback_status"`
+	CallbackAttempts int        `db:"callback_attempts"`
+	CallbackError    string     `db:"callback_error"`
+	CallbackAt       *time.Time `db:"callback_at"`
+}
+
+type DetectedDate struct {
+	Date       time.Time `json:"date"`
+	Kind       string    `json:"kind"`
+	Confidence float64   `json:"confidence"`
+	Text       string    `json:"text"`
+	Derived    bool      `json:"derived"`
+	Box        *Box      `json:"box,omitempty"`
+}
+
+type Box struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
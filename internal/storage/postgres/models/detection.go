@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Detection records the dates detected on an image of the user and, once the
+// user confirms or corrects them, the dates actually printed on the image.
+type Detection struct {
+	ID           int `db:"id"`
+	UserID       int `db:"id_user"`
+	Image        DetectionImage
+	Text         string         `db:"text"`
+	Dates        []DetectedDate `db:"dates"`
+	LatencyMs    int64          `db:"latency_ms"`
+	Manufactured *time.Time     `db:"manufactured_date"`
+	Expiry       *time.Time     `db:"expiry_date"`
+	CorrectedAt  *time.Time     `db:"corrected_at"`
+	CreatedAt    *time.Time     `db:"created_at"`
+}
+
+// DetectionImage is an image the dates were detected on. It is stored once
+// however many times it is detected.
+type DetectionImage struct {
+	Hash        string `db:"hash"`
+	Image       []byte `db:"image"`
+	ContentType string `db:"content_type"`
+}
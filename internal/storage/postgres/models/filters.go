@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type PageFilter struct {
 	Limit  int
 	Offset int
@@ -37,6 +39,25 @@ type ShelfLifeStatusFilter struct {
 
 type ShelfLifeFilter struct {
 	PageFilter
+	UserID int
+	// ViewerID limits the result to shelf lives the user owns or keeps in
+	// storages shared with them. Zero disables the limit.
+	ViewerID      int
+	ProductID     int
+	StorageID     int
+	MeasureID     int
+	StatusID      int
+	PurchasedFrom *time.Time
+	PurchasedTo   *time.Time
+	EndFrom       *time.Time
+	EndTo         *time.Time
+	// ExpiresWithin limits the result to items ending today or in the next
+	// given number of days. Nil disables the limit.
+	ExpiresWithin *int
+	// SortBy is one of end_date, purchase_date or product. Empty keeps
+	// the newest items first.
+	SortBy     string
+	Descending bool
 }
 
 type StorageTypeFilter struct {
@@ -47,6 +68,9 @@ type StorageTypeFilter struct {
 type StorageFilter struct {
 	PageFilter
 	Name string
+	// ViewerID limits the result to storages shared with the user.
+	// Zero disables the limit.
+	ViewerID int
 }
 
 type TipFilter struct {
@@ -67,3 +91,28 @@ type ProductFilter struct {
 	PageFilter
 	Name string
 }
+
+type NotificationFilter struct {
+	PageFilter
+	UserID int
+	Unread bool
+}
+
+type WasteFilter struct {
+	// UserID limits the report to the user. Zero reports on all users.
+	UserID int
+	From   time.Time
+	To     time.Time
+	// Period is the bucket size: day, week or month.
+	Period string
+	// GroupBy is one of product, category or storage.
+	GroupBy string
+}
+
+// DetectionFilter selects the detections of the user, or of all users if the
+// user is not set, optionally only the corrected or uncorrected ones.
+type DetectionFilter struct {
+	PageFilter
+	UserID    int
+	Corrected *bool
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Roles of the household members. Owners manage the household, editors
+// change the storages and the shelf lives kept in them, viewers only see
+// them.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Household owns storages shared by its members. Role is the role of the
+// user the household is found for.
+type Household struct {
+	ID        int        `db:"id"`
+	Name      string     `db:"name"`
+	Role      string     `db:"role"`
+	CreatedAt *time.Time `db:"created_at"`
+}
+
+type HouseholdMember struct {
+	User     User
+	Role     string     `db:"role"`
+	JoinedAt *time.Time `db:"joined_at"`
+}
+
+// HouseholdInvitation lets a user join the household with the role. Only
+// the hash of the code is stored.
+type HouseholdInvitation struct {
+	ID          int        `db:"id"`
+	HouseholdID int        `db:"id_household"`
+	CodeHash    string     `db:"code_hash"`
+	Role        string     `db:"role"`
+	ExpiresAt   time.Time  `db:"expires_at"`
+	CreatedAt   *time.Time `db:"created_at"`
+}
@@ -1,6 +1,27 @@
 package models
 
+// Dimensions of the measures. Measures of the same dimension are converted
+// by their factors.
+const (
+	DimensionMass   = "mass"
+	DimensionVolume = "volume"
+	DimensionCount  = "count"
+)
+
+// Measure is a unit of quantity. Factor is the size of the measure in the
+// base unit of its dimension: gram, millilitre or piece. Empty dimension is
+// unknown.
 type Measure struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+	ID        int     `db:"id"`
+	Name      string  `db:"name"`
+	Dimension string  `db:"dimension"`
+	Factor    float32 `db:"factor"`
+}
+
+// ProductConversion relates the dimensions of a product. Density is grams
+// per millilitre and PieceWeight is grams per piece. Zero is unknown.
+type ProductConversion struct {
+	ProductID   int     `db:"id_product"`
+	Density     float32 `db:"density"`
+	PieceWeight float32 `db:"piece_weight"`
 }
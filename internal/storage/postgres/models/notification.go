@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type Notification struct {
+	ID        int `db:"id"`
+	User      User
+	ShelfLife ShelfLife
+	Threshold int        `db:"threshold"`
+	Title     string     `db:"title"`
+	Message   string     `db:"message"`
+	ReadAt    *time.Time `db:"read_at"`
+	CreatedAt *time.Time `db:"created_at"`
+}
+
+type NotificationDelivery struct {
+	UserID      int    `db:"id_user"`
+	ShelfLifeID int    `db:"id_shelf_life"`
+	Threshold   int    `db:"threshold"`
+	Channel     string `db:"channel"`
+}
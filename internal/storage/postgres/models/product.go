@@ -3,14 +3,23 @@ package models
 import "time"
 
 type Product struct {
-	ID        int        `db:"id"`
-	Name      string     `db:"name"`
-	UpdatedAt *time.Time `db:"updated_at"`
-	DeletedAt *time.Time `db:"deleted_at"`
+	ID               int        `db:"id"`
+	Name             string     `db:"name"`
+	UpdatedAt        *time.Time `db:"updated_at"`
+	DeletedAt        *time.Time `db:"deleted_at"`
+	DefaultDurations []DefaultDuration
 }
 
 type ProductCategory struct {
-	ID        int        `db:"id,id_category"`
-	Name      string     `db:"name"`
-	CreatedAt *time.Time `db:"created_at"`
+	ID               int        `db:"id,id_category"`
+	Name             string     `db:"name"`
+	CreatedAt        *time.Time `db:"created_at"`
+	DefaultDurations []DefaultDuration
+}
+
+// DefaultDuration is the usual lifetime of a product kept in a storage of the
+// type. Zero storage type applies to any storage.
+type DefaultDuration struct {
+	StorageType StorageType
+	Days        int `db:"days"`
 }
@@ -6,6 +6,7 @@ type Recipe struct {
 	ID          int    `db:"id"`
 	Name        string `db:"name"`
 	Description string `db:"description"`
+	Servings    int    `db:"servings"`
 	User        User
 	UpdatedAt   *time.Time `db:"updated_at"`
 	DeletedAt   *time.Time `db:"deleted_at"`
@@ -23,5 +24,5 @@ type RecipeIngredient struct {
 	Recipe   Recipe
 	Product  Product
 	Measure  Measure
-	Quantity int `db:"quantity"`
+	Quantity float32 `db:"quantity"`
 }
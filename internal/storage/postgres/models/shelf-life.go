@@ -12,6 +12,49 @@ type ShelfLife struct {
 	PurchaseDate *time.Time `db:"purchase_date"`
 	EndDate      *time.Time `db:"end_date"`
 	CreatedAt    *time.Time `db:"created_at"`
+	ClosedAt     *time.Time `db:"closed_at"`
+}
+
+// Types of shelf life events. Spoiled items are recorded as discarded.
+const (
+	ShelfLifeEventConsumed  = "consumed"
+	ShelfLifeEventDiscarded = "discarded"
+	ShelfLifeEventGivenAway = "given_away"
+)
+
+// ShelfLifeEvent records a part of a shelf life used up in one way or another.
+type ShelfLifeEvent struct {
+	ID          int        `db:"id"`
+	ShelfLifeID int        `db:"id_shelf_life"`
+	Type        string     `db:"type"`
+	Quantity    float32    `db:"quantity"`
+	OccurredAt  *time.Time `db:"occurred_at"`
+	CreatedAt   *time.Time `db:"created_at"`
+}
+
+// Sources of the rules the end date of a new shelf life is derived from.
+const (
+	ShelfLifeRuleProduct  = "product"
+	ShelfLifeRuleCategory = "category"
+)
+
+// ShelfLifeRule is a default duration of the product or of its category.
+type ShelfLifeRule struct {
+	Source   string
+	SourceID int
+	Name     string
+	DefaultDuration
+}
+
+// ShelfLifeScan is the image a shelf life was created from with the dates
+// detected on it.
+type ShelfLifeScan struct {
+	ID          int            `db:"id"`
+	ShelfLifeID int            `db:"id_shelf_life"`
+	Image       []byte         `db:"image"`
+	ContentType string         `db:"content_type"`
+	Dates       []DetectedDate `db:"dates"`
+	CreatedAt   *time.Time     `db:"created_at"`
 }
 
 type ShelfLifeStatus struct {
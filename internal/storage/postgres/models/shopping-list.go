@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type ShoppingList struct {
+	ID        int `db:"id"`
+	User      User
+	Name      string     `db:"name"`
+	CreatedAt *time.Time `db:"created_at"`
+	Items     []ShoppingListItem
+}
+
+// ShoppingListItem is a product to buy. Checked off items may be turned into
+// shelf lives, ShelfLifeID is zero otherwise.
+type ShoppingListItem struct {
+	ID          int `db:"id"`
+	ListID      int `db:"id_list"`
+	Product     Product
+	Measure     Measure
+	Quantity    float32    `db:"quantity"`
+	CheckedAt   *time.Time `db:"checked_at"`
+	ShelfLifeID int        `db:"id_shelf_life"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ShelfLifeOutcomeExpired marks the quantity left in shelf lives after their
+// end date and ShelfLifeOutcomeConsumedExpired the quantity consumed after
+// it. Other outcomes are the types of shelf life events.
+const (
+	ShelfLifeOutcomeExpired         = "expired"
+	ShelfLifeOutcomeConsumedExpired = "consumed_expired"
+)
+
+// WasteRecord is the amount of shelf lives with the same outcome within one
+// period bucket, group and measure.
+type WasteRecord struct {
+	Bucket    time.Time
+	GroupID   int
+	GroupName string
+	Measure   Measure
+	Outcome   string
+	Count     int
+	Quantity  float32
+}
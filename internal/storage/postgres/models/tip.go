@@ -3,4 +3,34 @@ package models
 type Tip struct {
 	ID          int    `db:"id,id_tip"`
 	Description string `db:"description"`
+	Rule        TipRule
+}
+
+// TipRule limits when a tip is shown for a shelf life. The tip is shown only
+// if all of the set conditions hold.
+type TipRule struct {
+	// ExpiresWithin is the maximum amount of days left until the end date.
+	ExpiresWithin    *int     `db:"expires_within"`
+	TemperatureAbove *float32 `db:"temperature_above"`
+	TemperatureBelow *float32 `db:"temperature_below"`
+}
+
+// IsZero reports whether the rule has no conditions.
+func (r TipRule) IsZero() bool {
+	return r.ExpiresWithin == nil && r.TemperatureAbove == nil && r.TemperatureBelow == nil
+}
+
+// Sources of the tips of a shelf life from the most to the least specific.
+const (
+	TipSourceProduct     = "product"
+	TipSourceCategory    = "category"
+	TipSourceStorage     = "storage"
+	TipSourceStorageType = "storage_type"
+)
+
+// SourcedTip is a tip found for a shelf life through its product, a category
+// of the product, its storage or the type of the storage.
+type SourcedTip struct {
+	Tip
+	Source string
 }
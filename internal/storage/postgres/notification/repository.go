@@ -0,0 +1,178 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+type NotificationRepositorer interface {
+	FindMany(ctx context.Context, filter models.NotificationFilter) ([]models.Notification, error)
+	Create(ctx context.Context, notification *models.Notification) error
+	SetRead(ctx context.Context, userID, id int, read bool) error
+	Count(ctx context.Context, filter models.NotificationFilter) (int, error)
+	MarkDelivered(ctx context.Context, delivery models.NotificationDelivery) (bool, error)
+	UnmarkDelivered(ctx context.Context, delivery models.NotificationDelivery) error
+}
+
+type notificationRepository struct {
+	client postgres.Client
+}
+
+func New(client postgres.Client) NotificationRepositorer {
+	return &notificationRepository{
+		client: client,
+	}
+}
+
+// FindMany implements NotificationRepositorer
+func (r *notificationRepository) FindMany(
+	ctx context.Context,
+	filter models.NotificationFilter,
+) ([]models.Notification, error) {
+	var (
+		query = `
+			SELECT id, id_shelf_life, threshold, title, message, read_at, created_at
+			FROM notifications
+			WHERE id_user = $1 AND
+				($2 = FALSE OR read_at IS NULL)
+			ORDER BY created_at DESC
+			LIMIT $3
+			OFFSET $4
+		`
+		notifications = make([]models.Notification, 0, filter.Limit)
+	)
+	rows, err := r.client.Query(ctx, query, filter.UserID, filter.Unread, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find notifications: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		notification := models.Notification{User: models.User{ID: filter.UserID}}
+		if err := rows.Scan(
+			&notification.ID,
+			&notification.ShelfLife.ID,
+			&notification.Threshold,
+			&notification.Title,
+			&notification.Message,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+// Create implements NotificationRepositorer
+func (r *notificationRepository) Create(
+	ctx context.Context,
+	notification *models.Notification,
+) error {
+	query := `
+		INSERT INTO notifications
+			(id_user, id_shelf_life, threshold, title, message)
+		VALUES
+			($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	if err := r.client.QueryRow(ctx, query,
+		notification.User.ID,
+		notification.ShelfLife.ID,
+		notification.Threshold,
+		notification.Title,
+		notification.Message,
+	).Scan(&notification.ID, &notification.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// SetRead implements NotificationRepositorer
+func (r *notificationRepository) SetRead(ctx context.Context, userID, id int, read bool) error {
+	query := `
+		UPDATE notifications
+		SET read_at = CASE WHEN $3 THEN COALESCE(read_at, NOW()) ELSE NULL END
+		WHERE id_user = $1 AND id = $2
+	`
+	tag, err := r.client.Exec(ctx, query, userID, id, read)
+	if err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("notification %d of user %d not found", id, userID)
+	}
+	return nil
+}
+
+// Count implements NotificationRepositorer
+func (r *notificationRepository) Count(
+	ctx context.Context,
+	filter models.NotificationFilter,
+) (int, error) {
+	var (
+		query = `
+			SELECT COUNT(*)
+			FROM notifications
+			WHERE id_user = $1 AND
+				($2 = FALSE OR read_at IS NULL)
+		`
+		count int
+	)
+	if err := r.client.QueryRow(ctx, query, filter.UserID, filter.Unread).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkDelivered implements NotificationRepositorer
+//
+// It returns false when the delivery has already been recorded.
+func (r *notificationRepository) MarkDelivered(
+	ctx context.Context,
+	delivery models.NotificationDelivery,
+) (bool, error) {
+	query := `
+		INSERT INTO notifications_deliveries
+			(id_user, id_shelf_life, threshold, channel)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`
+	tag, err := r.client.Exec(ctx, query,
+		delivery.UserID,
+		delivery.ShelfLifeID,
+		delivery.Threshold,
+		delivery.Channel,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark notification delivered: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UnmarkDelivered implements NotificationRepositorer
+func (r *notificationRepository) UnmarkDelivered(
+	ctx context.Context,
+	delivery models.NotificationDelivery,
+) error {
+	query := `
+		DELETE FROM notifications_deliveries
+		WHERE id_user = $1 AND
+			id_shelf_life = $2 AND
+			threshold = $3 AND
+			channel = $4
+	`
+	if _, err := r.client.Exec(ctx, query,
+		delivery.UserID,
+		delivery.ShelfLifeID,
+		delivery.Threshold,
+		delivery.Channel,
+	); err != nil {
+		return fmt.Errorf("failed to unmark notification delivered: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 )
@@ -11,6 +12,7 @@ import (
 type ProductRepositorer interface {
 	FindByID(ctx context.Context, id int) (models.Product, error)
 	FindMany(ctx context.Context, filter models.ProductFilter) ([]models.Product, error)
+	FindAll(ctx context.Context) ([]models.Product, error)
 	Create(ctx context.Context, product models.Product) error
 	Update(ctx context.Context, product models.Product) error
 	Delete(ctx context.Context, id int) error
@@ -23,6 +25,10 @@ type ProductRepositorer interface {
 	CreateTip(ctx context.Context, productID, tipID int) (models.Tip, error)
 	DeleteTip(ctx context.Context, productID, tipID int) error
 	Count(ctx context.Context, filter models.ProductFilter) (int, error)
+	FindDurations(ctx context.Context, id int) ([]models.DefaultDuration, error)
+	UpdateDurations(ctx context.Context, id int, durations []models.DefaultDuration) error
+	FindConversions(ctx context.Context, ids []int) ([]models.ProductConversion, error)
+	UpdateConversion(ctx context.Context, conversion models.ProductConversion) error
 }
 
 type productRepository struct {
@@ -150,6 +156,134 @@ func (r *productRepository) DeleteCategory(
 	return nil
 }
 
+// FindDurations implements ProductRepositorer
+func (r *productRepository) FindDurations(
+	ctx context.Context,
+	id int,
+) ([]models.DefaultDuration, error) {
+	var (
+		query = `
+			SELECT COALESCE(st.id, 0), COALESCE(st.name, ''), pd.days
+			FROM products_durations pd
+			LEFT JOIN storages_types st ON st.id = pd.id_type
+			WHERE pd.id_product = $1
+			ORDER BY st.name NULLS FIRST
+		`
+		durations []models.DefaultDuration
+	)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find durations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var duration models.DefaultDuration
+		if err := rows.Scan(
+			&duration.StorageType.ID,
+			&duration.StorageType.Name,
+			&duration.Days,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan duration: %w", err)
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
+
+// UpdateDurations implements ProductRepositorer
+//
+// The durations replace the ones the product had.
+func (r *productRepository) UpdateDurations(
+	ctx context.Context,
+	id int,
+	durations []models.DefaultDuration,
+) error {
+	var (
+		queryDelete = `
+			DELETE FROM products_durations
+			WHERE id_product = $1
+		`
+		queryInsert = `
+			INSERT INTO products_durations (id_product, id_type, days)
+			VALUES ($1, NULLIF($2, 0), $3)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, queryDelete, id); err != nil {
+		return fmt.Errorf("failed to delete durations: %w", err)
+	}
+	for _, duration := range durations {
+		if _, err := tx.Exec(ctx, queryInsert, id, duration.StorageType.ID, duration.Days); err != nil {
+			return fmt.Errorf("failed to insert duration: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// FindConversions implements ProductRepositorer
+//
+// Products without the conversion are skipped.
+func (r *productRepository) FindConversions(
+	ctx context.Context,
+	ids []int,
+) ([]models.ProductConversion, error) {
+	var (
+		query = `
+			SELECT id_product, COALESCE(density, 0), COALESCE(piece_weight, 0)
+			FROM products_conversions
+			WHERE id_product = ANY($1)
+			ORDER BY id_product
+		`
+		conversions []models.ProductConversion
+	)
+	rows, err := r.client.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find conversions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var conversion models.ProductConversion
+		if err := rows.Scan(
+			&conversion.ProductID,
+			&conversion.Density,
+			&conversion.PieceWeight,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan conversion: %w", err)
+		}
+		conversions = append(conversions, conversion)
+	}
+	return conversions, nil
+}
+
+// UpdateConversion implements ProductRepositorer
+func (r *productRepository) UpdateConversion(
+	ctx context.Context,
+	conversion models.ProductConversion,
+) error {
+	query := `
+		INSERT INTO products_conversions (id_product, density, piece_weight)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0))
+		ON CONFLICT (id_product) DO UPDATE
+		SET density = EXCLUDED.density,
+			piece_weight = EXCLUDED.piece_weight
+	`
+	if _, err := r.client.Exec(ctx, query,
+		conversion.ProductID,
+		conversion.Density,
+		conversion.PieceWeight,
+	); err != nil {
+		return fmt.Errorf("failed to update conversion: %w", err)
+	}
+	return nil
+}
+
 func New(client postgres.Client) ProductRepositorer {
 	return &productRepository{client: client}
 }
@@ -256,6 +390,32 @@ func (repo *productRepository) FindMany(
 	return products, nil
 }
 
+// FindAll implements ProductRepositorer
+func (repo *productRepository) FindAll(ctx context.Context) ([]models.Product, error) {
+	var (
+		query = `
+			SELECT id, name
+			FROM products
+			WHERE deleted_at IS NULL
+			ORDER BY id
+		`
+		products []models.Product
+	)
+	rows, err := repo.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find products: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(&product.ID, &product.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
 func (repo *productRepository) Create(ctx context.Context, product models.Product) error {
 	query := `
 			INSERT INTO products (name)
@@ -22,6 +22,7 @@ type RecipeIngredientsRepositorer interface {
 		entity *models.RecipeIngredient,
 	) (models.RecipeIngredient, error)
 	DeleteIngredient(ctx context.Context, recipeId, productId int) error
+	FindByProducts(ctx context.Context, productIDs []int) ([]models.Recipe, error)
 }
 
 type RecipeStepsRepositorer interface {
@@ -207,6 +208,60 @@ func (r *recipesRepository) FindIngredients(
 	return entities, nil
 }
 
+// FindByProducts implements RecipesRepositorer
+//
+// It returns the recipes using any of the products along with all of their
+// ingredients.
+func (r *recipesRepository) FindByProducts(
+	ctx context.Context,
+	productIDs []int,
+) ([]models.Recipe, error) {
+	var (
+		query = `
+			SELECT r.id, r.name, r.description, p.id, p.name, m.id, m.name, prm.quantity
+			FROM recipes r
+			JOIN products_recipes_measures prm ON prm.id_recipe = r.id
+			JOIN products p ON p.id = prm.id_product
+			JOIN measures m ON m.id = prm.id_measure
+			WHERE r.deleted_at IS NULL AND r.id IN (
+				SELECT id_recipe FROM products_recipes_measures
+				WHERE id_product = ANY($1)
+			)
+			ORDER BY r.id, p.id
+		`
+		recipes []models.Recipe
+	)
+	rows, err := r.client.Query(ctx, query, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			recipe     models.Recipe
+			ingredient models.RecipeIngredient
+		)
+		if err := rows.Scan(
+			&recipe.ID,
+			&recipe.Name,
+			&recipe.Description,
+			&ingredient.Product.ID,
+			&ingredient.Product.Name,
+			&ingredient.Measure.ID,
+			&ingredient.Measure.Name,
+			&ingredient.Quantity,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan recipe: %w", err)
+		}
+		if n := len(recipes); n == 0 || recipes[n-1].ID != recipe.ID {
+			recipes = append(recipes, recipe)
+		}
+		last := &recipes[len(recipes)-1]
+		last.Ingredients = append(last.Ingredients, ingredient)
+	}
+	return recipes, nil
+}
+
 // UpdateIngredient implements RecipesRepositorer
 func (r *recipesRepository) UpdateIngredient(
 	ctx context.Context,
@@ -248,7 +303,7 @@ func New(client postgres.Client) RecipesRepositorer {
 func (r *recipesRepository) FindByID(ctx context.Context, id int) (models.Recipe, error) {
 	var (
 		query = `
-			SELECT id, name, description 
+			SELECT id, name, description, COALESCE(servings, 1)
 			FROM recipes 
 			WHERE id = $1
 		`
@@ -261,7 +316,12 @@ func (r *recipesRepository) FindByID(ctx context.Context, id int) (models.Recipe
 		`
 		recipe models.Recipe
 	)
-	if err := r.client.QueryRow(ctx, query, id).Scan(&recipe.ID, &recipe.Name, &recipe.Description); err != nil {
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&recipe.ID,
+		&recipe.Name,
+		&recipe.Description,
+		&recipe.Servings,
+	); err != nil {
 		return models.Recipe{}, fmt.Errorf("failed to query recipe: %w", err)
 	}
 	rows, err := r.client.Query(ctx, querySteps, id)
@@ -285,7 +345,7 @@ func (r *recipesRepository) FindMany(
 ) ([]models.Recipe, error) {
 	var (
 		query = `
-			SELECT id, name, description
+			SELECT id, name, description, COALESCE(servings, 1)
 			FROM recipes
 			WHERE name LIKE $1 AND 
 				deleted_at IS NULL
@@ -302,7 +362,7 @@ func (r *recipesRepository) FindMany(
 	defer rows.Close()
 	for rows.Next() {
 		var recipe models.Recipe
-		if err := rows.Scan(&recipe.ID, &recipe.Name, &recipe.Description); err != nil {
+		if err := rows.Scan(&recipe.ID, &recipe.Name, &recipe.Description, &recipe.Servings); err != nil {
 			return nil, fmt.Errorf("failed to scan recipe: %w", err)
 		}
 		recipes = append(recipes, recipe)
@@ -313,9 +373,9 @@ func (r *recipesRepository) FindMany(
 func (r *recipesRepository) Create(ctx context.Context, recipe *models.Recipe) error {
 	query := `
 			INSERT INTO recipes
-				(id_user, name, description)
+				(id_user, name, description, servings)
 			VALUES
-				($1, $2, $3)
+				($1, $2, $3, COALESCE(NULLIF($4, 0), 1))
 			RETURNING id
 		`
 	tx, err := r.client.Begin(ctx)
@@ -323,7 +383,7 @@ func (r *recipesRepository) Create(ctx context.Context, recipe *models.Recipe) e
 		return err
 	}
 	defer tx.Rollback(ctx)
-	if err := tx.QueryRow(ctx, query, recipe.User.ID, recipe.Name, recipe.Description).Scan(&recipe.ID); err != nil {
+	if err := tx.QueryRow(ctx, query, recipe.User.ID, recipe.Name, recipe.Description, recipe.Servings).Scan(&recipe.ID); err != nil {
 		return fmt.Errorf("failed to create recipe: %w", err)
 	}
 	if _, err = tx.CopyFrom(ctx,
@@ -355,10 +415,16 @@ func (r *recipesRepository) Update(ctx context.Context, recipe *models.Recipe) e
 			UPDATE recipes
 			SET name = $1,
 				description = $2,
+				servings = $3,
 				updated_at = NOW()
-			WHERE id = $3
+			WHERE id = $4
 		`
-	if _, err := r.client.Exec(ctx, query, recipe.Name, recipe.Description, recipe.ID); err != nil {
+	if _, err := r.client.Exec(ctx, query,
+		recipe.Name,
+		recipe.Description,
+		recipe.Servings,
+		recipe.ID,
+	); err != nil {
 		return fmt.Errorf("failed to update recipe: %w", err)
 	}
 	return nil
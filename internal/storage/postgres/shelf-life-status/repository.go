@@ -10,6 +10,7 @@ import (
 
 type ShelfLifeStatusRepositorer interface {
 	FindByID(ctx context.Context, id int) (models.ShelfLifeStatus, error)
+	FindByName(ctx context.Context, name string) (models.ShelfLifeStatus, error)
 	FindMany(
 		ctx context.Context,
 		filter models.ShelfLifeStatusFilter,
@@ -89,6 +90,26 @@ func (r *shelfLifeStatusRepository) FindByID(
 	return shelfLifeStatus, nil
 }
 
+// FindByName implements ShelfLifeStatusRepositorer
+func (r *shelfLifeStatusRepository) FindByName(
+	ctx context.Context,
+	name string,
+) (models.ShelfLifeStatus, error) {
+	var (
+		query = `
+			SELECT id, name
+			FROM statuses
+			WHERE name = $1
+			LIMIT 1
+		`
+		shelfLifeStatus models.ShelfLifeStatus
+	)
+	if err := r.client.QueryRow(ctx, query, name).Scan(&shelfLifeStatus.ID, &shelfLifeStatus.Name); err != nil {
+		return models.ShelfLifeStatus{}, fmt.Errorf("failed to find shelfLifeStatus: %w", err)
+	}
+	return shelfLifeStatus, nil
+}
+
 // FindMany implements ShelfLifeStatusRepositorer
 func (r *shelfLifeStatusRepository) FindMany(
 	ctx context.Context,
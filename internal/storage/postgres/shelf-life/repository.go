@@ -2,8 +2,11 @@ package shelflife
 
 import (
 	"context"
+	errs "errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 )
@@ -11,7 +14,9 @@ import (
 type ShelfLifeRepositorer interface {
 	FindByID(ctx context.Context, id int) (models.ShelfLife, error)
 	FindMany(ctx context.Context, filter models.ShelfLifeFilter) ([]models.ShelfLife, error)
-	Create(ctx context.Context, measure models.ShelfLife) error
+	Create(ctx context.Context, measure *models.ShelfLife) error
+	CreateScanned(ctx context.Context, model *models.ShelfLife, scan *models.ShelfLifeScan) error
+	FindScan(ctx context.Context, id int) (models.ShelfLifeScan, error)
 	Update(ctx context.Context, measure models.ShelfLife) error
 	Delete(ctx context.Context, id int) error
 	Restore(ctx context.Context, id int) error
@@ -19,28 +24,425 @@ type ShelfLifeRepositorer interface {
 	DeleteStatus(ctx context.Context, id, statusID int) error
 	FindStatuses(ctx context.Context, id int) ([]models.ShelfLifeStatus, error)
 	Count(ctx context.Context, filter models.ShelfLifeFilter) (int, error)
+	FindExpirable(ctx context.Context) ([]models.ShelfLife, error)
+	IsAccessible(ctx context.Context, id, userID int) (bool, error)
+	IsStorageAccessible(ctx context.Context, storageID, userID int) (bool, error)
+	IsWritable(ctx context.Context, id, userID int) (bool, error)
+	IsStorageWritable(ctx context.Context, storageID, userID int) (bool, error)
+	ReplaceStatus(ctx context.Context, id, statusID int, managedIDs []int) (bool, error)
+	CreateEvent(ctx context.Context, event *models.ShelfLifeEvent, consume ConsumeFunc) (float32, bool, error)
+	FindEvents(ctx context.Context, id int) ([]models.ShelfLifeEvent, error)
+	FindRules(ctx context.Context, productID, storageID int) ([]models.ShelfLifeRule, error)
+	FindTips(ctx context.Context, productID, storageID int) ([]models.SourcedTip, error)
+}
+
+// ConsumeFunc returns the quantity remaining in the locked shelf life once
+// the event is recorded and whether the shelf life is closed by it.
+type ConsumeFunc func(shelfLife models.ShelfLife, event models.ShelfLifeEvent) (float32, bool, error)
+
+// filterConditions selects shelf lives matching the models.ShelfLifeFilter
+// passed as the first eleven arguments by filterArgs.
+var filterConditions = `
+	sl.deleted_at IS NULL AND
+	sl.closed_at IS NULL AND
+	($1 = 0 OR sl.id_user = $1) AND
+	($2 = 0 OR sl.id_product = $2) AND
+	($3 = 0 OR sl.id_storage = $3) AND
+	($4 = 0 OR sl.id_measure = $4) AND
+	($5 = 0 OR EXISTS (
+		SELECT 1 FROM shelf_lives_statuses sls
+		WHERE sls.id_shelf_life = sl.id AND sls.id_status = $5
+	)) AND
+	($6::date IS NULL OR sl.purchase_date >= $6::date) AND
+	($7::date IS NULL OR sl.purchase_date < $7::date + 1) AND
+	($8::date IS NULL OR sl.end_date >= $8::date) AND
+	($9::date IS NULL OR sl.end_date < $9::date + 1) AND
+	($10::int IS NULL OR (
+		sl.end_date >= CURRENT_DATE AND
+		sl.end_date < CURRENT_DATE + $10::int + 1
+	)) AND
+	($11 = 0 OR sl.id_user = $11 OR sl.id_storage IN (` + postgres.AccessibleStorages("$11", true) + `))
+`
+
+func filterArgs(filter models.ShelfLifeFilter) []any {
+	return []any{
+		filter.UserID,
+		filter.ProductID,
+		filter.StorageID,
+		filter.MeasureID,
+		filter.StatusID,
+		filter.PurchasedFrom,
+		filter.PurchasedTo,
+		filter.EndFrom,
+		filter.EndTo,
+		filter.ExpiresWithin,
+		filter.ViewerID,
+	}
 }
 
 type shelfLifeRepository struct {
 	client postgres.Client
 }
 
+// Count implements ShelfLifeRepositorer
 func (r *shelfLifeRepository) Count(
 	ctx context.Context,
 	filter models.ShelfLifeFilter,
 ) (int, error) {
 	var (
 		query = `
-			SELECT COUNT(*) FROM shelf_lives WHERE deleted_at IS NULL
-		`
+			SELECT COUNT(*)
+			FROM shelf_lives sl
+			WHERE ` + filterConditions
 		count int
 	)
-	if err := r.client.QueryRow(ctx, query).Scan(&count); err != nil {
+	if err := r.client.QueryRow(ctx, query, filterArgs(filter)...).Scan(&count); err != nil {
 		return 0, fmt.Errorf("failed to count shelf lives: %w", err)
 	}
 	return count, nil
 }
 
+// CreateEvent implements ShelfLifeRepositorer
+//
+// It locks the shelf life, records the event and sets the quantity returned
+// by consume in one transaction. The shelf life is closed if consume says so.
+// It returns the remaining quantity and whether the shelf life has been
+// closed.
+func (r *shelfLifeRepository) CreateEvent(
+	ctx context.Context,
+	event *models.ShelfLifeEvent,
+	consume ConsumeFunc,
+) (float32, bool, error) {
+	var (
+		querySelect = `
+			SELECT id, quantity, closed_at
+			FROM shelf_lives
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+		`
+		queryInsert = `
+			INSERT INTO shelf_lives_events (id_shelf_life, type, quantity, occurred_at)
+			VALUES ($1, $2, $3, COALESCE($4, NOW()))
+			RETURNING id, occurred_at, created_at
+		`
+		queryUpdate = `
+			UPDATE shelf_lives
+			SET quantity = $2,
+				closed_at = CASE WHEN $3 THEN NOW() ELSE closed_at END,
+				updated_at = NOW()
+			WHERE id = $1
+		`
+		shelfLife models.ShelfLife
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return 0, false, errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, querySelect, event.ShelfLifeID).
+		Scan(&shelfLife.ID, &shelfLife.Quantity, &shelfLife.ClosedAt); err != nil {
+		return 0, false, fmt.Errorf("failed to find shelf life: %w", err)
+	}
+	remaining, closed, err := consume(shelfLife, *event)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := tx.QueryRow(ctx, queryInsert, event.ShelfLifeID, event.Type, event.Quantity, event.OccurredAt).
+		Scan(&event.ID, &event.OccurredAt, &event.CreatedAt); err != nil {
+		return 0, false, fmt.Errorf("failed to insert shelf life event: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryUpdate, event.ShelfLifeID, remaining, closed); err != nil {
+		return 0, false, fmt.Errorf("failed to update shelf life quantity: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return remaining, closed, nil
+}
+
+// FindRules implements ShelfLifeRepositorer
+//
+// The rules are the default durations of the product and of its categories
+// applicable to the type of the storage.
+func (r *shelfLifeRepository) FindRules(
+	ctx context.Context,
+	productID, storageID int,
+) ([]models.ShelfLifeRule, error) {
+	var (
+		query = `
+			WITH durations AS (
+				SELECT 'product' AS source, p.id, p.name, pd.id_type, pd.days
+				FROM products_durations pd
+				JOIN products p ON p.id = pd.id_product
+				WHERE pd.id_product = $1
+				UNION ALL
+				SELECT 'category', c.id, c.name, cd.id_type, cd.days
+				FROM categories_durations cd
+				JOIN categories c ON c.id = cd.id_category
+				JOIN products_categories pc ON pc.id_category = c.id
+				WHERE pc.id_product = $1 AND c.deleted_at IS NULL
+			)
+			SELECT d.source, d.id, d.name, COALESCE(st.id, 0), COALESCE(st.name, ''), d.days
+			FROM durations d
+			LEFT JOIN storages_types st ON st.id = d.id_type
+			WHERE d.id_type IS NULL
+				OR d.id_type = (SELECT id_type FROM storages WHERE id = $2)
+		`
+		rules []models.ShelfLifeRule
+	)
+	rows, err := r.client.Query(ctx, query, productID, storageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rules: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rule models.ShelfLifeRule
+		if err := rows.Scan(
+			&rule.Source,
+			&rule.SourceID,
+			&rule.Name,
+			&rule.StorageType.ID,
+			&rule.StorageType.Name,
+			&rule.Days,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// FindTips implements ShelfLifeRepositorer
+//
+// The tips are the ones of the product, of its categories, of the storage and
+// of the type of the storage. A tip attached to several of them is returned
+// once for each.
+func (r *shelfLifeRepository) FindTips(
+	ctx context.Context,
+	productID, storageID int,
+) ([]models.SourcedTip, error) {
+	var (
+		query = `
+			WITH sources AS (
+				SELECT '` + models.TipSourceProduct + `' AS source, id_tip
+				FROM products_tips
+				WHERE id_product = $1
+				UNION ALL
+				SELECT '` + models.TipSourceCategory + `', ct.id_tip
+				FROM categories_tips ct
+				JOIN products_categories pc ON pc.id_category = ct.id_category
+				JOIN categories c ON c.id = ct.id_category
+				WHERE pc.id_product = $1 AND c.deleted_at IS NULL
+				UNION ALL
+				SELECT '` + models.TipSourceStorage + `', id_tip
+				FROM storages_tips
+				WHERE id_storage = $2
+				UNION ALL
+				SELECT '` + models.TipSourceStorageType + `', stt.id_tip
+				FROM storages_types_tips stt
+				JOIN storages s ON s.id_type = stt.id_storage_type
+				WHERE s.id = $2
+			)
+			SELECT s.source, t.id, t.description, t.expires_within, t.temperature_above, t.temperature_below
+			FROM sources s
+			JOIN tips t ON t.id = s.id_tip
+			WHERE t.deleted_at IS NULL
+		`
+		tips []models.SourcedTip
+	)
+	rows, err := r.client.Query(ctx, query, productID, storageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tips: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tip models.SourcedTip
+		if err := rows.Scan(
+			&tip.Source,
+			&tip.ID,
+			&tip.Description,
+			&tip.Rule.ExpiresWithin,
+			&tip.Rule.TemperatureAbove,
+			&tip.Rule.TemperatureBelow,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan tip: %w", err)
+		}
+		tips = append(tips, tip)
+	}
+	return tips, nil
+}
+
+// FindEvents implements ShelfLifeRepositorer
+func (r *shelfLifeRepository) FindEvents(ctx context.Context, id int) ([]models.ShelfLifeEvent, error) {
+	var (
+		query = `
+			SELECT id, id_shelf_life, type, quantity, occurred_at, created_at
+			FROM shelf_lives_events
+			WHERE id_shelf_life = $1
+			ORDER BY occurred_at ASC, id ASC
+		`
+		events []models.ShelfLifeEvent
+	)
+	rows, err := r.client.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shelf life events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event models.ShelfLifeEvent
+		if err := rows.Scan(
+			&event.ID, &event.ShelfLifeID, &event.Type,
+			&event.Quantity, &event.OccurredAt, &event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan shelf life event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// IsAccessible implements ShelfLifeRepositorer
+//
+// A shelf life is accessible to its owner, to the users the storage it is
+// kept in is shared with and to the members of the household owning the
+// storage.
+func (r *shelfLifeRepository) IsAccessible(ctx context.Context, id, userID int) (bool, error) {
+	return r.isAccessible(ctx, id, userID, true)
+}
+
+// IsWritable implements ShelfLifeRepositorer
+//
+// It is IsAccessible without the household viewers.
+func (r *shelfLifeRepository) IsWritable(ctx context.Context, id, userID int) (bool, error) {
+	return r.isAccessible(ctx, id, userID, false)
+}
+
+func (r *shelfLifeRepository) isAccessible(
+	ctx context.Context,
+	id, userID int,
+	read bool,
+) (bool, error) {
+	var (
+		query = `
+			SELECT EXISTS (
+				SELECT 1
+				FROM shelf_lives sl
+				WHERE sl.id = $1 AND (
+					sl.id_user = $2 OR sl.id_storage IN (` + postgres.AccessibleStorages("$2", read) + `)
+				)
+			)
+		`
+		ok bool
+	)
+	if err := r.client.QueryRow(ctx, query, id, userID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check shelf life access: %w", err)
+	}
+	return ok, nil
+}
+
+// IsStorageAccessible implements ShelfLifeRepositorer
+func (r *shelfLifeRepository) IsStorageAccessible(
+	ctx context.Context,
+	storageID, userID int,
+) (bool, error) {
+	return r.isStorageAccessible(ctx, storageID, userID, true)
+}
+
+// IsStorageWritable implements ShelfLifeRepositorer
+func (r *shelfLifeRepository) IsStorageWritable(
+	ctx context.Context,
+	storageID, userID int,
+) (bool, error) {
+	return r.isStorageAccessible(ctx, storageID, userID, false)
+}
+
+func (r *shelfLifeRepository) isStorageAccessible(
+	ctx context.Context,
+	storageID, userID int,
+	read bool,
+) (bool, error) {
+	var (
+		query = `
+			SELECT $1 IN (` + postgres.AccessibleStorages("$2", read) + `)
+		`
+		ok bool
+	)
+	if err := r.client.QueryRow(ctx, query, storageID, userID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check storage access: %w", err)
+	}
+	return ok, nil
+}
+
+// FindExpirable implements ShelfLifeRepositorer
+func (r *shelfLifeRepository) FindExpirable(ctx context.Context) ([]models.ShelfLife, error) {
+	var (
+		query = `
+			SELECT id, end_date
+			FROM shelf_lives
+			WHERE deleted_at IS NULL AND closed_at IS NULL AND end_date IS NOT NULL
+			ORDER BY id ASC
+		`
+		shelfLives []models.ShelfLife
+	)
+	rows, err := r.client.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expirable shelf lives: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var shelfLife models.ShelfLife
+		if err := rows.Scan(&shelfLife.ID, &shelfLife.EndDate); err != nil {
+			return nil, fmt.Errorf("failed to scan shelf life: %w", err)
+		}
+		shelfLives = append(shelfLives, shelfLife)
+	}
+	return shelfLives, nil
+}
+
+// ReplaceStatus implements ShelfLifeRepositorer
+//
+// It attaches the status to the shelf life and detaches every other status
+// from managedIDs in one transaction. It reports whether anything changed.
+func (r *shelfLifeRepository) ReplaceStatus(
+	ctx context.Context,
+	id, statusID int,
+	managedIDs []int,
+) (bool, error) {
+	var (
+		queryDelete = `
+			DELETE FROM shelf_lives_statuses
+			WHERE id_shelf_life = $1 AND
+				id_status = ANY($2) AND
+				id_status <> $3
+		`
+		queryInsert = `
+			INSERT INTO shelf_lives_statuses (id_shelf_life, id_status)
+			SELECT $1, $2
+			WHERE NOT EXISTS (
+				SELECT 1 FROM shelf_lives_statuses
+				WHERE id_shelf_life = $1 AND id_status = $2
+			)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return false, errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	deleted, err := tx.Exec(ctx, queryDelete, id, managedIDs, statusID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete shelf life statuses: %w", err)
+	}
+	inserted, err := tx.Exec(ctx, queryInsert, id, statusID)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert shelf life status: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return deleted.RowsAffected()+inserted.RowsAffected() > 0, nil
+}
+
 // CreateStatus implements ShelfLifeRepositorer
 func (r *shelfLifeRepository) CreateStatus(
 	ctx context.Context,
@@ -110,19 +512,100 @@ func (r *shelfLifeRepository) FindStatuses(
 }
 
 // Create implements ShelfLifeRepositorer
-func (r *shelfLifeRepository) Create(ctx context.Context, model models.ShelfLife) error {
+func (r *shelfLifeRepository) Create(ctx context.Context, model *models.ShelfLife) error {
 	query := `
 			INSERT INTO shelf_lives
 				(id_product, id_storage, id_measure, id_user, quantity, purchase_date, end_date)
 			VALUES
 				($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`
+	if err := r.client.QueryRow(ctx, query, model.Product.ID, model.Storage.ID, model.Measure.ID, model.User.ID, model.Quantity, model.PurchaseDate, model.EndDate).Scan(&model.ID); err != nil {
+		return fmt.Errorf("failed to create shelf life: %w", err)
+	}
+	return nil
+}
+
+// CreateScanned implements ShelfLifeRepositorer
+//
+// It creates the shelf life and the scan it was created from in one
+// transaction.
+func (r *shelfLifeRepository) CreateScanned(
+	ctx context.Context,
+	model *models.ShelfLife,
+	scan *models.ShelfLifeScan,
+) error {
+	var (
+		queryShelfLife = `
+			INSERT INTO shelf_lives
+				(id_product, id_storage, id_measure, id_user, quantity, purchase_date, end_date)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`
+		queryScan = `
+			INSERT INTO shelf_lives_scans
+				(id_shelf_life, image, content_type, dates)
+			VALUES
+				($1, $2, $3, $4)
+			RETURNING id, created_at
 		`
-	if _, err := r.client.Exec(ctx, query, model.Product.ID, model.Storage.ID, model.Measure.ID, model.User.ID, model.Quantity, model.PurchaseDate, model.EndDate); err != nil {
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, queryShelfLife,
+		model.Product.ID,
+		model.Storage.ID,
+		model.Measure.ID,
+		model.User.ID,
+		model.Quantity,
+		model.PurchaseDate,
+		model.EndDate,
+	).Scan(&model.ID); err != nil {
 		return fmt.Errorf("failed to create shelf life: %w", err)
 	}
+	scan.ShelfLifeID = model.ID
+	if err := tx.QueryRow(ctx, queryScan,
+		scan.ShelfLifeID,
+		scan.Image,
+		scan.ContentType,
+		scan.Dates,
+	).Scan(&scan.ID, &scan.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create shelf life scan: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
 	return nil
 }
 
+// FindScan implements ShelfLifeRepositorer
+func (r *shelfLifeRepository) FindScan(ctx context.Context, id int) (models.ShelfLifeScan, error) {
+	query := `
+		SELECT id, id_shelf_life, image, content_type, dates, created_at
+		FROM shelf_lives_scans
+		WHERE id_shelf_life = $1
+	`
+	var scan models.ShelfLifeScan
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&scan.ID,
+		&scan.ShelfLifeID,
+		&scan.Image,
+		&scan.ContentType,
+		&scan.Dates,
+		&scan.CreatedAt,
+	); err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return models.ShelfLifeScan{}, fmt.Errorf("shelf life %d: %w", id, errors.ErrScanNotFound)
+		}
+		return models.ShelfLifeScan{}, fmt.Errorf("failed to find shelf life scan: %w", err)
+	}
+	return scan, nil
+}
+
 // Delete implements ShelfLifeRepositorer
 func (r *shelfLifeRepository) Delete(ctx context.Context, id int) error {
 	query := `
@@ -144,9 +627,9 @@ func (r *shelfLifeRepository) FindByID(ctx context.Context, id int) (models.Shel
 			SELECT 
 				sl.id, 
 				sl.id_product, p.name,
-				sl.id_storage, s.name,
+				sl.id_storage, s.name, s.temperature,
 				sl.id_measure, m.name,
-				sl.quantity, sl.purchase_date, sl.end_date
+				sl.quantity, sl.purchase_date, sl.end_date, sl.closed_at
 			FROM shelf_lives sl
 			JOIN products p ON p.id = sl.id_product
 			JOIN storages s ON s.id = sl.id_storage
@@ -156,13 +639,15 @@ func (r *shelfLifeRepository) FindByID(ctx context.Context, id int) (models.Shel
 		`
 		model models.ShelfLife
 	)
-	if err := r.client.QueryRow(ctx, query, id).Scan(&model.ID, &model.Product.ID, &model.Product.Name, &model.Storage.ID, &model.Storage.Name, &model.Measure.ID, &model.Measure.Name, &model.Quantity, &model.PurchaseDate, &model.EndDate); err != nil {
+	if err := r.client.QueryRow(ctx, query, id).Scan(&model.ID, &model.Product.ID, &model.Product.Name, &model.Storage.ID, &model.Storage.Name, &model.Storage.Temperature, &model.Measure.ID, &model.Measure.Name, &model.Quantity, &model.PurchaseDate, &model.EndDate, &model.ClosedAt); err != nil {
 		return models.ShelfLife{}, fmt.Errorf("failed to find shelf life: %w", err)
 	}
 	return model, nil
 }
 
 // FindMany implements ShelfLifeRepositorer
+//
+// Zero limit returns every shelf life matching the filter.
 func (r *shelfLifeRepository) FindMany(ctx context.Context, filter models.ShelfLifeFilter) ([]models.ShelfLife, error) {
 	var (
 		query = `
@@ -175,14 +660,26 @@ func (r *shelfLifeRepository) FindMany(ctx context.Context, filter models.ShelfL
 			JOIN products p ON p.id = sl.id_product
 			JOIN storages s ON s.id = sl.id_storage
 			JOIN measures m ON m.id = sl.id_measure
-			WHERE sl.deleted_at IS NULL
-			ORDER BY sl.created_at DESC
-			LIMIT $1 
-			OFFSET $2
+			WHERE ` + filterConditions + `
+			ORDER BY
+				CASE WHEN $12 = 'end_date' AND NOT $13 THEN sl.end_date END ASC NULLS LAST,
+				CASE WHEN $12 = 'end_date' AND $13 THEN sl.end_date END DESC NULLS LAST,
+				CASE WHEN $12 = 'purchase_date' AND NOT $13 THEN sl.purchase_date END ASC NULLS LAST,
+				CASE WHEN $12 = 'purchase_date' AND $13 THEN sl.purchase_date END DESC NULLS LAST,
+				CASE WHEN $12 = 'product' AND NOT $13 THEN p.name END ASC,
+				CASE WHEN $12 = 'product' AND $13 THEN p.name END DESC,
+				sl.created_at DESC,
+				sl.id DESC
+			LIMIT NULLIF($14::int, 0)
+			OFFSET $15
 		`
 		shelfLives = make([]models.ShelfLife, 0, filter.Limit)
+		args       = append(
+			filterArgs(filter),
+			filter.SortBy, filter.Descending, filter.Limit, filter.Offset,
+		)
 	)
-	rows, err := r.client.Query(ctx, query, filter.Limit, filter.Offset)
+	rows, err := r.client.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find shelf lives: %w", err)
 	}
@@ -204,16 +701,23 @@ func (r *shelfLifeRepository) FindMany(ctx context.Context, filter models.ShelfL
 }
 
 // Restore implements ShelfLifeRepositorer
+//
+// The closed shelf lives are not restored, errors.ErrShelfLifeClosed is
+// returned instead.
 func (r *shelfLifeRepository) Restore(ctx context.Context, id int) error {
 	query := `
 			UPDATE shelf_lives
 			SET deleted_at = NULL,
 				updated_at = NOW()
-			WHERE id = $1
+			WHERE id = $1 AND closed_at IS NULL
 		`
-	if _, err := r.client.Exec(ctx, query, id); err != nil {
+	tag, err := r.client.Exec(ctx, query, id)
+	if err != nil {
 		return fmt.Errorf("failed to restore shelf life: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("shelf life %d: %w", id, errors.ErrShelfLifeClosed)
+	}
 	return nil
 }
 
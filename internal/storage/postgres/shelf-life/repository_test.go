@@ -0,0 +1,94 @@
+package shelflife
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var errRecorded = errs.New("recorded")
+
+// recordingClient records the last query and its arguments instead of
+// running it.
+type recordingClient struct {
+	postgres.Client
+	sql  string
+	args []any
+}
+
+func (c *recordingClient) Query(_ context.Context, sql string, args ...any) (pgx.Rows, error) {
+	c.sql, c.args = sql, args
+	return nil, errRecorded
+}
+
+func (c *recordingClient) QueryRow(_ context.Context, sql string, args ...any) pgx.Row {
+	c.sql, c.args = sql, args
+	return recordedRow{}
+}
+
+type recordedRow struct{}
+
+func (recordedRow) Scan(...any) error {
+	return errRecorded
+}
+
+func Test_FilterQueries(t *testing.T) {
+	var (
+		day    = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		within = 3
+		filter = models.ShelfLifeFilter{
+			PageFilter:    models.PageFilter{Limit: 10, Offset: 20},
+			UserID:        1,
+			ViewerID:      2,
+			ProductID:     3,
+			StorageID:     4,
+			MeasureID:     5,
+			StatusID:      6,
+			PurchasedFrom: &day,
+			PurchasedTo:   &day,
+			EndFrom:       &day,
+			EndTo:         &day,
+			ExpiresWithin: &within,
+			SortBy:        "end_date",
+			Descending:    true,
+		}
+		client = &recordingClient{}
+		repo   = New(client)
+	)
+
+	_, err := repo.Count(context.Background(), filter)
+	assert.True(t, errs.Is(err, errRecorded), err)
+	countSQL, countArgs := client.sql, client.args
+	_, err = repo.FindMany(context.Background(), filter)
+	assert.True(t, errs.Is(err, errRecorded), err)
+
+	// The count is of the same shelf lives as the list.
+	assert.Contains(t, countSQL, filterConditions)
+	assert.Contains(t, client.sql, filterConditions)
+	assert.Equal(t, countArgs, client.args[:len(countArgs)])
+	assert.Equal(t, []any{
+		1, 3, 4, 5, 6, &day, &day, &day, &day, &within, 2,
+	}, countArgs)
+	assert.Equal(t, []any{"end_date", true, 10, 20}, client.args[len(countArgs):])
+	// Each filter placeholder is used by the conditions.
+	for i := range countArgs {
+		placeholder := regexp.MustCompile(fmt.Sprintf(`\$%d\b`, i+1))
+		assert.True(t, placeholder.MatchString(filterConditions), placeholder)
+	}
+}
+
+func Test_FindManyWithoutLimit(t *testing.T) {
+	client := &recordingClient{}
+	_, err := New(client).FindMany(context.Background(), models.ShelfLifeFilter{})
+	assert.True(t, errs.Is(err, errRecorded), err)
+	assert.Contains(t, client.sql, "LIMIT NULLIF($14::int, 0)")
+	assert.Equal(t, 0, client.args[13])
+}
@@ -0,0 +1,410 @@
+package shoppinglist
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+// ShoppingListRepositorer keeps the shopping lists of the users. Lists and
+// their items are always looked up together with the owner, so lists of
+// other users are reported as errors.ErrShoppingListNotFound.
+type ShoppingListRepositorer interface {
+	FindMany(ctx context.Context, userID int) ([]models.ShoppingList, error)
+	FindByID(ctx context.Context, userID, id int) (models.ShoppingList, error)
+	Create(ctx context.Context, list *models.ShoppingList) error
+	Delete(ctx context.Context, userID, id int) error
+	FindItems(ctx context.Context, userID, id int) ([]models.ShoppingListItem, error)
+	FindItem(ctx context.Context, userID, id, itemID int) (models.ShoppingListItem, error)
+	CreateItem(ctx context.Context, userID int, item *models.ShoppingListItem) error
+	MergeItems(ctx context.Context, userID, id int, items []models.ShoppingListItem) error
+	DeleteItem(ctx context.Context, userID, id, itemID int) error
+	CheckItem(ctx context.Context, userID, id, itemID int, checked bool) error
+	StockItem(ctx context.Context, userID, id, itemID int, shelfLife *models.ShelfLife) error
+}
+
+type shoppingListRepository struct {
+	client postgres.Client
+}
+
+// itemsQuery selects the items of the shopping list $2 of the user $1.
+const itemsQuery = `
+	SELECT i.id, i.id_list, p.id, p.name, m.id, m.name, i.quantity, i.checked_at,
+		COALESCE(i.id_shelf_life, 0)
+	FROM shopping_lists_items i
+	JOIN shopping_lists l ON l.id = i.id_list
+	JOIN products p ON p.id = i.id_product
+	JOIN measures m ON m.id = i.id_measure
+	WHERE l.id_user = $1 AND l.id = $2
+`
+
+// FindMany implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindMany(
+	ctx context.Context,
+	userID int,
+) ([]models.ShoppingList, error) {
+	var (
+		query = `
+			SELECT id, name, created_at
+			FROM shopping_lists
+			WHERE id_user = $1
+			ORDER BY created_at DESC, id DESC
+		`
+		lists = make([]models.ShoppingList, 0)
+	)
+	rows, err := r.client.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shopping lists: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		list := models.ShoppingList{User: models.User{ID: userID}}
+		if err := rows.Scan(&list.ID, &list.Name, &list.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shopping list: %w", err)
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// FindByID implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindByID(
+	ctx context.Context,
+	userID, id int,
+) (models.ShoppingList, error) {
+	var (
+		query = `
+			SELECT id, name, created_at
+			FROM shopping_lists
+			WHERE id_user = $1 AND id = $2
+		`
+		list = models.ShoppingList{User: models.User{ID: userID}}
+	)
+	err := r.client.QueryRow(ctx, query, userID, id).Scan(&list.ID, &list.Name, &list.CreatedAt)
+	if errs.Is(err, pgx.ErrNoRows) {
+		return models.ShoppingList{}, fmt.Errorf("shopping list %d: %w", id, errors.ErrShoppingListNotFound)
+	}
+	if err != nil {
+		return models.ShoppingList{}, fmt.Errorf("failed to find shopping list: %w", err)
+	}
+	return list, nil
+}
+
+// Create implements ShoppingListRepositorer
+func (r *shoppingListRepository) Create(ctx context.Context, list *models.ShoppingList) error {
+	query := `
+		INSERT INTO shopping_lists (id_user, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+	if err := r.client.QueryRow(ctx, query, list.User.ID, list.Name).
+		Scan(&list.ID, &list.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create shopping list: %w", err)
+	}
+	return nil
+}
+
+// Delete implements ShoppingListRepositorer
+func (r *shoppingListRepository) Delete(ctx context.Context, userID, id int) error {
+	var (
+		queryItems = `
+			DELETE FROM shopping_lists_items
+			WHERE id_list = (SELECT id FROM shopping_lists WHERE id_user = $1 AND id = $2)
+		`
+		queryList = `
+			DELETE FROM shopping_lists
+			WHERE id_user = $1 AND id = $2
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, queryItems, userID, id); err != nil {
+		return fmt.Errorf("failed to delete shopping list items: %w", err)
+	}
+	tag, err := tx.Exec(ctx, queryList, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete shopping list: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("shopping list %d: %w", id, errors.ErrShoppingListNotFound)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// FindItems implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindItems(
+	ctx context.Context,
+	userID, id int,
+) ([]models.ShoppingListItem, error) {
+	query := itemsQuery + `
+		ORDER BY i.checked_at NULLS FIRST, p.name, i.id
+	`
+	rows, err := r.client.Query(ctx, query, userID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shopping list items: %w", err)
+	}
+	defer rows.Close()
+	items := make([]models.ShoppingListItem, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// FindItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) FindItem(
+	ctx context.Context,
+	userID, id, itemID int,
+) (models.ShoppingListItem, error) {
+	query := itemsQuery + `
+		AND i.id = $3
+	`
+	item, err := scanItem(r.client.QueryRow(ctx, query, userID, id, itemID))
+	if errs.Is(err, pgx.ErrNoRows) {
+		return models.ShoppingListItem{}, fmt.Errorf(
+			"item %d of shopping list %d: %w",
+			itemID,
+			id,
+			errors.ErrShoppingListNotFound,
+		)
+	}
+	return item, err
+}
+
+func scanItem(row pgx.Row) (models.ShoppingListItem, error) {
+	var item models.ShoppingListItem
+	if err := row.Scan(
+		&item.ID,
+		&item.ListID,
+		&item.Product.ID,
+		&item.Product.Name,
+		&item.Measure.ID,
+		&item.Measure.Name,
+		&item.Quantity,
+		&item.CheckedAt,
+		&item.ShelfLifeID,
+	); err != nil {
+		return models.ShoppingListItem{}, fmt.Errorf("failed to scan shopping list item: %w", err)
+	}
+	return item, nil
+}
+
+// CreateItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) CreateItem(
+	ctx context.Context,
+	userID int,
+	item *models.ShoppingListItem,
+) error {
+	query := `
+		INSERT INTO shopping_lists_items (id_list, id_product, id_measure, quantity)
+		SELECT l.id, $3, $4, $5
+		FROM shopping_lists l
+		WHERE l.id_user = $1 AND l.id = $2
+		RETURNING id
+	`
+	err := r.client.QueryRow(
+		ctx,
+		query,
+		userID,
+		item.ListID,
+		item.Product.ID,
+		item.Measure.ID,
+		item.Quantity,
+	).Scan(&item.ID)
+	if errs.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("shopping list %d: %w", item.ListID, errors.ErrShoppingListNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create shopping list item: %w", err)
+	}
+	return nil
+}
+
+// MergeItems implements ShoppingListRepositorer
+//
+// Quantities are added to the unchecked items of the same product and
+// measure, the other items are inserted.
+func (r *shoppingListRepository) MergeItems(
+	ctx context.Context,
+	userID, id int,
+	items []models.ShoppingListItem,
+) error {
+	var (
+		queryList = `
+			SELECT id FROM shopping_lists
+			WHERE id_user = $1 AND id = $2
+			FOR UPDATE
+		`
+		queryUpdate = `
+			UPDATE shopping_lists_items
+			SET quantity = quantity + $4
+			WHERE id = (
+				SELECT id FROM shopping_lists_items
+				WHERE id_list = $1 AND id_product = $2 AND id_measure = $3
+					AND checked_at IS NULL
+				ORDER BY id
+				LIMIT 1
+			)
+		`
+		queryInsert = `
+			INSERT INTO shopping_lists_items (id_list, id_product, id_measure, quantity)
+			VALUES ($1, $2, $3, $4)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, queryList, userID, id).Scan(&id); err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("shopping list %d: %w", id, errors.ErrShoppingListNotFound)
+		}
+		return fmt.Errorf("failed to lock shopping list: %w", err)
+	}
+	for _, item := range items {
+		tag, err := tx.Exec(ctx, queryUpdate, id, item.Product.ID, item.Measure.ID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to update shopping list item: %w", err)
+		}
+		if tag.RowsAffected() > 0 {
+			continue
+		}
+		if _, err := tx.Exec(ctx, queryInsert, id, item.Product.ID, item.Measure.ID, item.Quantity); err != nil {
+			return fmt.Errorf("failed to insert shopping list item: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+// DeleteItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) DeleteItem(ctx context.Context, userID, id, itemID int) error {
+	query := `
+		DELETE FROM shopping_lists_items i
+		USING shopping_lists l
+		WHERE l.id = i.id_list AND l.id_user = $1 AND l.id = $2 AND i.id = $3
+	`
+	tag, err := r.client.Exec(ctx, query, userID, id, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete shopping list item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("item %d of shopping list %d: %w", itemID, id, errors.ErrShoppingListNotFound)
+	}
+	return nil
+}
+
+// CheckItem implements ShoppingListRepositorer
+func (r *shoppingListRepository) CheckItem(
+	ctx context.Context,
+	userID, id, itemID int,
+	checked bool,
+) error {
+	query := `
+		UPDATE shopping_lists_items i
+		SET checked_at = CASE WHEN $4 THEN COALESCE(i.checked_at, NOW()) ELSE NULL END
+		FROM shopping_lists l
+		WHERE l.id = i.id_list AND l.id_user = $1 AND l.id = $2 AND i.id = $3
+	`
+	tag, err := r.client.Exec(ctx, query, userID, id, itemID, checked)
+	if err != nil {
+		return fmt.Errorf("failed to check shopping list item: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("item %d of shopping list %d: %w", itemID, id, errors.ErrShoppingListNotFound)
+	}
+	return nil
+}
+
+// StockItem implements ShoppingListRepositorer
+//
+// It checks the item off and creates the shelf life in one transaction.
+// Items already turned into shelf lives are reported as
+// errors.ErrItemAlreadyStocked.
+func (r *shoppingListRepository) StockItem(
+	ctx context.Context,
+	userID, id, itemID int,
+	shelfLife *models.ShelfLife,
+) error {
+	var (
+		queryItem = `
+			SELECT i.id_shelf_life IS NOT NULL
+			FROM shopping_lists_items i
+			JOIN shopping_lists l ON l.id = i.id_list
+			WHERE l.id_user = $1 AND l.id = $2 AND i.id = $3
+			FOR UPDATE OF i
+		`
+		queryShelfLife = `
+			INSERT INTO shelf_lives
+				(id_product, id_storage, id_measure, id_user, quantity, purchase_date, end_date)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`
+		queryCheck = `
+			UPDATE shopping_lists_items
+			SET checked_at = COALESCE(checked_at, NOW()),
+				id_shelf_life = $2
+			WHERE id = $1
+		`
+		stocked bool
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, queryItem, userID, id, itemID).Scan(&stocked); err != nil {
+		if errs.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("item %d of shopping list %d: %w", itemID, id, errors.ErrShoppingListNotFound)
+		}
+		return fmt.Errorf("failed to lock shopping list item: %w", err)
+	}
+	if stocked {
+		return fmt.Errorf("item %d of shopping list %d: %w", itemID, id, errors.ErrItemAlreadyStocked)
+	}
+	if err := tx.QueryRow(
+		ctx,
+		queryShelfLife,
+		shelfLife.Product.ID,
+		shelfLife.Storage.ID,
+		shelfLife.Measure.ID,
+		shelfLife.User.ID,
+		shelfLife.Quantity,
+		shelfLife.PurchaseDate,
+		shelfLife.EndDate,
+	).Scan(&shelfLife.ID); err != nil {
+		return fmt.Errorf("failed to create shelf life: %w", err)
+	}
+	if _, err := tx.Exec(ctx, queryCheck, itemID, shelfLife.ID); err != nil {
+		return fmt.Errorf("failed to check shopping list item: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
+	return nil
+}
+
+func New(client postgres.Client) ShoppingListRepositorer {
+	return &shoppingListRepository{
+		client: client,
+	}
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/romankravchuk/muerta/internal/storage/postgres"
+	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
+)
+
+type StatsRepositorer interface {
+	FindWaste(ctx context.Context, filter models.WasteFilter) ([]models.WasteRecord, error)
+}
+
+type statsRepository struct {
+	client postgres.Client
+}
+
+// FindWaste implements StatsRepositorer
+//
+// Outcomes are the shelf life events and the quantity left in shelf lives
+// after their end date, deleted or not. The consumption after the end date
+// is told apart from the one before it. Every outcome is assigned to the bucket of the time
+// it happened at and to the group of its shelf life. Shelf lives of
+// products with several categories are counted in each of them.
+func (r *statsRepository) FindWaste(
+	ctx context.Context,
+	filter models.WasteFilter,
+) ([]models.WasteRecord, error) {
+	var (
+		query = `
+			WITH outcomes AS (
+				SELECT sl.id AS id_shelf_life,
+					CASE
+						WHEN e.type = '` + models.ShelfLifeEventConsumed + `' AND e.occurred_at::date > sl.end_date
+						THEN '` + models.ShelfLifeOutcomeConsumedExpired + `'
+						ELSE e.type
+					END AS outcome,
+					e.quantity, e.occurred_at AS happened_at
+				FROM shelf_lives_events e
+				JOIN shelf_lives sl ON sl.id = e.id_shelf_life
+				WHERE $1 = 0 OR sl.id_user = $1
+				UNION ALL
+				SELECT sl.id, '` + models.ShelfLifeOutcomeExpired + `', sl.quantity, sl.end_date::timestamp
+				FROM shelf_lives sl
+				WHERE sl.quantity > 0 AND sl.end_date < CURRENT_DATE
+					AND ($1 = 0 OR sl.id_user = $1)
+			)
+			SELECT date_trunc($5::text, o.happened_at) AS bucket, g.id, g.name, m.id, m.name, o.outcome,
+				COUNT(DISTINCT o.id_shelf_life), SUM(o.quantity)
+			FROM outcomes o
+			JOIN shelf_lives sl ON sl.id = o.id_shelf_life
+			JOIN measures m ON m.id = sl.id_measure
+			JOIN LATERAL (
+				SELECT p.id, p.name FROM products p
+				WHERE $4::text = 'product' AND p.id = sl.id_product
+				UNION ALL
+				SELECT c.id, c.name FROM products_categories pc
+				JOIN categories c ON c.id = pc.id_category
+				WHERE $4::text = 'category' AND pc.id_product = sl.id_product
+				UNION ALL
+				SELECT s.id, s.name FROM storages s
+				WHERE $4::text = 'storage' AND s.id = sl.id_storage
+			) g ON TRUE
+			WHERE o.happened_at >= $2 AND o.happened_at < $3
+			GROUP BY bucket, g.id, g.name, m.id, m.name, o.outcome
+			ORDER BY bucket, g.name, g.id, m.id, o.outcome
+		`
+		result []models.WasteRecord
+	)
+	rows, err := r.client.Query(
+		ctx,
+		query,
+		filter.UserID,
+		filter.From,
+		filter.To,
+		filter.GroupBy,
+		filter.Period,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query waste: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var record models.WasteRecord
+		if err := rows.Scan(
+			&record.Bucket,
+			&record.GroupID,
+			&record.GroupName,
+			&record.Measure.ID,
+			&record.Measure.Name,
+			&record.Outcome,
+			&record.Count,
+			&record.Quantity,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan waste: %w", err)
+		}
+		result = append(result, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read waste: %w", err)
+	}
+	return result, nil
+}
+
+func New(client postgres.Client) StatsRepositorer {
+	return &statsRepository{
+		client: client,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/romankravchuk/muerta/internal/pkg/errors"
 	"github.com/romankravchuk/muerta/internal/storage/postgres"
 	"github.com/romankravchuk/muerta/internal/storage/postgres/models"
 )
@@ -11,7 +12,7 @@ import (
 type StorageRepositorer interface {
 	FindByID(ctx context.Context, id int) (models.Vault, error)
 	FindMany(ctx context.Context, filter models.StorageFilter) ([]models.Vault, error)
-	Create(ctx context.Context, storage *models.Vault) error
+	Create(ctx context.Context, storage *models.Vault, userID int) error
 	Update(ctx context.Context, storage *models.Vault) error
 	Delete(ctx context.Context, id int) error
 	Restore(ctx context.Context, id int) error
@@ -20,6 +21,8 @@ type StorageRepositorer interface {
 	FindTips(ctx context.Context, id int) ([]models.Tip, error)
 	FindShelfLives(ctx context.Context, id int) ([]models.ShelfLife, error)
 	Count(ctx context.Context, filter models.StorageFilter) (int, error)
+	IsAccessible(ctx context.Context, id, userID int) (bool, error)
+	IsWritable(ctx context.Context, id, userID int) (bool, error)
 }
 
 type storageRepository struct {
@@ -36,7 +39,7 @@ func (r *storageRepository) FindShelfLives(
 			FROM shelf_lives sl
 			JOIN products p ON p.id = sl.id_product
 			JOIN measures m ON m.id = sl.id_measure
-			WHERE sl.deleted_at IS NULL AND sl.id_storage = $1
+			WHERE sl.deleted_at IS NULL AND sl.closed_at IS NULL AND sl.id_storage = $1
 			ORDER BY sl.end_date DESC, sl.purchase_date DESC
 		`
 		result []models.ShelfLife
@@ -71,11 +74,12 @@ func (r *storageRepository) Count(ctx context.Context, filter models.StorageFilt
 			SELECT COUNT(*) 
 			FROM storages 
 			WHERE deleted_at IS NULL AND
-				name ILIKE $1
+				name ILIKE $1 AND
+				($2 = 0 OR id IN (` + postgres.AccessibleStorages("$2", true) + `))
 		`
 		count int
 	)
-	if err := r.client.QueryRow(ctx, query, "%"+filter.Name+"%").Scan(&count); err != nil {
+	if err := r.client.QueryRow(ctx, query, "%"+filter.Name+"%", filter.ViewerID).Scan(&count); err != nil {
 		return 0, fmt.Errorf("failed to count storages: %w", err)
 	}
 	return count, nil
@@ -182,12 +186,13 @@ func (r *storageRepository) FindMany(
 			JOIN storages_types st ON st.id = s.id_type
 			WHERE s.deleted_at IS NULL
 				AND s.name ILIKE $3
+				AND ($4 = 0 OR s.id IN (` + postgres.AccessibleStorages("$4", true) + `))
 			ORDER BY s.created_at DESC
 			LIMIT $1 OFFSET $2
 		`
 		storages []models.Vault
 	)
-	rows, err := r.client.Query(ctx, query, filter.Limit, filter.Offset, "%"+filter.Name+"%")
+	rows, err := r.client.Query(ctx, query, filter.Limit, filter.Offset, "%"+filter.Name+"%", filter.ViewerID)
 	if err != nil {
 		return nil, fmt.Errorf("find many storages: %w", err)
 	}
@@ -202,20 +207,68 @@ func (r *storageRepository) FindMany(
 	return storages, nil
 }
 
-func (r *storageRepository) Create(ctx context.Context, storage *models.Vault) error {
-	query := `
+// Create inserts the storage and shares it with the user unless userID is zero.
+func (r *storageRepository) Create(ctx context.Context, storage *models.Vault, userID int) error {
+	var (
+		query = `
 			INSERT INTO storages 
 				(name, temperature, humidity, id_type)
 			VALUES
 				($1, $2, $3, $4)
 			RETURNING id
 		`
-	if _, err := r.client.Exec(ctx, query, storage.Name, storage.Temperature, storage.Humidity, storage.Type.ID); err != nil {
+		queryShare = `
+			INSERT INTO users_storages (id_user, id_storage)
+			VALUES ($1, $2)
+		`
+	)
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.ErrFailedToBeginTransaction.With(err)
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.QueryRow(ctx, query, storage.Name, storage.Temperature, storage.Humidity, storage.Type.ID).Scan(&storage.ID); err != nil {
 		return fmt.Errorf("create storage: %w", err)
 	}
+	if userID != 0 {
+		if _, err := tx.Exec(ctx, queryShare, userID, storage.ID); err != nil {
+			return fmt.Errorf("share storage: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.ErrFailedToCommitTransaction.With(err)
+	}
 	return nil
 }
 
+// IsAccessible implements StorageRepositorer
+//
+// A storage is accessible to the users it is shared with and to the members
+// of the household owning it.
+func (r *storageRepository) IsAccessible(ctx context.Context, id, userID int) (bool, error) {
+	return r.isAccessible(ctx, id, userID, true)
+}
+
+// IsWritable implements StorageRepositorer
+//
+// It is IsAccessible without the household viewers.
+func (r *storageRepository) IsWritable(ctx context.Context, id, userID int) (bool, error) {
+	return r.isAccessible(ctx, id, userID, false)
+}
+
+func (r *storageRepository) isAccessible(ctx context.Context, id, userID int, read bool) (bool, error) {
+	var (
+		query = `
+			SELECT $1 IN (` + postgres.AccessibleStorages("$2", read) + `)
+		`
+		ok bool
+	)
+	if err := r.client.QueryRow(ctx, query, id, userID).Scan(&ok); err != nil {
+		return false, fmt.Errorf("check storage access: %w", err)
+	}
+	return ok, nil
+}
+
 func (r *storageRepository) Update(ctx context.Context, storage *models.Vault) error {
 	query := `
 			UPDATE storages
@@ -184,11 +184,18 @@ func (r *tipRepository) FindStorages(ctx context.Context, id int) ([]models.Vaul
 // Create implements TipRepositorer
 func (r *tipRepository) Create(ctx context.Context, tip *models.Tip) error {
 	query := `
-			INSERT INTO tips (description)
-			VALUES ($1)
+			INSERT INTO tips (description, expires_within, temperature_above, temperature_below)
+			VALUES ($1, $2, $3, $4)
 			RETURNING id
 		`
-	if err := r.client.QueryRow(ctx, query, tip.Description).Scan(&tip.ID); err != nil {
+	if err := r.client.QueryRow(
+		ctx,
+		query,
+		tip.Description,
+		tip.Rule.ExpiresWithin,
+		tip.Rule.TemperatureAbove,
+		tip.Rule.TemperatureBelow,
+	).Scan(&tip.ID); err != nil {
 		return fmt.Errorf("failed to create tip: %w", err)
 	}
 	return nil
@@ -211,14 +218,20 @@ func (r *tipRepository) Delete(ctx context.Context, id int) error {
 func (r *tipRepository) FindByID(ctx context.Context, id int) (models.Tip, error) {
 	var (
 		query = `
-			SELECT id, description
+			SELECT id, description, expires_within, temperature_above, temperature_below
 			FROM tips
 			WHERE id = $1
 			LIMIT 1	
 		`
 		tip models.Tip
 	)
-	if err := r.client.QueryRow(ctx, query, id).Scan(&tip.ID, &tip.Description); err != nil {
+	if err := r.client.QueryRow(ctx, query, id).Scan(
+		&tip.ID,
+		&tip.Description,
+		&tip.Rule.ExpiresWithin,
+		&tip.Rule.TemperatureAbove,
+		&tip.Rule.TemperatureBelow,
+	); err != nil {
 		return models.Tip{}, fmt.Errorf("failed to find tip: %w", err)
 	}
 	return tip, nil
@@ -231,7 +244,7 @@ func (r *tipRepository) FindMany(
 ) ([]models.Tip, error) {
 	var (
 		query = `
-			SELECT id, description
+			SELECT id, description, expires_within, temperature_above, temperature_below
 			FROM tips
 			WHERE description ILIKE $1 AND
 				deleted_at IS NULL
@@ -247,7 +260,13 @@ func (r *tipRepository) FindMany(
 	defer rows.Close()
 	for rows.Next() {
 		var tip models.Tip
-		if err := rows.Scan(&tip.ID, &tip.Description); err != nil {
+		if err := rows.Scan(
+			&tip.ID,
+			&tip.Description,
+			&tip.Rule.ExpiresWithin,
+			&tip.Rule.TemperatureAbove,
+			&tip.Rule.TemperatureBelow,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan tip: %w", err)
 		}
 		tips = append(tips, tip)
@@ -272,10 +291,21 @@ func (r *tipRepository) Restore(ctx context.Context, id int) error {
 func (r *tipRepository) Update(ctx context.Context, tip models.Tip) error {
 	query := `
 			UPDATE tips
-			SET description = $1
-			WHERE id = $2
+			SET description = $1,
+				expires_within = $2,
+				temperature_above = $3,
+				temperature_below = $4
+			WHERE id = $5
 		`
-	if _, err := r.client.Exec(ctx, query, tip.Description, tip.ID); err != nil {
+	if _, err := r.client.Exec(
+		ctx,
+		query,
+		tip.Description,
+		tip.Rule.ExpiresWithin,
+		tip.Rule.TemperatureAbove,
+		tip.Rule.TemperatureBelow,
+		tip.ID,
+	); err != nil {
 		return fmt.Errorf("failed to update tip: %w", err)
 	}
 	return nil
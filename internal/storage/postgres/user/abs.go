@@ -20,6 +20,7 @@ type UserStorage interface {
 	UserVaultStorage
 	UserShelfLifeStorage
 	UserSettingStorage
+	UserCalendarStorage
 }
 
 type UserPasswordStorage interface {
@@ -34,6 +35,7 @@ type UserVaultStorage interface {
 	RemoveVault(ctx context.Context, id, storageID int) error
 	AddVault(ctx context.Context, id, storageID int) (models.Vault, error)
 	FindVaults(ctx context.Context, id int) ([]models.Vault, error)
+	HasVault(ctx context.Context, id, storageID int) (bool, error)
 }
 
 type UserSettingStorage interface {
@@ -49,3 +51,11 @@ type UserShelfLifeStorage interface {
 	DeleteShelfLife(ctx context.Context, userId int, shelfLifeId int) error
 	RestoreShelfLife(ctx context.Context, userId int, shelfLifeId int) (models.ShelfLife, error)
 }
+
+// UserCalendarStorage keeps hashes of the tokens that grant access to the
+// calendar feeds of the users. Each user has at most one token.
+type UserCalendarStorage interface {
+	SaveCalendarToken(ctx context.Context, id int, hash string) error
+	DeleteCalendarToken(ctx context.Context, id int) error
+	FindByCalendarToken(ctx context.Context, hash string) (models.User, error)
+}
@@ -1,5 +1,7 @@
 package user
 
+import "github.com/romankravchuk/muerta/internal/storage/postgres"
+
 const (
 	countUsers = `
 		SELECT COUNT(*)
@@ -53,7 +55,8 @@ const (
 		JOIN storages s ON sl.id_storage = s.id
 		JOIN measures m ON sl.id_measure = m.id
 		WHERE sl.id_user = $1 AND 
-			sl.deleted_at IS NULL
+			sl.deleted_at IS NULL AND
+			sl.closed_at IS NULL
 		ORDER BY sl.end_date DESC
 	`
 	restoreShelfLife = `
@@ -61,7 +64,7 @@ const (
 			UPDATE shelf_lives
 			SET deleted_at = NULL,
 				updated_at = NOW()
-			WHERE id_user = $1 AND id = $2
+			WHERE id_user = $1 AND id = $2 AND closed_at IS NULL
 			RETURNING id, id_product, id_storage, id_measure, quantity, purchase_date, end_date
 		)
 		SELECT 
@@ -73,7 +76,7 @@ const (
 		JOIN storages s ON u.id_storage = s.id
 		JOIN measures m ON u.id_measure = m.id
 		WHERE p.deleted_at IS NULL AND
-			s.deleted_at IS NULL AND
+			s.deleted_at IS NULL
 		LIMIT 1
 	`
 	updateShelfLife = `
@@ -98,7 +101,7 @@ const (
 		JOIN storages s ON u.id_storage = s.id
 		JOIN measures m ON u.id_measure = m.id
 		WHERE p.deleted_at IS NULL AND
-			s.deleted_at IS NULL AND
+			s.deleted_at IS NULL
 		LIMIT 1
 	`
 	addVault = `
@@ -116,17 +119,16 @@ const (
 			s.deleted_at IS NULL
 		LIMIT 1
 	`
+	hasVault = `
+		SELECT EXISTS (
+			SELECT 1 FROM users_storages
+			WHERE id_user = $1 AND id_storage = $2
+		)
+	`
 	removeVault = `
 		DELETE FROM users_storages
 		WHERE id_user = $1 AND id_storage = $2
 	`
-	findVaults = `
-		SELECT s.id, s.name, st.name, s.temperature, s.humidity
-		FROM storages s
-		JOIN storages_types st ON s.id_type = st.id
-		JOIN users_storages us ON us.id_storage = s.id
-		WHERE us.id_user = $1
-	`
 	findRoles = `
 		SELECT r.id, r.name
 		FROM roles r
@@ -201,4 +203,30 @@ const (
 		JOIN settings_categories sc ON s.id_category = sc.id
 		WHERE us.id_user = $1
 	`
+	saveCalendarToken = `
+		INSERT INTO users_calendar_tokens (id_user, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (id_user) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash,
+			created_at = NOW()
+	`
+	deleteCalendarToken = `
+		DELETE FROM users_calendar_tokens
+		WHERE id_user = $1
+	`
+	findByCalendarToken = `
+		SELECT u.id, u.name
+		FROM users u
+		JOIN users_calendar_tokens ct ON ct.id_user = u.id
+		WHERE ct.token_hash = $1 AND u.deleted_at IS NULL
+	`
 )
+
+// findVaults selects the storages shared with the user and the ones of the
+// households the user is a member of.
+var findVaults = `
+	SELECT s.id, s.name, st.name, s.temperature, s.humidity
+	FROM storages s
+	JOIN storages_types st ON s.id_type = st.id
+	WHERE s.id IN (` + postgres.AccessibleStorages("$1", true) + `)
+`
@@ -140,6 +140,18 @@ func (s *userStorage) AddVault(ctx context.Context, id, vaultId int) (models.Vau
 	return vault, nil
 }
 
+// HasVault implements UserRepositorer
+//
+// Only the storages shared with the user directly are reported, not the ones
+// of the households of the user.
+func (s *userStorage) HasVault(ctx context.Context, id, vaultId int) (bool, error) {
+	var ok bool
+	if err := s.c.QueryRow(ctx, hasVault, id, vaultId).Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check storage: %w", err)
+	}
+	return ok, nil
+}
+
 // RemoveVault implements UserRepositorer
 func (s *userStorage) RemoveVault(ctx context.Context, id, vaultId int) error {
 	if _, err := s.c.Exec(ctx, removeVault, id, vaultId); err != nil {
@@ -342,3 +354,28 @@ func (repo *userStorage) Restore(ctx context.Context, id int) error {
 	}
 	return nil
 }
+
+// SaveCalendarToken implements UserCalendarStorage
+func (s *userStorage) SaveCalendarToken(ctx context.Context, id int, hash string) error {
+	if _, err := s.c.Exec(ctx, saveCalendarToken, id, hash); err != nil {
+		return fmt.Errorf("failed to save calendar token: %w", err)
+	}
+	return nil
+}
+
+// DeleteCalendarToken implements UserCalendarStorage
+func (s *userStorage) DeleteCalendarToken(ctx context.Context, id int) error {
+	if _, err := s.c.Exec(ctx, deleteCalendarToken, id); err != nil {
+		return fmt.Errorf("failed to delete calendar token: %w", err)
+	}
+	return nil
+}
+
+// FindByCalendarToken implements UserCalendarStorage
+func (s *userStorage) FindByCalendarToken(ctx context.Context, hash string) (models.User, error) {
+	user := models.User{}
+	if err := s.c.QueryRow(ctx, findByCalendarToken, hash).Scan(&user.ID, &user.Name); err != nil {
+		return models.User{}, fmt.Errorf("failed to find user by calendar token: %w", err)
+	}
+	return user, nil
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notifications_deliveries;
+DROP TABLE IF EXISTS notifications;
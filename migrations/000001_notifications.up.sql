@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    id_user INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    id_shelf_life INT NOT NULL REFERENCES shelf_lives (id) ON DELETE CASCADE,
+    threshold INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_id_user_idx ON notifications (id_user, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS notifications_deliveries (
+    id_user INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    id_shelf_life INT NOT NULL REFERENCES shelf_lives (id) ON DELETE CASCADE,
+    threshold INT NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    delivered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id_user, id_shelf_life, threshold, channel)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS shelf_lives_events;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS shelf_lives_events (
+    id SERIAL PRIMARY KEY,
+    id_shelf_life INT NOT NULL REFERENCES shelf_lives (id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL CHECK (type IN ('consumed', 'discarded', 'given_away')),
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS shelf_lives_events_id_shelf_life_idx ON shelf_lives_events (id_shelf_life);
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS users_calendar_tokens (
+    id_user INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
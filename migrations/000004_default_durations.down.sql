@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS categories_durations;
+DROP TABLE IF EXISTS products_durations;
@@ -0,0 +1,20 @@
+-- The durations without a storage type apply to the storages of any type.
+CREATE TABLE IF NOT EXISTS products_durations (
+    id SERIAL PRIMARY KEY,
+    id_product INT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    id_type INT REFERENCES storages_types (id) ON DELETE CASCADE,
+    days INT NOT NULL CHECK (days > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS products_durations_type_idx
+    ON products_durations (id_product, COALESCE(id_type, 0));
+
+CREATE TABLE IF NOT EXISTS categories_durations (
+    id SERIAL PRIMARY KEY,
+    id_category INT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    id_type INT REFERENCES storages_types (id) ON DELETE CASCADE,
+    days INT NOT NULL CHECK (days > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS categories_durations_type_idx
+    ON categories_durations (id_category, COALESCE(id_type, 0));
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS shopping_lists (
+    id SERIAL PRIMARY KEY,
+    id_user INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS shopping_lists_id_user_idx ON shopping_lists (id_user);
+
+-- The shelf life is the one the checked item was stocked as.
+CREATE TABLE IF NOT EXISTS shopping_lists_items (
+    id SERIAL PRIMARY KEY,
+    id_list INT NOT NULL REFERENCES shopping_lists (id) ON DELETE CASCADE,
+    id_product INT NOT NULL REFERENCES products (id),
+    id_measure INT NOT NULL REFERENCES measures (id),
+    quantity REAL NOT NULL CHECK (quantity > 0),
+    checked_at TIMESTAMPTZ,
+    id_shelf_life INT REFERENCES shelf_lives (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS shopping_lists_items_id_list_idx ON shopping_lists_items (id_list);
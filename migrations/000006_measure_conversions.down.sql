@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS products_conversions;
+
+ALTER TABLE measures
+    DROP COLUMN IF EXISTS factor,
+    DROP COLUMN IF EXISTS dimension;
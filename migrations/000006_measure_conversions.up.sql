@@ -0,0 +1,12 @@
+-- The factor is the size of the measure in the base unit of its dimension:
+-- grams, millilitres or pieces.
+ALTER TABLE measures
+    ADD COLUMN IF NOT EXISTS dimension VARCHAR(16) CHECK (dimension IN ('mass', 'volume', 'count')),
+    ADD COLUMN IF NOT EXISTS factor REAL CHECK (factor > 0);
+
+-- Density is grams per millilitre and piece weight is grams per piece.
+CREATE TABLE IF NOT EXISTS products_conversions (
+    id_product INT PRIMARY KEY REFERENCES products (id) ON DELETE CASCADE,
+    density REAL CHECK (density > 0),
+    piece_weight REAL CHECK (piece_weight > 0)
+);
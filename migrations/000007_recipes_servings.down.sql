@@ -0,0 +1,5 @@
+ALTER TABLE products_recipes_measures
+    ALTER COLUMN quantity TYPE INT USING ROUND(quantity);
+
+ALTER TABLE recipes
+    DROP COLUMN IF EXISTS servings;
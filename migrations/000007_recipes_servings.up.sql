@@ -0,0 +1,6 @@
+ALTER TABLE recipes
+    ADD COLUMN IF NOT EXISTS servings INT NOT NULL DEFAULT 1 CHECK (servings > 0);
+
+-- The quantities of the ingredients are scaled to the servings.
+ALTER TABLE products_recipes_measures
+    ALTER COLUMN quantity TYPE REAL;
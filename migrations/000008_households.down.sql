@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS households_invitations;
+DROP TABLE IF EXISTS households_storages;
+DROP TABLE IF EXISTS households_members;
+DROP TABLE IF EXISTS households;
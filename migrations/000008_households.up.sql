@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS households (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS households_members (
+    id_household INT NOT NULL REFERENCES households (id) ON DELETE CASCADE,
+    id_user INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id_household, id_user)
+);
+
+CREATE INDEX IF NOT EXISTS households_members_id_user_idx ON households_members (id_user);
+
+-- A storage belongs to one household at most.
+CREATE TABLE IF NOT EXISTS households_storages (
+    id_household INT NOT NULL REFERENCES households (id) ON DELETE CASCADE,
+    id_storage INT PRIMARY KEY REFERENCES storages (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS households_storages_id_household_idx ON households_storages (id_household);
+
+-- Only the hash of the invitation code is kept.
+CREATE TABLE IF NOT EXISTS households_invitations (
+    id SERIAL PRIMARY KEY,
+    id_household INT NOT NULL REFERENCES households (id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
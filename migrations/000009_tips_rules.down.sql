@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS categories_tips;
+
+ALTER TABLE tips
+    DROP COLUMN IF EXISTS temperature_below,
+    DROP COLUMN IF EXISTS temperature_above,
+    DROP COLUMN IF EXISTS expires_within;
@@ -0,0 +1,13 @@
+-- The rules limit a tip to the shelf lives expiring within the days or kept
+-- at a temperature above or below the bounds. A tip without them applies to
+-- any shelf life.
+ALTER TABLE tips
+    ADD COLUMN IF NOT EXISTS expires_within INT CHECK (expires_within >= 0),
+    ADD COLUMN IF NOT EXISTS temperature_above REAL,
+    ADD COLUMN IF NOT EXISTS temperature_below REAL;
+
+CREATE TABLE IF NOT EXISTS categories_tips (
+    id_category INT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    id_tip INT NOT NULL REFERENCES tips (id) ON DELETE CASCADE,
+    PRIMARY KEY (id_category, id_tip)
+);
@@ -0,0 +1,20 @@
+-- The image is dropped once the job is finished and the job is deleted after
+-- the retention. A running job is taken over once it is not finished until
+-- locked_until.
+CREATE TABLE IF NOT EXISTS detection_jobs (
+    id SERIAL PRIMARY KEY,
+    id_user INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    image BYTEA,
+    callback_url TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    result JSONB,
+    error TEXT,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS detection_jobs_run_at_idx ON detection_jobs (status, run_at);
+CREATE INDEX IF NOT EXISTS detection_jobs_finished_at_idx ON detection_jobs (finished_at);
@@ -0,0 +1,9 @@
+-- The photo a shelf life was scanned from with the dates detected on it.
+CREATE TABLE IF NOT EXISTS shelf_lives_scans (
+    id SERIAL PRIMARY KEY,
+    id_shelf_life INT NOT NULL UNIQUE REFERENCES shelf_lives (id) ON DELETE CASCADE,
+    image BYTEA NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    dates JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS detections;
+DROP TABLE IF EXISTS detection_images;
@@ -0,0 +1,24 @@
+-- The images are kept once by their SHA-256 hash however many times they are
+-- detected.
+CREATE TABLE IF NOT EXISTS detection_images (
+    hash CHAR(64) PRIMARY KEY,
+    image BYTEA NOT NULL,
+    content_type VARCHAR(100) NOT NULL
+);
+
+-- The dates are the detected ones, manufactured_date and expiry_date the
+-- ones corrected by the user.
+CREATE TABLE IF NOT EXISTS detections (
+    id SERIAL PRIMARY KEY,
+    id_user INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    image_hash CHAR(64) NOT NULL REFERENCES detection_images (hash),
+    text TEXT NOT NULL,
+    dates JSONB,
+    latency_ms BIGINT NOT NULL,
+    manufactured_date DATE,
+    expiry_date DATE,
+    corrected_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS detections_id_user_idx ON detections (id_user, created_at DESC);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS detection_jobs_callback_at_idx;
+
+ALTER TABLE detection_jobs
+    DROP COLUMN IF EXISTS callback_at,
+    DROP COLUMN IF EXISTS callback_error,
+    DROP COLUMN IF EXISTS callback_attempts,
+    DROP COLUMN IF EXISTS callback_status;
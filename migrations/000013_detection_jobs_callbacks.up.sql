@@ -0,0 +1,10 @@
+-- The callback of a finished job is pending until it is delivered or has
+-- failed all of its attempts. A pending callback is held by a worker until
+-- callback_at and is retried from then on.
+ALTER TABLE detection_jobs
+    ADD COLUMN IF NOT EXISTS callback_status VARCHAR(16) CHECK (callback_status IN ('pending', 'delivered', 'failed')),
+    ADD COLUMN IF NOT EXISTS callback_attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS callback_error TEXT,
+    ADD COLUMN IF NOT EXISTS callback_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS detection_jobs_callback_at_idx ON detection_jobs (callback_status, callback_at);
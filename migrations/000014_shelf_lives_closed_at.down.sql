@@ -0,0 +1,6 @@
+UPDATE shelf_lives
+SET deleted_at = closed_at
+WHERE closed_at IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE shelf_lives
+    DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,11 @@
+-- A shelf life is closed once its quantity is used up. Closed shelf lives
+-- are kept apart from the deleted ones, which can be restored.
+ALTER TABLE shelf_lives
+    ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+
+-- The used up shelf lives used to be deleted.
+UPDATE shelf_lives sl
+SET closed_at = sl.deleted_at, deleted_at = NULL
+WHERE sl.deleted_at IS NOT NULL AND sl.quantity = 0 AND EXISTS (
+    SELECT 1 FROM shelf_lives_events e WHERE e.id_shelf_life = sl.id
+);